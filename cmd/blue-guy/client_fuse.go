@@ -6,18 +6,36 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"strings"
 
 	"github.com/victorarias/blue-guy/internal/client"
+	"github.com/victorarias/blue-guy/internal/config"
+	"github.com/victorarias/blue-guy/internal/identity"
 )
 
-func runClient(ctx context.Context, connect string) {
-	addr := connect
-	if !strings.Contains(addr, ":") {
-		addr += ":7654"
+// runDaemon runs a client process until ctx ends or it's stopped. With an
+// address it mounts workspaces of that host and exits once the mounts are
+// gone, as a join in the foreground; without, it's the background daemon
+// that joins mount into. force mounts over directories with files in them.
+func runDaemon(ctx context.Context, cfg *config.Config, load func() (*config.Config, error), id identity.Identity, addr string, workspaces []string, force bool) {
+	d := client.NewDaemon(id, client.DaemonOptions{
+		Client:        client.Options{Timeout: cfg.Network.Timeout},
+		Background:    addr == "",
+		ExitWhenEmpty: addr != "",
+	})
+	d.SetReloader(reloader(cfg, load, config.ClientLive, func(cfg *config.Config) {
+		d.Reconfigure(client.Options{Timeout: cfg.Network.Timeout})
+	}))
+	if addr != "" {
+		for _, ws := range workspaces {
+			if _, err := d.Join(addr, ws, id, force); err != nil {
+				d.Stop()
+				d.Run(ctx)
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
 	}
-	c := client.New(addr)
-	if err := c.Start(ctx); err != nil {
+	if err := d.Run(ctx); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
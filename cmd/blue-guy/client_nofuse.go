@@ -6,9 +6,12 @@ import (
 	"context"
 	"fmt"
 	"os"
+
+	"github.com/victorarias/blue-guy/internal/config"
+	"github.com/victorarias/blue-guy/internal/identity"
 )
 
-func runClient(_ context.Context, _ string) {
+func runDaemon(context.Context, *config.Config, func() (*config.Config, error), identity.Identity, string, []string, bool) {
 	fmt.Fprintln(os.Stderr, "Client mode requires CGO and FUSE.")
 	fmt.Fprintln(os.Stderr, "On macOS: brew install fuse-t")
 	fmt.Fprintln(os.Stderr, "Then build with: CGO_ENABLED=1 go build ./cmd/blue-guy")
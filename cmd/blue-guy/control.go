@@ -0,0 +1,466 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/victorarias/blue-guy/internal/control"
+	pb "github.com/victorarias/blue-guy/internal/proto/gen"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const controlTimeout = 5 * time.Second
+
+// The management commands find the local host or client through its
+// control socket. With several running, --pid picks one.
+
+func runClients(args []string) {
+	fs := flag.NewFlagSet("clients", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Print JSON")
+	pid := fs.Int("pid", 0, "Host process to ask, when several are running")
+	fs.Parse(args)
+
+	ctl, done := dialLocal(control.RoleHost, *pid)
+	defer done()
+	ctx, cancel := context.WithTimeout(context.Background(), controlTimeout)
+	defer cancel()
+
+	resp, err := ctl.ListClients(ctx, &pb.ListClientsRequest{})
+	if err != nil {
+		fail(err)
+	}
+	if *asJSON {
+		printJSON(resp)
+		return
+	}
+	if len(resp.Clients) == 0 {
+		fmt.Println("No clients connected")
+		return
+	}
+	for _, c := range resp.Clients {
+		who := c.Name
+		if c.Email != "" {
+			who += " <" + c.Email + ">"
+		}
+		if who == "" {
+			who = "(anonymous)"
+		}
+		fmt.Printf("%-40s %-22s connected %s ago\n", who, c.Addr, since(c.ConnectedUnix))
+	}
+}
+
+func runCheckpoint(args []string) {
+	fs := flag.NewFlagSet("checkpoint", flag.ExitOnError)
+	message := fs.String("m", "", "Commit message (default: generated from the changes)")
+	ws := fs.String("workspace", "", "Host workspace to commit, when it shares several (default: the one we're in)")
+	asJSON := fs.Bool("json", false, "Print JSON")
+	pid := fs.Int("pid", 0, "Process to ask, when several are running")
+	fs.Parse(args)
+
+	// A host commits its own workspace; a client asks its host to
+	role := ""
+	if *pid == 0 {
+		role = control.RoleHost
+		if len(control.Find(control.RoleHost)) == 0 {
+			role = control.RoleClient
+		}
+	}
+	ctl, done := dialLocal(role, *pid)
+	defer done()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// A host sharing several workspaces, or a client with several mounts,
+	// commits the one we're in
+	cwd, _ := os.Getwd()
+	if *ws == "" {
+		*ws = cwd
+	}
+	resp, err := ctl.Checkpoint(ctx, &pb.CheckpointRequest{Message: *message, Mount: cwd, Workspace: *ws})
+	if err != nil {
+		fail(err)
+	}
+	switch {
+	case *asJSON:
+		printJSON(resp)
+	case resp.Commit == "":
+		fmt.Println("Nothing to commit")
+	default:
+		fmt.Printf("Committed %.12s\n", resp.Commit)
+	}
+}
+
+func runStop(args []string) {
+	fs := flag.NewFlagSet("stop", flag.ExitOnError)
+	reason := fs.String("reason", "", "Why, for the host's log")
+	asJSON := fs.Bool("json", false, "Print JSON")
+	pid := fs.Int("pid", 0, "Host process to stop, when several are running")
+	fs.Parse(args)
+
+	ctl, done := dialLocal(control.RoleHost, *pid)
+	defer done()
+	stop(ctl, *reason, *asJSON, "Stopping host")
+}
+
+// runLeave implements `blue-guy leave [mount]` (or unmount): unmount one
+// workspace, whichever client process has it.
+func runLeave(args []string) {
+	fs := flag.NewFlagSet("leave", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: blue-guy leave [flags] [mount path or host]")
+		fs.PrintDefaults()
+	}
+	all := fs.Bool("all", false, "Unmount everything and stop the client daemon")
+	asJSON := fs.Bool("json", false, "Print JSON")
+	fs.Parse(args)
+
+	if *all {
+		for _, ep := range control.Find(control.RoleClient) {
+			ctl, closeConn, err := control.Dial(ep.Path)
+			if err != nil {
+				fail(err)
+			}
+			stop(ctl, "leave --all", *asJSON, fmt.Sprintf("Unmounting everything in %d", ep.PID))
+			closeConn()
+		}
+		return
+	}
+
+	which := fs.Arg(0)
+	if _, err := os.Stat(which); err == nil {
+		if abs, err := filepath.Abs(which); err == nil {
+			which = abs
+		}
+	}
+	var found []localMount
+	for _, m := range localMounts() {
+		if which == "" || which == m.Mount || which == m.Addr || which == hostOf(m.Addr) {
+			found = append(found, m)
+		}
+	}
+	switch {
+	case len(found) == 0:
+		fmt.Fprintln(os.Stderr, "Error: no matching workspace is mounted")
+		os.Exit(1)
+	case len(found) > 1 && which == "":
+		fmt.Fprintln(os.Stderr, "Error: several workspaces are mounted; say which:")
+		for _, m := range found {
+			fmt.Fprintf(os.Stderr, "  %s  (%s)\n", m.Mount, m.Addr)
+		}
+		os.Exit(1)
+	}
+
+	// Naming a host leaves every workspace mounted from it
+	for _, m := range found {
+		leave(m, *asJSON)
+	}
+}
+
+func leave(m localMount, asJSON bool) {
+	ctl, closeConn, err := control.Dial(m.endpoint.Path)
+	if err != nil {
+		fail(err)
+	}
+	defer closeConn()
+	ctx, cancel := context.WithTimeout(context.Background(), controlTimeout)
+	defer cancel()
+	resp, err := ctl.Leave(ctx, &pb.LeaveRequest{Mount: m.Mount})
+	if err != nil {
+		fail(err)
+	}
+	if asJSON {
+		printJSON(resp)
+		return
+	}
+	fmt.Printf("Unmounting %s\n", m.Mount)
+}
+
+// runList implements `blue-guy list`: every workspace mounted on this
+// machine.
+func runList(args []string) {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Print JSON")
+	fs.Parse(args)
+
+	mounts := localMounts()
+	if *asJSON {
+		resp := &pb.ListMountsResponse{}
+		for _, m := range mounts {
+			resp.Mounts = append(resp.Mounts, m.MountInfo)
+		}
+		printJSON(resp)
+		return
+	}
+	if len(mounts) == 0 {
+		fmt.Println("Nothing mounted")
+		return
+	}
+	for _, m := range mounts {
+		fmt.Printf("%-40s %-22s up %s\n", m.Mount, m.Addr, since(m.StartedUnix))
+	}
+}
+
+// localMount is a workspace mounted by one of this machine's clients.
+type localMount struct {
+	*pb.MountInfo
+	endpoint control.Endpoint
+}
+
+func localMounts() []localMount {
+	var out []localMount
+	for _, ep := range control.Find(control.RoleClient) {
+		st, err := statusOf(ep)
+		if err != nil {
+			continue
+		}
+		for _, m := range st.Mounts {
+			out = append(out, localMount{MountInfo: m, endpoint: ep})
+		}
+	}
+	return out
+}
+
+// hostOf is the host part of host:port.
+func hostOf(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
+
+func stop(ctl pb.ControlServiceClient, reason string, asJSON bool, action string) {
+	ctx, cancel := context.WithTimeout(context.Background(), controlTimeout)
+	defer cancel()
+	resp, err := ctl.Stop(ctx, &pb.StopRequest{Reason: reason})
+	if err != nil {
+		fail(err)
+	}
+	if asJSON {
+		printJSON(resp)
+		return
+	}
+	fmt.Println(action)
+}
+
+func runKick(args []string) {
+	fs := flag.NewFlagSet("kick", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: blue-guy kick [flags] <name, email or address>")
+		fs.PrintDefaults()
+	}
+	reason := fs.String("reason", "", "Message shown to the client")
+	asJSON := fs.Bool("json", false, "Print JSON")
+	pid := fs.Int("pid", 0, "Host process to ask, when several are running")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	ctl, done := dialLocal(control.RoleHost, *pid)
+	defer done()
+	ctx, cancel := context.WithTimeout(context.Background(), controlTimeout)
+	defer cancel()
+
+	resp, err := ctl.KickClient(ctx, &pb.KickClientRequest{Client: fs.Arg(0), Reason: *reason})
+	if err != nil {
+		fail(err)
+	}
+	if *asJSON {
+		printJSON(resp)
+		return
+	}
+	for _, c := range resp.Kicked {
+		fmt.Printf("Kicked %s (%s)\n", fs.Arg(0), c.Addr)
+	}
+}
+
+func runReload(args []string) {
+	fs := flag.NewFlagSet("reload", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Print JSON")
+	pid := fs.Int("pid", 0, "Process to reload, when several are running")
+	fs.Parse(args)
+
+	ctl, done := dialLocal("", *pid)
+	defer done()
+	ctx, cancel := context.WithTimeout(context.Background(), controlTimeout)
+	defer cancel()
+
+	resp, err := ctl.ReloadConfig(ctx, &pb.ReloadConfigRequest{})
+	if err != nil {
+		fail(err)
+	}
+	if *asJSON {
+		printJSON(resp)
+		return
+	}
+	if len(resp.Applied)+len(resp.Restart) == 0 {
+		fmt.Println("No settings changed")
+	}
+	if len(resp.Applied) > 0 {
+		fmt.Printf("Applied: %s\n", strings.Join(resp.Applied, ", "))
+	}
+	if len(resp.Restart) > 0 {
+		fmt.Printf("Need a restart: %s\n", strings.Join(resp.Restart, ", "))
+	}
+}
+
+func runLogLevel(args []string) {
+	fs := flag.NewFlagSet("log-level", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: blue-guy log-level [flags] <trace|debug|info|warn|error|disabled>")
+		fs.PrintDefaults()
+	}
+	asJSON := fs.Bool("json", false, "Print JSON")
+	pid := fs.Int("pid", 0, "Process to change, when several are running")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	ctl, done := dialLocal("", *pid)
+	defer done()
+	ctx, cancel := context.WithTimeout(context.Background(), controlTimeout)
+	defer cancel()
+
+	resp, err := ctl.SetLogLevel(ctx, &pb.SetLogLevelRequest{Level: fs.Arg(0)})
+	if err != nil {
+		fail(err)
+	}
+	if *asJSON {
+		printJSON(resp)
+		return
+	}
+	fmt.Printf("Log level %s (was %s)\n", resp.Level, resp.Previous)
+}
+
+// localStatus implements `blue-guy status` without an address: every host
+// and client running on this machine.
+func localStatus(asJSON bool) {
+	var all []*pb.ControlStatus
+	for _, ep := range control.Find("") {
+		if st, err := statusOf(ep); err == nil {
+			all = append(all, st)
+		}
+	}
+
+	if asJSON {
+		// One JSON document: an array of statuses
+		fmt.Print("[")
+		for i, st := range all {
+			if i > 0 {
+				fmt.Print(",")
+			}
+			b, _ := protojson.Marshal(st)
+			os.Stdout.Write(b)
+		}
+		fmt.Println("]")
+		return
+	}
+	if len(all) == 0 {
+		fmt.Println("No blue-guy hosts or clients running")
+		return
+	}
+	for _, st := range all {
+		switch st.Role {
+		case control.RoleHost:
+			if len(st.Workspaces) > 1 {
+				fmt.Printf("Host %d: %d workspaces on %s, %d clients, up %s\n", st.Pid, len(st.Workspaces), st.Addr, st.Clients, since(st.StartedUnix))
+				for _, w := range st.Workspaces {
+					fmt.Printf("  %s: %s\n", w.Name, w.Root)
+					printSession(w.Session, "    ")
+				}
+				continue
+			}
+			fmt.Printf("Host %d: %s on %s, %d clients, up %s\n", st.Pid, st.Workspace, st.Addr, st.Clients, since(st.StartedUnix))
+			printSession(st.Session, "  ")
+		default:
+			kind := "Client"
+			if st.Background {
+				kind = "Client daemon"
+			}
+			fmt.Printf("%s %d: %d mounts, up %s\n", kind, st.Pid, len(st.Mounts), since(st.StartedUnix))
+			for _, m := range st.Mounts {
+				fmt.Printf("  %s from %s\n", m.Mount, m.Addr)
+				if m.Session != nil {
+					printSession(m.Session, "    ")
+				}
+			}
+		}
+	}
+}
+
+func statusOf(ep control.Endpoint) (*pb.ControlStatus, error) {
+	ctl, closeConn, err := control.Dial(ep.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer closeConn()
+	ctx, cancel := context.WithTimeout(context.Background(), controlTimeout)
+	defer cancel()
+	return ctl.Status(ctx, &pb.ControlStatusRequest{})
+}
+
+// dialLocal connects to the one local process matching role (any if
+// empty) and pid, exiting with a list of candidates when that's ambiguous.
+func dialLocal(role string, pid int) (pb.ControlServiceClient, func()) {
+	var matches []control.Endpoint
+	for _, ep := range control.Find(role) {
+		if pid == 0 || ep.PID == pid {
+			matches = append(matches, ep)
+		}
+	}
+
+	what := "blue-guy process"
+	if role != "" {
+		what = "blue-guy " + role
+	}
+	switch len(matches) {
+	case 0:
+		fmt.Fprintf(os.Stderr, "Error: no running %s found\n", what)
+		os.Exit(1)
+	case 1:
+	default:
+		fmt.Fprintf(os.Stderr, "Error: several %ss are running; pick one with --pid:\n", what)
+		for _, ep := range matches {
+			line := fmt.Sprintf("  %d", ep.PID)
+			if st, err := statusOf(ep); err == nil {
+				line += fmt.Sprintf("  %s  %s", st.Role, st.Workspace)
+			}
+			fmt.Fprintln(os.Stderr, line)
+		}
+		os.Exit(1)
+	}
+
+	ctl, closeConn, err := control.Dial(matches[0].Path)
+	if err != nil {
+		fail(err)
+	}
+	return ctl, func() { closeConn() }
+}
+
+func printJSON(m proto.Message) {
+	b, err := protojson.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(m)
+	if err != nil {
+		fail(err)
+	}
+	fmt.Println(string(b))
+}
+
+func since(unix int64) time.Duration {
+	return time.Since(time.Unix(unix, 0)).Round(time.Second)
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	os.Exit(1)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/victorarias/blue-guy/internal/control"
+	"github.com/victorarias/blue-guy/internal/identity"
+	pb "github.com/victorarias/blue-guy/internal/proto/gen"
+)
+
+// daemonStartTimeout is how long join waits for a daemon it started to
+// open its control socket.
+const daemonStartTimeout = 10 * time.Second
+
+// runDaemonCmd implements `blue-guy daemon`: the background client that
+// joins mount into. It ignores the terminal closing and unmounts
+// everything when stopped.
+func runDaemonCmd(args []string) {
+	fs := flag.NewFlagSet("daemon", flag.ExitOnError)
+	cfg, load := loadConfig(fs, args)
+
+	signal.Ignore(syscall.SIGHUP)
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	runDaemon(ctx, cfg, load, clientIdentity(cfg), "", nil, false)
+}
+
+// joinDaemon asks the background daemon to mount a workspace of addr,
+// starting it with daemonArgs first if none is running.
+func joinDaemon(addr, workspace string, id identity.Identity, force bool, daemonArgs []string, asJSON bool) {
+	ep, ok := findDaemon()
+	if !ok {
+		ep = startDaemon(daemonArgs)
+	}
+	ctl, closeConn, err := control.Dial(ep.Path)
+	if err != nil {
+		fail(err)
+	}
+	defer closeConn()
+
+	// Mounting probes the host, which can take a while to time out
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	m, err := ctl.Join(ctx, &pb.JoinRequest{Addr: addr, Workspace: workspace, Name: id.Name, Email: id.Email, Force: force})
+	if err != nil {
+		fail(err)
+	}
+	if asJSON {
+		printJSON(m)
+		return
+	}
+	fmt.Printf("Mounted workspace at %s\n", m.Mount)
+	fmt.Printf("Leave with: blue-guy leave %s\n", m.Mount)
+}
+
+// findDaemon looks for a running background client.
+func findDaemon() (control.Endpoint, bool) {
+	for _, ep := range control.Find(control.RoleClient) {
+		if st, err := statusOf(ep); err == nil && st.Background {
+			return ep, true
+		}
+	}
+	return control.Endpoint{}, false
+}
+
+// startDaemon runs `blue-guy daemon` detached from this terminal, logging
+// to daemon.log in the control directory, and waits for its socket.
+func startDaemon(args []string) control.Endpoint {
+	exe, err := os.Executable()
+	if err != nil {
+		fail(err)
+	}
+	if err := os.MkdirAll(control.Dir(), 0700); err != nil {
+		fail(err)
+	}
+	logPath := filepath.Join(control.Dir(), "daemon.log")
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		fail(err)
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(exe, append([]string{"daemon"}, args...)...)
+	cmd.Stdout, cmd.Stderr = logFile, logFile
+	detach(cmd)
+	if err := cmd.Start(); err != nil {
+		fail(fmt.Errorf("start client daemon: %w", err))
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+
+	deadline := time.After(daemonStartTimeout)
+	tick := time.NewTicker(50 * time.Millisecond)
+	defer tick.Stop()
+	for {
+		select {
+		case <-exited:
+			fail(fmt.Errorf("client daemon exited; see %s", logPath))
+		case <-deadline:
+			fail(fmt.Errorf("client daemon didn't start; see %s", logPath))
+		case <-tick.C:
+			for _, ep := range control.Find(control.RoleClient) {
+				if ep.PID == cmd.Process.Pid {
+					return ep
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/victorarias/blue-guy/internal/gitops"
+)
+
+// runFinish implements `blue-guy finish`: squash a session branch into one
+// commit and optionally land it on the original branch.
+func runFinish(args []string) {
+	fs := flag.NewFlagSet("finish", flag.ExitOnError)
+	message := fs.String("m", "", "Commit message for the squashed commit (required)")
+	session := fs.String("session", "", "Session ID to finish (default: most recent mob/session-* branch)")
+	onto := fs.String("onto", "", "Branch to integrate with (default: the branch the session started from)")
+	integrate := fs.String("integrate", "none", "What to do with the squashed commit: none, merge or rebase")
+	fs.Parse(args)
+
+	mode, err := gitops.ParseIntegrate(*integrate)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	opts := gitops.FinishOptions{
+		Message:   *message,
+		Onto:      *onto,
+		Integrate: mode,
+	}
+	if *session != "" {
+		opts.Branch = "mob/session-" + *session
+	}
+	if err := finish(cwd, opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func finish(root string, opts gitops.FinishOptions) error {
+	log := zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr}).
+		With().Timestamp().Str("role", "finish").Logger()
+
+	res, err := gitops.Finish(root, opts, log)
+	if res != nil {
+		fmt.Printf("Squashed %d commits on %s into %s\n", res.Squashed, res.Branch, res.Commit[:min(len(res.Commit), 12)])
+		fmt.Printf("Raw session history kept at %s\n", res.Backup)
+		if err == nil && opts.Integrate != gitops.IntegrateNone {
+			fmt.Printf("Integrated into %s (%s)\n", res.Onto, opts.Integrate)
+		}
+	}
+	return err
+}
+
+// promptFinish asks on the terminal whether to squash the session that just
+// ended. An empty message skips it. Call it with SIGINT no longer caught, so
+// Ctrl+C abandons the prompt.
+func promptFinish(root, branch string, mode gitops.Integrate) {
+	fmt.Printf("Squash %s into one commit? Enter a message (empty to skip): ", branch)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	message := strings.TrimSpace(line)
+	if err != nil && message == "" {
+		fmt.Println()
+		return
+	}
+	if message == "" {
+		return
+	}
+
+	opts := gitops.FinishOptions{
+		Branch:    branch,
+		Message:   message,
+		Integrate: mode,
+	}
+	if err := finish(root, opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	}
+}
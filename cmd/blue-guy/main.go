@@ -6,49 +6,310 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
 	"syscall"
 
 	"github.com/google/uuid"
+	"github.com/victorarias/blue-guy/internal/config"
+	"github.com/victorarias/blue-guy/internal/gitops"
 	"github.com/victorarias/blue-guy/internal/host"
+	"github.com/victorarias/blue-guy/internal/identity"
+	pb "github.com/victorarias/blue-guy/internal/proto/gen"
 )
 
 var version = "dev"
 
+const usage = `Usage: blue-guy <command> [flags]
+
+Sessions:
+  host              Share the current directory (the default command)
+  join <addr>       Mount a host's workspace at ~/mob/<host>, in the background
+                    (--workspace or --all for hosts sharing several)
+  leave [mount]     Unmount a workspace (--all for every one)
+  list              List mounted workspaces
+  daemon            Run the client daemon in this terminal (join starts one)
+  finish            Squash a session branch into one commit
+  version           Print the version
+
+Managing running hosts and clients on this machine:
+  status [addr]     Show local hosts and mounts, or query a remote host
+  clients           List the clients connected to the local host
+  checkpoint        Commit the session now
+  stop              End the local host's session
+  kick <client>     Disconnect a client from the local host
+  reload            Re-read config files and the environment
+  log-level <level> Change how much a host or client logs
+
+Run blue-guy <command> -h for the command's flags.
+`
+
 func main() {
-	showVersion := flag.Bool("version", false, "Print version and exit")
-	connect := flag.String("connect", "", "Host address to connect to (client mode)")
-	port := flag.Int("port", 7654, "Port to listen on (host mode)")
-	flag.Parse()
+	cmd, args := "host", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cmd, args = args[0], args[1:]
+	} else if len(args) == 1 && (args[0] == "--version" || args[0] == "-version") {
+		cmd = "version"
+	}
 
-	if *showVersion {
+	switch cmd {
+	case "version":
 		fmt.Println(version)
-		return
+	case "host":
+		runHost(args)
+	case "join":
+		runJoin(args)
+	case "finish":
+		runFinish(args)
+	case "status":
+		runStatus(args)
+	case "clients":
+		runClients(args)
+	case "checkpoint":
+		runCheckpoint(args)
+	case "stop":
+		runStop(args)
+	case "leave", "unmount":
+		runLeave(args)
+	case "list":
+		runList(args)
+	case "daemon":
+		runDaemonCmd(args)
+	case "kick":
+		runKick(args)
+	case "reload":
+		runReload(args)
+	case "log-level":
+		runLogLevel(args)
+	case "help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n%s", cmd, usage)
+		os.Exit(2)
 	}
+}
 
-	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
-	defer cancel()
-
-	if *connect != "" {
-		runClient(ctx, *connect)
-		return
-	}
+// loadConfig parses a host or join command line and merges it with config
+// files and the environment. The returned function loads them again the
+// same way, for a reload.
+func loadConfig(fs *flag.FlagSet, args []string) (*config.Config, func() (*config.Config, error)) {
+	configFile := fs.String("config", "", "Config file to use instead of the workspace's blueguy.toml")
+	overrides := config.RegisterFlags(fs)
+	fs.Parse(args)
 
-	// Host mode
 	cwd, err := os.Getwd()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
-
-	sessionID := uuid.New().String()[:8]
-	h, err := host.New(cwd, *port, sessionID)
+	load := func() (*config.Config, error) {
+		return config.Load(config.LoadOptions{Workspace: cwd, File: *configFile, Flags: overrides})
+	}
+	cfg, err := load()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		os.Exit(2)
+	}
+	for _, f := range cfg.Files {
+		fmt.Printf("Using config %s\n", f)
+	}
+	return cfg, load
+}
+
+// reloader answers ReloadConfig: it loads the settings again, hands the
+// ones in live to apply, and reports what changed.
+func reloader(cfg *config.Config, load func() (*config.Config, error), live []string, apply func(*config.Config)) func() (*pb.ReloadConfigResponse, error) {
+	var mu sync.Mutex
+	running := *cfg
+	return func() (*pb.ReloadConfigResponse, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		next, err := load()
+		if err != nil {
+			return nil, err
+		}
+		applied, restart := running.Update(next, live)
+		apply(&running)
+		return &pb.ReloadConfigResponse{Files: next.Files, Applied: applied, Restart: restart}, nil
 	}
+}
+
+// runHost implements `blue-guy host` (or plain `blue-guy`): share the
+// current directory (or its --export subdirectory), or the --workspace
+// directories, until interrupted or stopped.
+func runHost(args []string) {
+	fs := flag.NewFlagSet("host", flag.ExitOnError)
+	connect := fs.String("connect", "", "Join a host instead (same as blue-guy join <addr>)")
+	resume := fs.String("resume", "", "Continue an existing session: a session ID or \"latest\"")
+	var shares []share
+	fs.Func("workspace", "Share a directory as `name=path` (repeatable; the name defaults to the directory's). Default: the current directory", func(v string) error {
+		name, path, ok := strings.Cut(v, "=")
+		if !ok {
+			name, path = "", v
+		}
+		if path == "" {
+			return fmt.Errorf("no directory in %q", v)
+		}
+		shares = append(shares, share{name: name, path: path})
+		return nil
+	})
+	cfg, load := loadConfig(fs, args)
+
+	if *connect != "" {
+		join(cfg, load, *connect, []string{""}, false)
+		return
+	}
+
+	if len(shares) == 0 {
+		cwd, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		// An export shares part of the repository; git still works on
+		// all of it but commits only what's under the export
+		shares = []share{{path: filepath.Join(cwd, filepath.FromSlash(cfg.Workspace.Export))}}
+	} else if cfg.Workspace.Export != "" {
+		fmt.Fprintln(os.Stderr, "Error: --export shares part of the current directory; with --workspace, give it the subdirectory instead")
+		os.Exit(2)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	sessionID := uuid.New().String()[:8]
+	if *resume == "" {
+		// Still on a session branch means the last host didn't shut down
+		// cleanly; branching a new session off it would nest the two.
+		for _, sh := range shares {
+			if id := gitops.InterruptedSession(sh.path); id != "" {
+				fmt.Printf("Found interrupted session %s in %s, resuming it\n", id, sh.path)
+				*resume = id
+				break
+			}
+		}
+	}
+	if *resume != "" {
+		// Every workspace continues the same session
+		for i, sh := range shares {
+			id, err := gitops.ResolveSession(sh.path, *resume)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s: %v\n", sh.path, err)
+				os.Exit(1)
+			}
+			if i > 0 && id != sessionID {
+				fmt.Fprintf(os.Stderr, "Error: %s is on session %s, not %s; share it separately\n", sh.path, id, sessionID)
+				os.Exit(1)
+			}
+			sessionID = id
+		}
+	}
+	opts := cfg.HostOptions()
+	opts.Git.Resume = *resume != ""
+	h := host.New(cfg.Network.Port, sessionID, opts)
+	for _, sh := range shares {
+		if err := h.AddWorkspace(sh.name, sh.path); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+	}
+	h.SetReloader(reloader(cfg, load, config.HostLive, func(c *config.Config) {
+		h.Reconfigure(c.HostOptions())
+	}))
 
 	if err := h.Start(ctx); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
+
+	if cfg.Git.SquashOnStop {
+		// The session is over; give Ctrl+C back so it can abandon the prompt
+		cancel()
+		for _, w := range h.Workspaces() {
+			if w.GitEnabled() {
+				promptFinish(w.Root(), "mob/session-"+sessionID, cfg.Git.Integrate)
+			}
+		}
+	}
+}
+
+// share is a directory given to host --workspace.
+type share struct {
+	name, path string
+}
+
+// runJoin implements `blue-guy join <addr>`: mount a host's workspace in
+// the background client daemon, starting it if need be, or with
+// --foreground in this terminal until interrupted or unmounted.
+func runJoin(args []string) {
+	fs := flag.NewFlagSet("join", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: blue-guy join [flags] <host[:port]>")
+		fs.PrintDefaults()
+	}
+	foreground := fs.Bool("foreground", false, "Stay in this terminal until interrupted instead of mounting in the background daemon")
+	asJSON := fs.Bool("json", false, "Print JSON")
+	ws := fs.String("workspace", "", "Which of the host's workspaces to mount, at ~/mob/<host>/<workspace>")
+	all := fs.Bool("all", false, "Mount every workspace the host shares")
+	force := fs.Bool("force", false, "Mount over a directory that already has files in it")
+	cfg, load := loadConfig(fs, args)
+	if fs.NArg() != 1 || (*all && *ws != "") {
+		fs.Usage()
+		os.Exit(2)
+	}
+	addr := hostAddr(fs.Arg(0))
+	workspaces := []string{*ws}
+	if *all {
+		var err error
+		if workspaces, err = hostWorkspaces(addr); err != nil {
+			fail(err)
+		}
+	}
+	if *foreground {
+		join(cfg, load, addr, workspaces, *force)
+		return
+	}
+
+	// A daemon we start gets the same settings we were given
+	var daemonArgs []string
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "foreground", "json", "workspace", "all", "force":
+		default:
+			daemonArgs = append(daemonArgs, "--"+f.Name+"="+f.Value.String())
+		}
+	})
+	for _, w := range workspaces {
+		joinDaemon(addr, w, clientIdentity(cfg), *force, daemonArgs, *asJSON)
+	}
+}
+
+// join mounts workspaces of addr in this process until interrupted or
+// they're all unmounted.
+func join(cfg *config.Config, load func() (*config.Config, error), addr string, workspaces []string, force bool) {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	runDaemon(ctx, cfg, load, clientIdentity(cfg), hostAddr(addr), workspaces, force)
+}
+
+// clientIdentity is who a client says it is: the configured name and
+// email, falling back to git config.
+func clientIdentity(cfg *config.Config) identity.Identity {
+	id := identity.FromGitConfig()
+	if cfg.Auth.Name != "" {
+		id.Name = cfg.Auth.Name
+	}
+	if cfg.Auth.Email != "" {
+		id.Email = cfg.Auth.Email
+	}
+	return id
+}
+
+// hostAddr adds the default port to a host without one.
+func hostAddr(addr string) string {
+	if !strings.Contains(addr, ":") {
+		addr += ":7654"
+	}
+	return addr
 }
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	pb "github.com/victorarias/blue-guy/internal/proto/gen"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// runStatus implements `blue-guy status [host[:port]]`: without an address,
+// list the hosts and clients running here; with one, ask that host about
+// its session and who is driving.
+func runStatus(args []string) {
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Print JSON")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		localStatus(*asJSON)
+		return
+	}
+	addr := hostAddr(fs.Arg(0))
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	list, err := pb.NewWorkspaceServiceClient(conn).ListWorkspaces(ctx, &pb.ListWorkspacesRequest{})
+	if status.Code(err) == codes.Unimplemented {
+		// A host from before workspaces shares just the one
+		var st *pb.SessionStatus
+		st, err = pb.NewSessionServiceClient(conn).GetStatus(ctx, &pb.GetStatusRequest{})
+		list = &pb.ListWorkspacesResponse{Workspaces: []*pb.WorkspaceInfo{{Session: st}}}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: query %s: %v\n", addr, err)
+		os.Exit(1)
+	}
+	if *asJSON {
+		printJSON(list)
+		return
+	}
+	for _, w := range list.Workspaces {
+		if len(list.Workspaces) == 1 {
+			if w.Name != "" {
+				fmt.Printf("Workspace: %s\n", w.Name)
+			}
+			printSession(w.Session, "")
+			continue
+		}
+		fmt.Printf("Workspace %s:\n", w.Name)
+		printSession(w.Session, "  ")
+	}
+}
+
+// hostWorkspaces asks the host at addr for the names of its workspaces.
+func hostWorkspaces(addr string) ([]string, error) {
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	list, err := pb.NewWorkspaceServiceClient(conn).ListWorkspaces(ctx, &pb.ListWorkspacesRequest{})
+	if status.Code(err) == codes.Unimplemented {
+		return []string{""}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("list workspaces of %s: %w", addr, err)
+	}
+	var names []string
+	for _, w := range list.Workspaces {
+		names = append(names, w.Name)
+	}
+	return names, nil
+}
+
+// printSession describes a session, each line starting with indent.
+func printSession(st *pb.SessionStatus, indent string) {
+	if st == nil {
+		return
+	}
+	fmt.Printf("%sSession: %s | Branch: %s\n", indent, st.SessionId, st.Branch)
+	if st.Push != nil {
+		fmt.Println(indent + describePush(st.Push))
+	}
+	if st.Rotation == nil {
+		fmt.Println(indent + "Rotation: off")
+		return
+	}
+	fmt.Printf("%sDriver: %s (%s left) | Next: %s\n",
+		indent, st.Rotation.Driver, remaining(st.Rotation.TurnEndsUnix), st.Rotation.NextDriver)
+	if st.Rotation.DriversOnly {
+		fmt.Println(indent + "Non-drivers are read-only")
+	}
+}
+
+func remaining(endsUnix int64) time.Duration {
+	d := time.Until(time.Unix(endsUnix, 0)).Round(time.Second)
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+func describePush(p *pb.PushState) string {
+	line := fmt.Sprintf("Push: %s to %s", p.Policy, p.Remote)
+	if p.LastPushUnix != 0 {
+		line += " | last " + time.Unix(p.LastPushUnix, 0).Format("15:04:05")
+	}
+	if p.Pending {
+		line += " | unpushed commits"
+	}
+	if p.Held != "" {
+		line += " | on hold: " + p.Held
+	}
+	if p.LastError != "" {
+		line += " | " + p.LastError
+	}
+	if p.NextRetryUnix != 0 {
+		line += fmt.Sprintf(" (retry in %s)", remaining(p.NextRetryUnix))
+	}
+	return line
+}
@@ -7,36 +7,95 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/rs/zerolog"
-	"github.com/winfsp/cgofuse/fuse"
+	"github.com/victorarias/blue-guy/internal/clock"
+	"github.com/victorarias/blue-guy/internal/identity"
 	pb "github.com/victorarias/blue-guy/internal/proto/gen"
+	"github.com/victorarias/blue-guy/internal/workspace"
+	"github.com/winfsp/cgofuse/fuse"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 )
 
+const defaultTimeout = 10 * time.Second
+
+// Options configures a Client. The zero value is usable.
+type Options struct {
+	// Timeout bounds each filesystem call to the host. 0 means 10s.
+	Timeout time.Duration
+	// Clock is used for announcements. nil means the system clock.
+	Clock clock.Clock
+	// Workspace names which of the host's workspaces to mount. Empty
+	// means the host's only one.
+	Workspace string
+	// Force mounts over a directory that already has files in it, hiding
+	// them until unmounted.
+	Force bool
+}
+
 type Client struct {
 	addr      string
+	identity  identity.Identity
+	opts      Options
 	mountPath string
 	conn      *grpc.ClientConn
 	fsHost    *fuse.FileSystemHost
+	remoteFS  *RemoteFS
 	log       zerolog.Logger
+	pushErr   string // last push failure announced, touched only by watchSession
+	started   time.Time
+	cancel    context.CancelFunc // unmounts, e.g. when kicked
+	mounted   chan struct{}      // closed once FUSE has mounted
 }
 
-func New(addr string) *Client {
+// New creates a client for the host at addr. id is sent with every request
+// so the host can credit this client in commits.
+func New(addr string, id identity.Identity, opts Options) *Client {
 	log := zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr}).
 		With().Timestamp().Str("role", "client").Logger()
 
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	opts.Clock = clock.Or(opts.Clock)
+
 	return &Client{
-		addr: addr,
-		log:  log,
+		addr:      addr,
+		identity:  id,
+		opts:      opts,
+		mountPath: mountPathFor(addr, opts.Workspace),
+		started:   opts.Clock.Now(),
+		log:       log.With().Str("addr", addr).Logger(),
+		mounted:   make(chan struct{}),
 	}
 }
 
+// mountPathFor is where a host's workspace is mounted: ~/mob/<host>, or
+// ~/mob/<host>/<workspace> for a named one.
+func mountPathFor(addr, ws string) string {
+	return filepath.Join(os.Getenv("HOME"), "mob", inferWorkspaceName(addr), ws)
+}
+
 func (c *Client) Start(ctx context.Context) error {
-	c.log.Info().Str("addr", c.addr).Msg("Connecting to host")
+	ctx, c.cancel = context.WithCancel(ctx)
+	defer c.cancel()
+
+	c.log.Info().Str("addr", c.addr).Str("as", c.identity.String()).Msg("Connecting to host")
+	if c.identity.Email == "" {
+		c.log.Warn().Msg("No email set (--email or git config user.email); your changes won't be credited in commits")
+	}
 
-	conn, err := grpc.NewClient(c.addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := grpc.NewClient(c.addr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(
+			identity.UnaryClientInterceptor(c.identity),
+			workspace.UnaryClientInterceptor(c.opts.Workspace)),
+		grpc.WithChainStreamInterceptor(
+			identity.StreamClientInterceptor(c.identity),
+			workspace.StreamClientInterceptor(c.opts.Workspace)),
+	)
 	if err != nil {
 		return fmt.Errorf("connect to %s: %w", c.addr, err)
 	}
@@ -51,15 +110,12 @@ func (c *Client) Start(ctx context.Context) error {
 		return fmt.Errorf("probe host: %w", err)
 	}
 
-	// Determine mount path: ~/mob/<workspace-name>
-	// We infer the workspace name from the directory listing (or use addr as fallback)
-	workspaceName := inferWorkspaceName(c.addr)
-	c.mountPath = filepath.Join(os.Getenv("HOME"), "mob", workspaceName)
-
-	if err := os.MkdirAll(c.mountPath, 0755); err != nil {
+	cleanup, err := prepareMountPoint(c.mountPath, c.opts.Force)
+	if err != nil {
 		conn.Close()
-		return fmt.Errorf("create mount point %s: %w", c.mountPath, err)
+		return fmt.Errorf("mount point: %w", err)
 	}
+	defer cleanup()
 
 	c.log.Info().
 		Str("mount", c.mountPath).
@@ -69,8 +125,11 @@ func (c *Client) Start(ctx context.Context) error {
 	fmt.Printf("Mounted workspace at %s\n", c.mountPath)
 	fmt.Printf("Ready. All changes sync to host.\n")
 
-	remoteFS := NewRemoteFS(fc, c.log)
-	c.fsHost = fuse.NewFileSystemHost(remoteFS)
+	c.remoteFS = NewRemoteFS(fc, pb.NewGitServiceClient(conn), c.opts.Timeout, c.log)
+	c.remoteFS.SetOnMount(func() { close(c.mounted) })
+	go c.watchSession(ctx, pb.NewSessionServiceClient(conn))
+
+	c.fsHost = fuse.NewFileSystemHost(c.remoteFS)
 
 	// Unmount on context cancellation
 	go func() {
@@ -90,6 +149,20 @@ func (c *Client) Start(ctx context.Context) error {
 	return nil
 }
 
+// Mounted is closed once the workspace is mounted.
+func (c *Client) Mounted() <-chan struct{} {
+	return c.mounted
+}
+
+// Reconfigure applies the settings in opts that can change while mounted,
+// which is just the call timeout. Call it only once Mounted is closed.
+func (c *Client) Reconfigure(opts Options) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	c.remoteFS.SetTimeout(opts.Timeout)
+}
+
 func (c *Client) MountPath() string {
 	return c.mountPath
 }
@@ -0,0 +1,160 @@
+//go:build cgo
+
+package client
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/victorarias/blue-guy/internal/control"
+	"github.com/victorarias/blue-guy/internal/identity"
+	pb "github.com/victorarias/blue-guy/internal/proto/gen"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// controlServer implements the ControlService for a client process and
+// its mounts.
+type controlServer struct {
+	pb.UnimplementedControlServiceServer
+	d *Daemon
+}
+
+func (s *controlServer) Status(ctx context.Context, _ *pb.ControlStatusRequest) (*pb.ControlStatus, error) {
+	st := &pb.ControlStatus{
+		Role:        control.RoleClient,
+		Pid:         int32(os.Getpid()),
+		StartedUnix: s.d.started.Unix(),
+		Background:  s.d.opts.Background,
+		Mounts:      s.mounts(ctx),
+	}
+	// A single mount is described at the top level too
+	if len(st.Mounts) == 1 {
+		m := st.Mounts[0]
+		st.Workspace, st.Addr, st.Session = m.Mount, m.Addr, m.Session
+	}
+	return st, nil
+}
+
+func (s *controlServer) mounts(ctx context.Context) []*pb.MountInfo {
+	var out []*pb.MountInfo
+	for _, c := range s.d.Clients() {
+		out = append(out, c.info(ctx))
+	}
+	return out
+}
+
+// info describes the mount, asking the host for the session's status.
+func (c *Client) info(ctx context.Context) *pb.MountInfo {
+	m := &pb.MountInfo{Addr: c.addr, Mount: c.mountPath, StartedUnix: c.started.Unix(), Workspace: c.opts.Workspace}
+	select {
+	case <-c.mounted:
+	default:
+		return m
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.remoteFS.Timeout())
+	defer cancel()
+	m.Session, _ = pb.NewSessionServiceClient(c.conn).GetStatus(ctx, &pb.GetStatusRequest{})
+	return m
+}
+
+func (s *controlServer) ListClients(context.Context, *pb.ListClientsRequest) (*pb.ListClientsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "clients are listed by the host")
+}
+
+// Checkpoint asks a mount's host to commit, as this client. With several
+// mounts, req.Mount (a mount point or any path inside one) picks.
+func (s *controlServer) Checkpoint(ctx context.Context, req *pb.CheckpointRequest) (*pb.CheckpointResponse, error) {
+	clients := s.d.Clients()
+	var c *Client
+	for _, cl := range clients {
+		if req.Mount == cl.mountPath || strings.HasPrefix(req.Mount, cl.mountPath+string(filepath.Separator)) {
+			c = cl
+		}
+	}
+	if c == nil {
+		switch len(clients) {
+		case 0:
+			return nil, status.Error(codes.FailedPrecondition, "nothing mounted")
+		case 1:
+			c = clients[0]
+		default:
+			return nil, status.Error(codes.InvalidArgument, "several workspaces are mounted; say which")
+		}
+	}
+	select {
+	case <-c.mounted:
+	default:
+		return nil, status.Errorf(codes.Unavailable, "%s is still mounting", c.mountPath)
+	}
+	return pb.NewGitServiceClient(c.conn).Checkpoint(ctx, &pb.CheckpointRequest{Message: req.Message})
+}
+
+func (s *controlServer) Stop(_ context.Context, req *pb.StopRequest) (*pb.StopResponse, error) {
+	s.d.log.Info().Str("reason", req.Reason).Msg("Stop requested over the control socket")
+	s.d.Stop()
+	return &pb.StopResponse{}, nil
+}
+
+func (s *controlServer) ReloadConfig(context.Context, *pb.ReloadConfigRequest) (*pb.ReloadConfigResponse, error) {
+	if s.d.reload == nil {
+		return nil, status.Error(codes.Unimplemented, "this client can't reload its settings")
+	}
+	resp, err := s.d.reload()
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	s.d.log.Info().Strs("applied", resp.Applied).Strs("restart", resp.Restart).Msg("Reloaded settings")
+	return resp, nil
+}
+
+func (s *controlServer) SetLogLevel(_ context.Context, req *pb.SetLogLevelRequest) (*pb.SetLogLevelResponse, error) {
+	return control.SetLogLevel(req)
+}
+
+func (s *controlServer) KickClient(context.Context, *pb.KickClientRequest) (*pb.KickClientResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "clients are kicked by the host")
+}
+
+func (s *controlServer) Join(ctx context.Context, req *pb.JoinRequest) (*pb.MountInfo, error) {
+	if req.Addr == "" {
+		return nil, status.Error(codes.InvalidArgument, "say which host to join")
+	}
+	c, err := s.d.Join(req.Addr, req.Workspace, identity.Identity{Name: req.Name, Email: req.Email}, req.Force)
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return c.info(ctx), nil
+}
+
+func (s *controlServer) Leave(_ context.Context, req *pb.LeaveRequest) (*pb.LeaveResponse, error) {
+	if err := s.d.Leave(req.Mount); err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	return &pb.LeaveResponse{}, nil
+}
+
+func (s *controlServer) ListMounts(ctx context.Context, _ *pb.ListMountsRequest) (*pb.ListMountsResponse, error) {
+	return &pb.ListMountsResponse{Mounts: s.mounts(ctx)}, nil
+}
+
+// serveControl starts the control socket, returning a function that shuts
+// it down. Without one the mounts work but can't be managed from the CLI.
+func (d *Daemon) serveControl() func() {
+	lis, path, err := control.Listen(control.RoleClient)
+	if err != nil {
+		d.log.Warn().Err(err).Msg("Control socket unavailable")
+		return func() {}
+	}
+	srv := grpc.NewServer()
+	pb.RegisterControlServiceServer(srv, &controlServer{d: d})
+	go srv.Serve(lis)
+	return func() {
+		// Graceful, so the reply to a Stop request gets out
+		srv.GracefulStop()
+		os.Remove(path)
+	}
+}
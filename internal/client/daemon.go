@@ -0,0 +1,219 @@
+//go:build cgo
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/victorarias/blue-guy/internal/clock"
+	"github.com/victorarias/blue-guy/internal/identity"
+	pb "github.com/victorarias/blue-guy/internal/proto/gen"
+)
+
+// DaemonOptions configures a Daemon. The zero value is usable.
+type DaemonOptions struct {
+	// Client configures every mount.
+	Client Options
+	// Background marks a daemon that outlives the terminal that started
+	// it, as opposed to a join in the foreground.
+	Background bool
+	// ExitWhenEmpty stops the daemon once its last mount is gone.
+	ExitWhenEmpty bool
+}
+
+// Daemon keeps any number of mounts in one process, each a Client with its
+// own connection to its host, and serves the control socket for them all.
+type Daemon struct {
+	identity identity.Identity // for joins that don't say who they are
+	opts     DaemonOptions
+	started  time.Time
+	reload   Reloader
+	log      zerolog.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc // unmounts everything and ends Run
+	wg     sync.WaitGroup     // running mounts
+
+	mu     sync.Mutex
+	mounts map[string]*daemonMount // by mount point
+}
+
+type daemonMount struct {
+	client *Client
+	cancel context.CancelFunc
+}
+
+// Reloader re-reads the client's settings for ReloadConfig, applying what
+// it can with Reconfigure and reporting the rest.
+type Reloader func() (*pb.ReloadConfigResponse, error)
+
+// NewDaemon creates a daemon with no mounts. id is the identity for joins
+// that don't give one.
+func NewDaemon(id identity.Identity, opts DaemonOptions) *Daemon {
+	if opts.Client.Timeout <= 0 {
+		opts.Client.Timeout = defaultTimeout
+	}
+	opts.Client.Clock = clock.Or(opts.Client.Clock)
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Daemon{
+		identity: id,
+		opts:     opts,
+		started:  opts.Client.Clock.Now(),
+		log: zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr}).
+			With().Timestamp().Str("role", "client").Logger(),
+		ctx:    ctx,
+		cancel: cancel,
+		mounts: make(map[string]*daemonMount),
+	}
+}
+
+// SetReloader sets how the control socket's ReloadConfig re-reads
+// settings. Without one, reloading isn't supported.
+func (d *Daemon) SetReloader(fn Reloader) {
+	d.reload = fn
+}
+
+// Join mounts a workspace of the host at addr, returning once it's
+// mounted. An empty workspace means the host's only one, and a zero id
+// uses the daemon's identity. force mounts over a directory with files in
+// it.
+func (d *Daemon) Join(addr, workspace string, id identity.Identity, force bool) (*Client, error) {
+	if id.IsZero() {
+		id = d.identity
+	}
+	d.mu.Lock()
+	if d.ctx.Err() != nil {
+		d.mu.Unlock()
+		return nil, errors.New("shutting down")
+	}
+	opts := d.opts.Client
+	opts.Workspace = workspace
+	opts.Force = force
+	c := New(addr, id, opts)
+	if _, ok := d.mounts[c.mountPath]; ok {
+		d.mu.Unlock()
+		return nil, fmt.Errorf("%s is already mounted", c.mountPath)
+	}
+	ctx, cancel := context.WithCancel(d.ctx)
+	d.mounts[c.mountPath] = &daemonMount{client: c, cancel: cancel}
+	d.wg.Add(1)
+	d.mu.Unlock()
+
+	done := make(chan error, 1)
+	go func() {
+		defer d.wg.Done()
+		err := c.Start(ctx)
+		cancel()
+		d.remove(c.mountPath)
+		if err != nil {
+			c.log.Warn().Err(err).Msg("Mount ended")
+		}
+		done <- err
+	}()
+
+	select {
+	case <-c.Mounted():
+		return c, nil
+	case err := <-done:
+		if err == nil {
+			err = errors.New("unmounted before it was ready")
+		}
+		return nil, err
+	}
+}
+
+// remove forgets a mount that has ended.
+func (d *Daemon) remove(mountPath string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.mounts, mountPath)
+	if len(d.mounts) == 0 && d.opts.ExitWhenEmpty {
+		d.cancel()
+	}
+}
+
+// Leave unmounts the mount at mountPath, or every workspace mounted from
+// the host at that address.
+func (d *Daemon) Leave(which string) error {
+	found := d.find(which)
+	if len(found) == 0 {
+		return fmt.Errorf("nothing mounted at %s", which)
+	}
+	for _, m := range found {
+		m.cancel()
+	}
+	return nil
+}
+
+func (d *Daemon) find(which string) []*daemonMount {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if m, ok := d.mounts[which]; ok {
+		return []*daemonMount{m}
+	}
+	var found []*daemonMount
+	for _, m := range d.mounts {
+		if m.client.addr == which || inferWorkspaceName(m.client.addr) == which {
+			found = append(found, m)
+		}
+	}
+	return found
+}
+
+// Clients lists the mounts, in mount point order.
+func (d *Daemon) Clients() []*Client {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	out := make([]*Client, 0, len(d.mounts))
+	for _, m := range d.mounts {
+		out = append(out, m.client)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].mountPath < out[j].mountPath })
+	return out
+}
+
+// Reconfigure applies opts to every mount, and to later joins.
+func (d *Daemon) Reconfigure(opts Options) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	d.opts.Client.Timeout = opts.Timeout
+	for _, m := range d.mounts {
+		select {
+		case <-m.client.Mounted():
+			m.client.Reconfigure(opts)
+		default:
+			// Still mounting, with the old timeout
+		}
+	}
+}
+
+// Stop unmounts everything and ends Run.
+func (d *Daemon) Stop() {
+	d.cancel()
+}
+
+// Run serves the control socket until ctx ends or Stop is called, then
+// unmounts everything, waiting for the unmounts to finish.
+func (d *Daemon) Run(ctx context.Context) error {
+	stopControl := d.serveControl()
+	defer stopControl()
+
+	select {
+	case <-ctx.Done():
+		d.cancel()
+	case <-d.ctx.Done():
+	}
+	d.log.Info().Int("mounts", len(d.Clients())).Msg("Shutting down")
+	d.wg.Wait()
+	return nil
+}
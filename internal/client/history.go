@@ -0,0 +1,117 @@
+//go:build cgo
+
+package client
+
+import (
+	"strings"
+
+	pb "github.com/victorarias/blue-guy/internal/proto/gen"
+	"github.com/winfsp/cgofuse/fuse"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// The virtual /.mob tree holds read-only views the host serves from git.
+// /.mob/history/<snapshot>/... is the workspace as it was at a session
+// commit, where <snapshot> is a commit hash, "start", or a time. It isn't
+// listed in the mount root so `diff -r`, `grep -r` and friends don't
+// descend into every snapshot.
+const (
+	mobDir     = "/.mob"
+	historyDir = mobDir + "/history"
+)
+
+func isMobPath(path string) bool {
+	return path == mobDir || strings.HasPrefix(path, mobDir+"/")
+}
+
+// splitHistory splits "/.mob/history/<snapshot>/<rest>".
+func splitHistory(path string) (snapshot, rest string) {
+	s := strings.TrimPrefix(strings.TrimPrefix(path, historyDir), "/")
+	snapshot, rest, _ = strings.Cut(s, "/")
+	return snapshot, rest
+}
+
+func isHistoryPath(path string) bool {
+	return path == historyDir || strings.HasPrefix(path, historyDir+"/")
+}
+
+func (fs *RemoteFS) mobGetattr(path string, stat *fuse.Stat_t) int {
+	if path == mobDir {
+		fillStat(stat, &pb.FileInfo{Name: ".mob", Mode: fuse.S_IFDIR | 0o555, IsDir: true})
+		return 0
+	}
+	if !isHistoryPath(path) {
+		return -fuse.ENOENT
+	}
+
+	ctx, cancel := fs.ctx()
+	defer cancel()
+
+	snapshot, rest := splitHistory(path)
+	resp, err := fs.git.HistoryStat(ctx, &pb.HistoryRequest{Snapshot: snapshot, Path: rest})
+	if err != nil {
+		return fs.historyErr(err, "Getattr", path)
+	}
+	fillStat(stat, resp.Info)
+	return 0
+}
+
+func (fs *RemoteFS) mobReaddir(path string, fill func(name string, stat *fuse.Stat_t, ofst int64) bool) int {
+	fill(".", nil, 0)
+	fill("..", nil, 0)
+	if path == mobDir {
+		fill("history", nil, 0)
+		return 0
+	}
+	if !isHistoryPath(path) {
+		return -fuse.ENOENT
+	}
+
+	ctx, cancel := fs.ctx()
+	defer cancel()
+
+	snapshot, rest := splitHistory(path)
+	resp, err := fs.git.HistoryReadDir(ctx, &pb.HistoryRequest{Snapshot: snapshot, Path: rest})
+	if err != nil {
+		return fs.historyErr(err, "Readdir", path)
+	}
+	for _, entry := range resp.Entries {
+		var st fuse.Stat_t
+		fillStat(&st, entry)
+		if !fill(entry.Name, &st, 0) {
+			break
+		}
+	}
+	return 0
+}
+
+func (fs *RemoteFS) mobRead(path string, buff []byte, ofst int64) int {
+	if !isHistoryPath(path) {
+		return -fuse.EISDIR
+	}
+
+	ctx, cancel := fs.ctx()
+	defer cancel()
+
+	snapshot, rest := splitHistory(path)
+	resp, err := fs.git.HistoryReadFile(ctx, &pb.HistoryReadRequest{
+		Snapshot: snapshot,
+		Path:     rest,
+		Offset:   ofst,
+		Length:   int64(len(buff)),
+	})
+	if err != nil {
+		return fs.historyErr(err, "Read", path)
+	}
+	return copy(buff, resp.Data)
+}
+
+// historyErr is errToFuse, except that a host without git integration
+// simply has no history.
+func (fs *RemoteFS) historyErr(err error, op, path string) int {
+	if status.Code(err) == codes.FailedPrecondition {
+		return -fuse.ENOENT
+	}
+	return fs.errToFuse(err, op, path)
+}
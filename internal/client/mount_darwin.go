@@ -2,6 +2,30 @@
 
 package client
 
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
 func mountOptions() []string {
 	return []string{"-o", "volname=blue-guy"}
 }
+
+// isStaleMount reports whether err is what touching a FUSE mount whose
+// process has died gives: "device not configured" from a kernel FUSE
+// mount, a dead connection or a timeout from FUSE-T's NFS ones.
+func isStaleMount(err error) bool {
+	return errors.Is(err, syscall.ENOTCONN) || errors.Is(err, syscall.ETIMEDOUT) || errors.Is(err, syscall.ENXIO)
+}
+
+// unmount detaches a mount; users can unmount what they mounted.
+func unmount(path string) error {
+	out, err := exec.Command("umount", path).CombinedOutput()
+	if err != nil && len(out) > 0 {
+		err = fmt.Errorf("umount: %s", strings.TrimSpace(string(out)))
+	}
+	return err
+}
@@ -2,6 +2,37 @@
 
 package client
 
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
 func mountOptions() []string {
 	return []string{}
 }
+
+// isStaleMount reports whether err is what touching a FUSE mount whose
+// process has died gives: "transport endpoint is not connected".
+func isStaleMount(err error) bool {
+	return errors.Is(err, syscall.ENOTCONN)
+}
+
+// unmount detaches a FUSE mount without root, trying fusermount3 before
+// the older fusermount.
+func unmount(path string) error {
+	var err error
+	for _, prog := range []string{"fusermount3", "fusermount"} {
+		var out []byte
+		out, err = exec.Command(prog, "-u", path).CombinedOutput()
+		if err == nil {
+			return nil
+		}
+		if len(out) > 0 {
+			err = fmt.Errorf("%s: %s", prog, strings.TrimSpace(string(out)))
+		}
+	}
+	return err
+}
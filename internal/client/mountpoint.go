@@ -0,0 +1,108 @@
+//go:build cgo
+
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// prepareMountPoint gets path ready to mount on. A mount left behind by a
+// client that crashed is unmounted, missing directories are created, and a
+// directory that's in use -- mounted, or holding files -- is refused unless
+// force is set for the latter. The returned cleanup removes whatever
+// directories were created, once they're empty again.
+func prepareMountPoint(path string, force bool) (cleanup func(), err error) {
+	// Walk up to the first directory that exists, clearing dead mounts on
+	// the way: a stale ~/mob/<host> is as much in the way as a stale
+	// ~/mob/<host>/<workspace>
+	var missing []string
+	for p := path; ; p = filepath.Dir(p) {
+		fi, err := statPath(p)
+		if isStaleMount(err) {
+			// Everything under a dead mount is stale too, so p may only be
+			// inside the one to unmount
+			dead := deadMount(p)
+			if err := unmountDead(dead); err != nil {
+				return nil, fmt.Errorf("%s is a dead mount from an earlier client and unmounting it failed: %w", dead, err)
+			}
+			fi, err = statPath(p)
+		}
+		if errors.Is(err, os.ErrNotExist) && filepath.Dir(p) != p {
+			missing = append(missing, p)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !fi.IsDir() {
+			return nil, fmt.Errorf("%s isn't a directory", p)
+		}
+		break
+	}
+
+	if len(missing) == 0 {
+		if err := checkMountPoint(path, force); err != nil {
+			return nil, err
+		}
+	} else if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, err
+	}
+	return func() {
+		// Innermost first; one still in use stops the rest
+		for _, p := range missing {
+			if os.Remove(p) != nil {
+				return
+			}
+		}
+	}, nil
+}
+
+// Swapped out by tests, which can't leave real dead mounts behind.
+var (
+	statPath    = os.Stat
+	unmountDead = unmount
+)
+
+// deadMount walks up from a stale path to the dead mount it's in: the
+// highest directory that's still stale.
+func deadMount(p string) string {
+	for {
+		parent := filepath.Dir(p)
+		if parent == p {
+			return p
+		}
+		if _, err := statPath(parent); !isStaleMount(err) {
+			return p
+		}
+		p = parent
+	}
+}
+
+// checkMountPoint refuses an existing directory that's already mounted
+// or, without force, has anything in it.
+func checkMountPoint(path string, force bool) error {
+	var st, parent syscall.Stat_t
+	if syscall.Stat(path, &st) == nil && syscall.Stat(filepath.Dir(path), &parent) == nil && st.Dev != parent.Dev {
+		return fmt.Errorf("%s is already mounted; leave it first (blue-guy leave %s)", path, path)
+	}
+	if force {
+		return nil
+	}
+	d, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	if _, err := d.Readdirnames(1); err != io.EOF {
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("%s isn't empty; move its files away or mount over them with --force", path)
+	}
+	return nil
+}
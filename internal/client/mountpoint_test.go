@@ -0,0 +1,142 @@
+//go:build cgo
+
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestPrepareMountPoint_RemovesWhatItCreated(t *testing.T) {
+	base := t.TempDir()
+	path := filepath.Join(base, "mob", "host", "app")
+	cleanup, err := prepareMountPoint(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := os.Stat(path); err != nil || !fi.IsDir() {
+		t.Fatalf("expected %s created, got %v", path, err)
+	}
+	cleanup()
+	if _, err := os.Stat(filepath.Join(base, "mob")); !os.IsNotExist(err) {
+		t.Errorf("expected the created directories removed, got %v", err)
+	}
+	if _, err := os.Stat(base); err != nil {
+		t.Errorf("expected the existing directory kept, got %v", err)
+	}
+}
+
+func TestPrepareMountPoint_KeepsDirectoriesInUse(t *testing.T) {
+	base := t.TempDir()
+	path := filepath.Join(base, "host", "app")
+	cleanup, err := prepareMountPoint(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(base, "host", "notes.txt"), []byte("mine"), 0644)
+	cleanup()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected the empty mount point removed, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(base, "host", "notes.txt")); err != nil {
+		t.Errorf("expected a directory with files in it kept, got %v", err)
+	}
+}
+
+func TestPrepareMountPoint_RefusesFilesUnlessForced(t *testing.T) {
+	path := t.TempDir()
+	os.WriteFile(filepath.Join(path, "a.txt"), []byte("x"), 0644)
+	if _, err := prepareMountPoint(path, false); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("expected a directory with files refused, got %v", err)
+	}
+	cleanup, err := prepareMountPoint(path, true)
+	if err != nil {
+		t.Fatalf("expected --force to mount over the files, got %v", err)
+	}
+	cleanup()
+	if _, err := os.Stat(filepath.Join(path, "a.txt")); err != nil {
+		t.Errorf("expected an existing directory left alone, got %v", err)
+	}
+}
+
+func TestPrepareMountPoint_RefusesAFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.txt")
+	os.WriteFile(path, []byte("x"), 0644)
+	if _, err := prepareMountPoint(filepath.Join(path, "app"), false); err == nil {
+		t.Error("expected a file in the way refused")
+	}
+}
+
+func TestCheckMountPoint_RefusesAMountedDirectory(t *testing.T) {
+	// Anything mounted will do; /proc is on every Linux box
+	var st, root syscall.Stat_t
+	if syscall.Stat("/proc", &st) != nil || syscall.Stat("/", &root) != nil || st.Dev == root.Dev {
+		t.Skip("no separate /proc mount to test with")
+	}
+	if err := checkMountPoint("/proc", true); err == nil || !strings.Contains(err.Error(), "already mounted") {
+		t.Errorf("expected a mounted directory refused even with --force, got %v", err)
+	}
+}
+
+// fakeDeadMount makes dir and everything under it stale, the way a FUSE
+// mount whose client died is, until it's unmounted.
+func fakeDeadMount(t *testing.T, dir string) (unmounted *[]string) {
+	t.Helper()
+	unmounted = new([]string)
+	dead := true
+	oldStat, oldUnmount := statPath, unmountDead
+	t.Cleanup(func() { statPath, unmountDead = oldStat, oldUnmount })
+	statPath = func(p string) (os.FileInfo, error) {
+		if dead && (p == dir || strings.HasPrefix(p, dir+string(filepath.Separator))) {
+			return nil, &os.PathError{Op: "stat", Path: p, Err: syscall.ENOTCONN}
+		}
+		return os.Stat(p)
+	}
+	unmountDead = func(p string) error {
+		*unmounted = append(*unmounted, p)
+		if p != dir {
+			return &os.PathError{Op: "unmount", Path: p, Err: syscall.EINVAL}
+		}
+		dead = false
+		return nil
+	}
+	return unmounted
+}
+
+func TestPrepareMountPoint_UnmountsADeadMount(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "host")
+	os.Mkdir(path, 0755)
+	unmounted := fakeDeadMount(t, path)
+	if _, err := prepareMountPoint(path, false); err != nil {
+		t.Fatal(err)
+	}
+	if len(*unmounted) != 1 || (*unmounted)[0] != path {
+		t.Errorf("expected %s unmounted, got %v", path, *unmounted)
+	}
+}
+
+func TestPrepareMountPoint_UnmountsADeadParent(t *testing.T) {
+	// A dead ~/mob/<host> makes ~/mob/<host>/<workspace> stale too, though
+	// it was never a mount itself
+	host := filepath.Join(t.TempDir(), "host")
+	os.Mkdir(host, 0755)
+	unmounted := fakeDeadMount(t, host)
+	path := filepath.Join(host, "app")
+	cleanup, err := prepareMountPoint(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*unmounted) != 1 || (*unmounted)[0] != host {
+		t.Errorf("expected only %s unmounted, got %v", host, *unmounted)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("expected %s created once the dead mount was gone, got %v", path, err)
+	}
+	cleanup()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected the created workspace directory removed, got %v", err)
+	}
+}
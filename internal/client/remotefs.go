@@ -6,6 +6,7 @@ import (
 	"context"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/rs/zerolog"
@@ -19,8 +20,15 @@ import (
 type RemoteFS struct {
 	fuse.FileSystemBase
 	client  pb.FileServiceClient
+	git     pb.GitServiceClient // serves the read-only /.mob tree
 	log     zerolog.Logger
-	timeout time.Duration
+	timeout atomic.Int64 // time.Duration
+	onMount func()
+
+	// Writes under way hold writes for reading, so StopWrites can wait
+	// them out by taking it
+	writes   sync.RWMutex
+	readOnly atomic.Bool
 
 	// File handle tracking
 	mu      sync.Mutex
@@ -28,18 +36,68 @@ type RemoteFS struct {
 	handles map[uint64]string // fh -> path
 }
 
-func NewRemoteFS(client pb.FileServiceClient, log zerolog.Logger) *RemoteFS {
-	return &RemoteFS{
+// NewRemoteFS returns a filesystem backed by the host's services. Each call
+// gives up after timeout.
+func NewRemoteFS(client pb.FileServiceClient, git pb.GitServiceClient, timeout time.Duration, log zerolog.Logger) *RemoteFS {
+	fs := &RemoteFS{
 		client:  client,
+		git:     git,
 		log:     log,
-		timeout: 10 * time.Second,
 		nextFH:  1,
 		handles: make(map[uint64]string),
 	}
+	fs.SetTimeout(timeout)
+	return fs
+}
+
+// SetTimeout changes how long each call waits for the host. It can change
+// while mounted.
+func (fs *RemoteFS) SetTimeout(d time.Duration) {
+	fs.timeout.Store(int64(d))
+}
+
+// Timeout is how long each call waits for the host.
+func (fs *RemoteFS) Timeout() time.Duration {
+	return time.Duration(fs.timeout.Load())
+}
+
+// SetOnMount sets a function called once FUSE has mounted the filesystem.
+func (fs *RemoteFS) SetOnMount(fn func()) {
+	fs.onMount = fn
+}
+
+// Init is called by FUSE when the filesystem is mounted.
+func (fs *RemoteFS) Init() {
+	if fs.onMount != nil {
+		fs.onMount()
+	}
+}
+
+// StopWrites makes the filesystem read-only and returns once every write
+// already under way has reached the host.
+func (fs *RemoteFS) StopWrites() {
+	fs.readOnly.Store(true)
+	fs.writes.Lock()
+	fs.writes.Unlock()
+}
+
+// startWrite admits a write unless StopWrites has been called. Admitted
+// writes call endWrite when they're done.
+func (fs *RemoteFS) startWrite() bool {
+	fs.writes.RLock()
+	if fs.readOnly.Load() {
+		fs.writes.RUnlock()
+		return false
+	}
+	return true
+}
+
+func (fs *RemoteFS) endWrite() {
+	fs.writes.RUnlock()
 }
 
 func (fs *RemoteFS) ctx() (context.Context, context.CancelFunc) {
-	return context.WithTimeout(context.Background(), fs.timeout)
+	return context.WithTimeout(context.Background(), fs.Timeout())
 }
 
 func (fs *RemoteFS) allocFH(path string) uint64 {
@@ -64,6 +122,10 @@ func (fs *RemoteFS) pathForFH(fh uint64) string {
 }
 
 func (fs *RemoteFS) Getattr(path string, stat *fuse.Stat_t, fh uint64) int {
+	if isMobPath(path) {
+		return fs.mobGetattr(path, stat)
+	}
+
 	ctx, cancel := fs.ctx()
 	defer cancel()
 
@@ -77,6 +139,10 @@ func (fs *RemoteFS) Getattr(path string, stat *fuse.Stat_t, fh uint64) int {
 }
 
 func (fs *RemoteFS) Readdir(path string, fill func(name string, stat *fuse.Stat_t, ofst int64) bool, ofst int64, fh uint64) int {
+	if isMobPath(path) {
+		return fs.mobReaddir(path, fill)
+	}
+
 	ctx, cancel := fs.ctx()
 	defer cancel()
 
@@ -100,6 +166,17 @@ func (fs *RemoteFS) Readdir(path string, fill func(name string, stat *fuse.Stat_
 }
 
 func (fs *RemoteFS) Open(path string, flags int) (int, uint64) {
+	if isMobPath(path) {
+		if flags&fuse.O_ACCMODE != fuse.O_RDONLY {
+			return -fuse.EROFS, ^uint64(0)
+		}
+		var st fuse.Stat_t
+		if errc := fs.mobGetattr(path, &st); errc != 0 {
+			return errc, ^uint64(0)
+		}
+		return 0, fs.allocFH(path)
+	}
+
 	// Verify the file exists via Stat
 	ctx, cancel := fs.ctx()
 	defer cancel()
@@ -119,6 +196,14 @@ func (fs *RemoteFS) Release(path string, fh uint64) int {
 }
 
 func (fs *RemoteFS) Opendir(path string) (int, uint64) {
+	if isMobPath(path) {
+		var st fuse.Stat_t
+		if errc := fs.mobGetattr(path, &st); errc != 0 {
+			return errc, ^uint64(0)
+		}
+		return 0, fs.allocFH(path)
+	}
+
 	ctx, cancel := fs.ctx()
 	defer cancel()
 
@@ -137,6 +222,10 @@ func (fs *RemoteFS) Releasedir(path string, fh uint64) int {
 }
 
 func (fs *RemoteFS) Read(path string, buff []byte, ofst int64, fh uint64) int {
+	if isMobPath(path) {
+		return fs.mobRead(path, buff, ofst)
+	}
+
 	ctx, cancel := fs.ctx()
 	defer cancel()
 
@@ -154,6 +243,14 @@ func (fs *RemoteFS) Read(path string, buff []byte, ofst int64, fh uint64) int {
 }
 
 func (fs *RemoteFS) Write(path string, buff []byte, ofst int64, fh uint64) int {
+	if isMobPath(path) {
+		return -fuse.EROFS
+	}
+	if !fs.startWrite() {
+		return -fuse.EROFS
+	}
+	defer fs.endWrite()
+
 	ctx, cancel := fs.ctx()
 	defer cancel()
 
@@ -170,6 +267,14 @@ func (fs *RemoteFS) Write(path string, buff []byte, ofst int64, fh uint64) int {
 }
 
 func (fs *RemoteFS) Create(path string, flags int, mode uint32) (int, uint64) {
+	if isMobPath(path) {
+		return -fuse.EROFS, ^uint64(0)
+	}
+	if !fs.startWrite() {
+		return -fuse.EROFS, ^uint64(0)
+	}
+	defer fs.endWrite()
+
 	ctx, cancel := fs.ctx()
 	defer cancel()
 
@@ -186,6 +291,14 @@ func (fs *RemoteFS) Create(path string, flags int, mode uint32) (int, uint64) {
 }
 
 func (fs *RemoteFS) Mkdir(path string, mode uint32) int {
+	if isMobPath(path) {
+		return -fuse.EROFS
+	}
+	if !fs.startWrite() {
+		return -fuse.EROFS
+	}
+	defer fs.endWrite()
+
 	ctx, cancel := fs.ctx()
 	defer cancel()
 
@@ -200,6 +313,14 @@ func (fs *RemoteFS) Mkdir(path string, mode uint32) int {
 }
 
 func (fs *RemoteFS) Unlink(path string) int {
+	if isMobPath(path) {
+		return -fuse.EROFS
+	}
+	if !fs.startWrite() {
+		return -fuse.EROFS
+	}
+	defer fs.endWrite()
+
 	ctx, cancel := fs.ctx()
 	defer cancel()
 
@@ -211,6 +332,14 @@ func (fs *RemoteFS) Unlink(path string) int {
 }
 
 func (fs *RemoteFS) Rmdir(path string) int {
+	if isMobPath(path) {
+		return -fuse.EROFS
+	}
+	if !fs.startWrite() {
+		return -fuse.EROFS
+	}
+	defer fs.endWrite()
+
 	ctx, cancel := fs.ctx()
 	defer cancel()
 
@@ -222,6 +351,14 @@ func (fs *RemoteFS) Rmdir(path string) int {
 }
 
 func (fs *RemoteFS) Rename(oldpath string, newpath string) int {
+	if isMobPath(oldpath) || isMobPath(newpath) {
+		return -fuse.EROFS
+	}
+	if !fs.startWrite() {
+		return -fuse.EROFS
+	}
+	defer fs.endWrite()
+
 	ctx, cancel := fs.ctx()
 	defer cancel()
 
@@ -236,6 +373,14 @@ func (fs *RemoteFS) Rename(oldpath string, newpath string) int {
 }
 
 func (fs *RemoteFS) Truncate(path string, size int64, fh uint64) int {
+	if isMobPath(path) {
+		return -fuse.EROFS
+	}
+	if !fs.startWrite() {
+		return -fuse.EROFS
+	}
+	defer fs.endWrite()
+
 	ctx, cancel := fs.ctx()
 	defer cancel()
 
@@ -250,6 +395,14 @@ func (fs *RemoteFS) Truncate(path string, size int64, fh uint64) int {
 }
 
 func (fs *RemoteFS) Chmod(path string, mode uint32) int {
+	if isMobPath(path) {
+		return -fuse.EROFS
+	}
+	if !fs.startWrite() {
+		return -fuse.EROFS
+	}
+	defer fs.endWrite()
+
 	ctx, cancel := fs.ctx()
 	defer cancel()
 
@@ -0,0 +1,144 @@
+//go:build cgo
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	pb "github.com/victorarias/blue-guy/internal/proto/gen"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// watchSession prints session announcements from the host until ctx ends or
+// the stream breaks.
+func (c *Client) watchSession(ctx context.Context, sc pb.SessionServiceClient) {
+	stream, err := sc.WatchSession(ctx, &pb.WatchSessionRequest{})
+	if err != nil {
+		c.log.Debug().Err(err).Msg("Session events unavailable")
+		return
+	}
+
+	for {
+		event, err := stream.Recv()
+		if status.Code(err) == codes.PermissionDenied {
+			// Kicked by the host: every call would fail from here on
+			fmt.Printf("Disconnected: %s\n", status.Convert(err).Message())
+			c.cancel()
+			return
+		}
+		if err != nil {
+			return
+		}
+		switch e := event.Event.(type) {
+		case *pb.SessionEvent_DriverChange:
+			c.announceDriver(e.DriverChange)
+		case *pb.SessionEvent_Push:
+			c.announcePush(e.Push)
+		case *pb.SessionEvent_Secrets:
+			announceSecrets(e.Secrets)
+		case *pb.SessionEvent_LargeFiles:
+			announceLargeFiles(e.LargeFiles)
+		case *pb.SessionEvent_Ending:
+			c.endSession(ctx, sc, e.Ending)
+			return
+		}
+	}
+}
+
+// endSession winds the mount down when the host ends the session: no new
+// writes, the ones under way finish, the host hears we're done so it can
+// make its final commit, and the workspace unmounts.
+func (c *Client) endSession(ctx context.Context, sc pb.SessionServiceClient, e *pb.SessionEnding) {
+	msg := "The host ended the session"
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	fmt.Printf("%s. Finishing writes and unmounting %s\n", msg, c.mountPath)
+
+	c.remoteFS.StopWrites()
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(e.GraceMs)*time.Millisecond)
+	defer cancel()
+	if _, err := sc.Flushed(ctx, &pb.FlushedRequest{FlushId: e.FlushId}); err != nil {
+		c.log.Warn().Err(err).Msg("Host didn't hear that our writes are done")
+	}
+	c.cancel()
+}
+
+func (c *Client) announceDriver(dc *pb.DriverChange) {
+	r := dc.Rotation
+	if r == nil {
+		return
+	}
+	until := time.Unix(r.TurnEndsUnix, 0).Format("15:04")
+
+	if c.isMe(r.Driver) {
+		fmt.Printf("You're driving until %s. Next: %s\n", until, r.NextDriver)
+		return
+	}
+	fmt.Printf("Driver: %s until %s. Next: %s\n", r.Driver, until, r.NextDriver)
+	if r.DriversOnly {
+		fmt.Println("The workspace is read-only for you this turn.")
+	}
+}
+
+// announcePush reports when pushing starts failing and when it recovers;
+// routine successful pushes stay quiet.
+func (c *Client) announcePush(p *pb.PushState) {
+	failing := p.Failures > 0
+	if failing && p.LastError != c.pushErr {
+		wait := time.Unix(p.NextRetryUnix, 0).Sub(c.opts.Clock.Now()).Round(time.Second)
+		fmt.Printf("Push to %s failing (%d attempts), retrying in %s: %s\n",
+			p.Remote, p.Failures, max(wait, 0), p.LastError)
+	} else if !failing && c.pushErr != "" {
+		fmt.Printf("Push to %s recovered\n", p.Remote)
+	}
+	c.pushErr = ""
+	if failing {
+		c.pushErr = p.LastError
+	}
+}
+
+// announceSecrets warns that something just written looks like a
+// credential, so whoever pasted it can take it out.
+func announceSecrets(s *pb.SecretAlert) {
+	if s.Mode == "hold-push" {
+		fmt.Printf("Possible secrets committed in %.12s; pushing is on hold:\n", s.Commit)
+	} else {
+		fmt.Println("Possible secrets found; auto-commit is paused until they're removed:")
+	}
+	for _, f := range s.Findings {
+		fmt.Printf("  %s:%d  %s (%s)\n", f.Path, f.Line, f.Rule, f.Redacted)
+	}
+}
+
+// announceLargeFiles says which files aren't being committed, so nobody
+// assumes a dataset or build output made it into the session branch.
+func announceLargeFiles(lf *pb.LargeFiles) {
+	var skipped, lfs []string
+	for _, f := range lf.Files {
+		desc := fmt.Sprintf("%s (%.1f MB", f.Path, float64(f.Size)/(1<<20))
+		if f.Binary {
+			desc += ", binary"
+		}
+		desc += ")"
+		if f.Lfs {
+			lfs = append(lfs, desc)
+		} else {
+			skipped = append(skipped, desc)
+		}
+	}
+	if len(skipped) > 0 {
+		fmt.Printf("Too large to auto-commit, left uncommitted: %s\n", strings.Join(skipped, ", "))
+	}
+	if len(lfs) > 0 {
+		fmt.Printf("Committed through Git LFS: %s\n", strings.Join(lfs, ", "))
+	}
+}
+
+func (c *Client) isMe(name string) bool {
+	return strings.EqualFold(name, c.identity.Name) || strings.EqualFold(name, c.identity.Email)
+}
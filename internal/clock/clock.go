@@ -0,0 +1,57 @@
+// Package clock abstracts the time source so timing-dependent code
+// (debouncing, push retries, rotation turns, polling) can be tested without
+// sleeping.
+package clock
+
+import "time"
+
+// Clock tells the time and schedules work.
+type Clock interface {
+	Now() time.Time
+	// AfterFunc calls f in its own goroutine once d has passed.
+	AfterFunc(d time.Duration, f func()) Timer
+	// After sends the time on the returned channel once d has passed.
+	After(d time.Duration) <-chan time.Time
+	// NewTicker sends the time every d.
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is a pending AfterFunc call.
+type Timer interface {
+	// Stop cancels the call, reporting whether it was still pending.
+	Stop() bool
+}
+
+// Ticker delivers ticks until stopped.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Real is the system clock.
+var Real Clock = realClock{}
+
+// Or returns c, or Real when c is nil, so a nil Clock in an options struct
+// means the system clock.
+func Or(c Clock) Clock {
+	if c == nil {
+		return Real
+	}
+	return c
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer { return time.AfterFunc(d, f) }
+
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+func (realClock) NewTicker(d time.Duration) Ticker { return realTicker{time.NewTicker(d)} }
+
+type realTicker struct{ t *time.Ticker }
+
+func (t realTicker) C() <-chan time.Time { return t.t.C }
+
+func (t realTicker) Stop() { t.t.Stop() }
@@ -0,0 +1,135 @@
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Fake is a Clock that only moves when Advance is called. Timers due by
+// then run synchronously, in order, before Advance returns; tickers and
+// After channels are sent to without blocking, like the real ones.
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*waiter
+}
+
+type waiter struct {
+	clock  *Fake
+	at     time.Time
+	period time.Duration // tickers only
+	f      func()        // AfterFunc
+	ch     chan time.Time
+	done   bool
+}
+
+// NewFake returns a fake clock set to start.
+func NewFake(start time.Time) *Fake {
+	return &Fake{now: start}
+}
+
+func (c *Fake) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *Fake) AfterFunc(d time.Duration, f func()) Timer {
+	return c.add(&waiter{at: c.Now().Add(d), f: f})
+}
+
+func (c *Fake) After(d time.Duration) <-chan time.Time {
+	return c.add(&waiter{at: c.Now().Add(d), ch: make(chan time.Time, 1)}).ch
+}
+
+func (c *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive ticker interval")
+	}
+	return fakeTicker{c.add(&waiter{at: c.Now().Add(d), period: d, ch: make(chan time.Time, 1)})}
+}
+
+// Advance moves the clock forward by d, firing everything that falls due.
+func (c *Fake) Advance(d time.Duration) {
+	c.mu.Lock()
+	end := c.now.Add(d)
+	c.mu.Unlock()
+
+	for {
+		c.mu.Lock()
+		w := c.nextLocked(end)
+		if w == nil {
+			c.now = end
+			c.mu.Unlock()
+			return
+		}
+		c.now = w.at
+		at := w.at
+		if w.period > 0 {
+			w.at = w.at.Add(w.period)
+		} else {
+			w.done = true
+			c.removeLocked(w)
+		}
+		c.mu.Unlock()
+
+		if w.f != nil {
+			w.f()
+		} else {
+			select {
+			case w.ch <- at:
+			default:
+			}
+		}
+	}
+}
+
+// Pending reports how many timers, After channels and tickers are waiting.
+func (c *Fake) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+func (c *Fake) add(w *waiter) *waiter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	w.clock = c
+	c.waiters = append(c.waiters, w)
+	return w
+}
+
+func (c *Fake) nextLocked(end time.Time) *waiter {
+	sort.SliceStable(c.waiters, func(i, j int) bool { return c.waiters[i].at.Before(c.waiters[j].at) })
+	if len(c.waiters) == 0 || c.waiters[0].at.After(end) {
+		return nil
+	}
+	return c.waiters[0]
+}
+
+func (c *Fake) removeLocked(w *waiter) {
+	for i, x := range c.waiters {
+		if x == w {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+func (w *waiter) Stop() bool {
+	w.clock.mu.Lock()
+	defer w.clock.mu.Unlock()
+	if w.done {
+		return false
+	}
+	w.done = true
+	w.clock.removeLocked(w)
+	return true
+}
+
+type fakeTicker struct{ w *waiter }
+
+func (t fakeTicker) C() <-chan time.Time { return t.w.ch }
+
+func (t fakeTicker) Stop() { t.w.Stop() }
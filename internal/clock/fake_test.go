@@ -0,0 +1,86 @@
+package clock_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/victorarias/blue-guy/internal/clock"
+)
+
+var start = time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+
+func TestFake_AfterFuncRunsInOrderOnAdvance(t *testing.T) {
+	c := clock.NewFake(start)
+	var order []string
+	c.AfterFunc(2*time.Second, func() { order = append(order, "b") })
+	c.AfterFunc(time.Second, func() {
+		order = append(order, "a")
+		if got := c.Now(); !got.Equal(start.Add(time.Second)) {
+			t.Errorf("expected the clock at the timer's time, got %s", got)
+		}
+	})
+	stopped := c.AfterFunc(time.Second, func() { order = append(order, "stopped") })
+	if !stopped.Stop() || stopped.Stop() {
+		t.Error("expected Stop to report true once")
+	}
+
+	c.Advance(1500 * time.Millisecond)
+	if len(order) != 1 || order[0] != "a" {
+		t.Fatalf("expected only a, got %v", order)
+	}
+	c.Advance(time.Second)
+	if len(order) != 2 || order[1] != "b" {
+		t.Errorf("expected b next, got %v", order)
+	}
+	if !c.Now().Equal(start.Add(2500 * time.Millisecond)) {
+		t.Errorf("unexpected time %s", c.Now())
+	}
+}
+
+func TestFake_TimersScheduledWhileFiring(t *testing.T) {
+	c := clock.NewFake(start)
+	fired := 0
+	var again func()
+	again = func() {
+		fired++
+		c.AfterFunc(time.Second, again)
+	}
+	c.AfterFunc(time.Second, again)
+
+	c.Advance(3 * time.Second)
+	if fired != 3 {
+		t.Errorf("expected 3 firings, got %d", fired)
+	}
+}
+
+func TestFake_TickerAndAfter(t *testing.T) {
+	c := clock.NewFake(start)
+	ticker := c.NewTicker(time.Second)
+	after := c.After(1500 * time.Millisecond)
+
+	c.Advance(time.Second)
+	select {
+	case <-ticker.C():
+	default:
+		t.Fatal("expected a tick")
+	}
+	select {
+	case <-after:
+		t.Fatal("After fired early")
+	default:
+	}
+
+	c.Advance(5 * time.Second) // ticks coalesce like a real ticker's
+	<-after
+	<-ticker.C()
+	select {
+	case <-ticker.C():
+		t.Error("expected missed ticks to be dropped")
+	default:
+	}
+
+	ticker.Stop()
+	if c.Pending() != 0 {
+		t.Errorf("expected nothing pending, got %d", c.Pending())
+	}
+}
@@ -0,0 +1,170 @@
+// Package config layers blue-guy's settings: built-in defaults, then the
+// user's config file, then the workspace's blueguy.toml (or .yaml), then
+// BLUEGUY_* environment variables, then command-line flags.
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/victorarias/blue-guy/internal/gitops"
+	"github.com/victorarias/blue-guy/internal/host"
+)
+
+// Config is the merged result of every source.
+type Config struct {
+	Network   Network
+	Auth      Auth
+	Git       Git
+	Watcher   Watcher
+	Workspace Workspace
+	Cache     Cache
+	Rotation  Rotation
+
+	// Files lists the config files that were read, lowest precedence first.
+	Files []string
+}
+
+type Network struct {
+	Port int
+	// Bind is the address the host listens on.
+	Bind string
+	// Timeout bounds each filesystem call a client makes to the host.
+	Timeout time.Duration
+	// ShutdownGrace is how long a stopping host waits for clients to
+	// finish writing.
+	ShutdownGrace time.Duration
+}
+
+// Auth is who a client says it is, for commit credit.
+type Auth struct {
+	Name  string
+	Email string
+}
+
+type Git struct {
+	Backend        string
+	MessageHook    string
+	Push           gitops.PushPolicy
+	PushInterval   time.Duration
+	Remote         string
+	Refspec        string
+	Dirty          gitops.DirtyMode
+	Secrets        gitops.SecretMode
+	SecretsPaths   []string
+	SecretsAllow   []string
+	CommitDelay    time.Duration
+	MaxCommitDelay time.Duration // negative means no bound
+	// StructureCommitDelay applies after creates, deletes and renames; 0
+	// means CommitDelay
+	StructureCommitDelay time.Duration
+	MaxFileSize          int64 // negative means no limit
+	MaxBinarySize        int64
+	LFS                  bool
+	SquashOnStop         bool
+	Integrate            gitops.Integrate
+}
+
+type Watcher struct {
+	Backend      string
+	PollInterval time.Duration
+	// SubscriberBuffer is how many change events a slow subscriber can fall
+	// behind by before events are dropped for it.
+	SubscriberBuffer int
+}
+
+// Workspace is what the host shares.
+type Workspace struct {
+	// Export is a subdirectory of the workspace to share instead of all
+	// of it, slash-separated. Empty means the whole workspace.
+	Export string
+}
+
+type Cache struct {
+	// MaxReadSize is the most the host returns for a single read.
+	MaxReadSize int64
+}
+
+type Rotation struct {
+	Turn        time.Duration
+	Roster      []string
+	DriversOnly bool
+}
+
+// Default returns the built-in settings.
+func Default() Config {
+	return Config{
+		Network: Network{Port: 7654, Bind: "0.0.0.0", Timeout: 10 * time.Second, ShutdownGrace: 5 * time.Second},
+		Git: Git{
+			Backend:        gitops.BackendExec,
+			Push:           gitops.PushOnCommit,
+			Remote:         "origin",
+			Dirty:          gitops.DirtyRefuse,
+			Secrets:        gitops.SecretsBlock,
+			CommitDelay:    5 * time.Second,
+			MaxCommitDelay: time.Minute,
+			MaxFileSize:    50 << 20,
+			MaxBinarySize:  5 << 20,
+			Integrate:      gitops.IntegrateNone,
+		},
+		Watcher: Watcher{Backend: host.BackendFsnotify, PollInterval: 2 * time.Second, SubscriberBuffer: 64},
+		Cache:   Cache{MaxReadSize: 1 << 20},
+	}
+}
+
+// HostOptions translates the settings for host.New.
+func (c *Config) HostOptions() host.Options {
+	return host.Options{
+		Bind:             c.Network.Bind,
+		WatcherBackend:   c.Watcher.Backend,
+		PollInterval:     c.Watcher.PollInterval,
+		SubscriberBuffer: c.Watcher.SubscriberBuffer,
+		MaxReadSize:      c.Cache.MaxReadSize,
+		ShutdownGrace:    c.Network.ShutdownGrace,
+		Git: gitops.Options{
+			MessageHook: c.Git.MessageHook,
+			Dirty:       c.Git.Dirty,
+			Backend:     c.Git.Backend,
+			Push: gitops.PushOptions{
+				Policy:   c.Git.Push,
+				Interval: c.Git.PushInterval,
+				Remote:   c.Git.Remote,
+				Refspec:  c.Git.Refspec,
+			},
+			Secrets: gitops.SecretOptions{
+				Mode:        c.Git.Secrets,
+				AllowPaths:  c.Git.SecretsPaths,
+				AllowValues: c.Git.SecretsAllow,
+			},
+			CommitDelay:          c.Git.CommitDelay,
+			StructureCommitDelay: c.Git.StructureCommitDelay,
+			MaxCommitDelay:       c.Git.MaxCommitDelay,
+			Files: gitops.FileGuardOptions{
+				MaxFileSize:   c.Git.MaxFileSize,
+				MaxBinarySize: c.Git.MaxBinarySize,
+				LFS:           c.Git.LFS,
+			},
+		},
+		Rotation: host.RotationOptions{
+			Roster:      c.Rotation.Roster,
+			Turn:        c.Rotation.Turn,
+			DriversOnly: c.Rotation.DriversOnly,
+		},
+	}
+}
+
+// Error is a setting that couldn't be applied, naming where it came from.
+type Error struct {
+	Source string // file path, environment variable or flag
+	Key    string // e.g. git.commit_delay
+	Err    error
+}
+
+func (e *Error) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("%s: %v", e.Source, e.Err)
+	}
+	return fmt.Sprintf("%s: %s: %v", e.Source, e.Key, e.Err)
+}
+
+func (e *Error) Unwrap() error { return e.Err }
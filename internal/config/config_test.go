@@ -0,0 +1,269 @@
+package config_test
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/victorarias/blue-guy/internal/config"
+	"github.com/victorarias/blue-guy/internal/gitops"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// load reads settings with nothing from the real user dir or environment.
+func load(t *testing.T, opts config.LoadOptions) (*config.Config, error) {
+	t.Helper()
+	if opts.UserDir == "" {
+		opts.UserDir = t.TempDir()
+	}
+	if opts.Env == nil {
+		opts.Env = []string{}
+	}
+	return config.Load(opts)
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	cfg, err := load(t, config.LoadOptions{Workspace: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Network.Port != 7654 || cfg.Git.CommitDelay != 5*time.Second || cfg.Watcher.SubscriberBuffer != 64 || len(cfg.Files) != 0 {
+		t.Errorf("unexpected defaults %+v", cfg)
+	}
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	user, workspace := t.TempDir(), t.TempDir()
+	writeFile(t, user, "config.toml", `
+[network]
+port = 9000
+timeout = "30s"
+
+[git]
+commit_delay = "10s"
+push = "never"
+secrets_allow = ["^a{1,3}$", "^test-"]
+`)
+	writeFile(t, workspace, "blueguy.toml", `
+[git]
+commit_delay = "2s"
+max_file_size = "off"
+`)
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags := config.RegisterFlags(fs)
+	if err := fs.Parse([]string{"--commit-delay", "1s", "--lfs"}); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := load(t, config.LoadOptions{
+		Workspace: workspace,
+		UserDir:   user,
+		Env:       []string{"BLUEGUY_NETWORK_PORT=9100", "BLUEGUY_GIT_COMMIT_DELAY=3s", "HOME=/nowhere"},
+		Flags:     flags,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Network.Timeout != 30*time.Second || cfg.Git.Push != gitops.PushNever || len(cfg.Git.SecretsAllow) != 2 || cfg.Git.SecretsAllow[0] != "^a{1,3}$" {
+		t.Errorf("expected user settings to apply, got %+v", cfg)
+	}
+	if cfg.Git.MaxFileSize != -1 {
+		t.Errorf("expected workspace settings to apply, got %+v", cfg.Git)
+	}
+	if cfg.Network.Port != 9100 {
+		t.Errorf("expected the environment over files, got port %d", cfg.Network.Port)
+	}
+	if cfg.Git.CommitDelay != time.Second || !cfg.Git.LFS {
+		t.Errorf("expected flags over everything, got %+v", cfg.Git)
+	}
+	if len(cfg.Files) != 2 {
+		t.Errorf("expected both files to be recorded, got %v", cfg.Files)
+	}
+
+	// Flags left at their defaults don't override files
+	if cfg.Git.Backend != gitops.BackendExec || cfg.Git.Remote != "origin" {
+		t.Errorf("unexpected git settings %+v", cfg.Git)
+	}
+}
+
+func TestLoad_YAMLAndExplicitFile(t *testing.T) {
+	workspace := t.TempDir()
+	writeFile(t, workspace, "blueguy.toml", "[network]\nport = 1\n")
+	file := writeFile(t, t.TempDir(), "team.yaml", `
+git:
+  max_commit_delay: off
+  secrets: hold-push
+rotation:
+  turn: 10m
+  roster: [alice, bob]
+  drivers_only: true
+`)
+
+	cfg, err := load(t, config.LoadOptions{Workspace: workspace, File: file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Network.Port != 7654 {
+		t.Error("expected the explicit file to replace the workspace one")
+	}
+	if cfg.Git.MaxCommitDelay != -1 || cfg.Git.Secrets != gitops.SecretsHoldPush {
+		t.Errorf("unexpected git settings %+v", cfg.Git)
+	}
+	opts := cfg.HostOptions()
+	if opts.Rotation.Turn != 10*time.Minute || len(opts.Rotation.Roster) != 2 || !opts.Rotation.DriversOnly {
+		t.Errorf("unexpected rotation %+v", opts.Rotation)
+	}
+}
+
+func TestLoad_ErrorsNameTheKey(t *testing.T) {
+	for name, tc := range map[string]struct {
+		file, env string
+		want      []string
+	}{
+		"unknown key":     {file: "[git]\ncomit_delay = \"5s\"\n", want: []string{"blueguy.toml", "git.comit_delay", "unknown"}},
+		"bad duration":    {file: "[git]\ncommit_delay = 5\n", want: []string{"git.commit_delay", "duration"}},
+		"bad enum":        {file: "[git]\ndirty = \"sometimes\"\n", want: []string{"git.dirty", "sometimes"}},
+		"list for scalar": {file: "[network]\nport = [1, 2]\n", want: []string{"network.port", "list"}},
+		"not a section":   {file: "port = 1\n", want: []string{"port", "section"}},
+		"export outside":  {env: "BLUEGUY_WORKSPACE_EXPORT=../elsewhere", want: []string{"workspace.export", "subdirectory"}},
+		"bad env":         {env: "BLUEGUY_WATCHER_POLL_INTERVAL=soon", want: []string{"$BLUEGUY_WATCHER_POLL_INTERVAL", "watcher.poll_interval"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			workspace := t.TempDir()
+			if tc.file != "" {
+				writeFile(t, workspace, "blueguy.toml", tc.file)
+			}
+			env := []string{}
+			if tc.env != "" {
+				env = append(env, tc.env)
+			}
+			_, err := load(t, config.LoadOptions{Workspace: workspace, Env: env})
+			var cerr *config.Error
+			if !errors.As(err, &cerr) {
+				t.Fatalf("expected a config error, got %v", err)
+			}
+			for _, want := range tc.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("expected %q in %q", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestLoad_WorkspaceFileCantRunCommands(t *testing.T) {
+	// Clients can write the workspace's file, so a hook there would run
+	// whatever they like on the host
+	workspace := t.TempDir()
+	writeFile(t, workspace, "blueguy.toml", "[git]\nmessage_hook = \"touch pwned\"\n")
+	cfg, err := load(t, config.LoadOptions{Workspace: workspace})
+	if err == nil || !strings.Contains(err.Error(), "git.message_hook") {
+		t.Fatalf("expected the workspace's hook refused, got %v %+v", err, cfg)
+	}
+
+	// Your own config, an explicit file and the environment are fine
+	user := t.TempDir()
+	writeFile(t, user, "config.toml", "[git]\nmessage_hook = \"echo mine\"\n")
+	cfg, err = load(t, config.LoadOptions{Workspace: t.TempDir(), UserDir: user})
+	if err != nil || cfg.Git.MessageHook != "echo mine" {
+		t.Errorf("expected the user config's hook, got %v %q", err, cfg.Git.MessageHook)
+	}
+	file := writeFile(t, t.TempDir(), "team.toml", "[git]\nmessage_hook = \"echo team\"\n")
+	cfg, err = load(t, config.LoadOptions{Workspace: workspace, File: file})
+	if err != nil || cfg.Git.MessageHook != "echo team" {
+		t.Errorf("expected the explicit file's hook, got %v %q", err, cfg.Git.MessageHook)
+	}
+	cfg, err = load(t, config.LoadOptions{Workspace: t.TempDir(), Env: []string{"BLUEGUY_GIT_MESSAGE_HOOK=echo env"}})
+	if err != nil || cfg.Git.MessageHook != "echo env" {
+		t.Errorf("expected the environment's hook, got %v %q", err, cfg.Git.MessageHook)
+	}
+}
+
+func TestLoad_WorkspaceFileCantWeakenTheHost(t *testing.T) {
+	for key, file := range map[string]string{
+		"git.secrets":             "[git]\nsecrets = \"off\"\n",
+		"git.secrets_allow_paths": "[git]\nsecrets_allow_paths = [\"*\"]\n",
+		"git.remote":              "[git]\nremote = \"theirs\"\n",
+		"git.refspec":             "[git]\nrefspec = \"refs/heads/{branch}:refs/heads/main\"\n",
+		"git.push":                "[git]\npush = \"commit\"\n",
+		"git.backend":             "[git]\nbackend = \"exec\"\n",
+		"workspace.export":        "[workspace]\nexport = \"src\"\n",
+	} {
+		workspace := t.TempDir()
+		writeFile(t, workspace, "blueguy.toml", file)
+		if _, err := load(t, config.LoadOptions{Workspace: workspace}); err == nil || !strings.Contains(err.Error(), key) {
+			t.Errorf("expected %s refused from the workspace file, got %v", key, err)
+		}
+	}
+}
+
+func TestLoad_BadFlagIsReported(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags := config.RegisterFlags(fs)
+	fs.Parse([]string{"--max-file-size", "huge"})
+
+	_, err := load(t, config.LoadOptions{Flags: flags})
+	if err == nil || !strings.HasPrefix(err.Error(), "--max-file-size: ") {
+		t.Errorf("expected the flag to be named, got %v", err)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	workspace := t.TempDir()
+	running, err := load(t, config.LoadOptions{Workspace: workspace})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, workspace, "blueguy.toml", `
+[git]
+commit_delay = "1s"
+dirty = "stash"
+`)
+	next, err := load(t, config.LoadOptions{Workspace: workspace})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied, restart := running.Update(next, config.HostLive)
+	if len(applied) != 1 || applied[0] != "git.commit_delay" || running.Git.CommitDelay != time.Second {
+		t.Errorf("expected the commit delay applied, got %v", applied)
+	}
+	if len(restart) != 1 || restart[0] != "git.dirty" || running.Git.Dirty == gitops.DirtyStash {
+		t.Errorf("expected the dirty mode left for a restart, got %v", restart)
+	}
+
+	// Still pending until the restart, but nothing new to apply
+	applied, restart = running.Update(next, config.HostLive)
+	if len(applied) != 0 || len(restart) != 1 {
+		t.Errorf("expected only the restart setting again, got %v %v", applied, restart)
+	}
+}
+
+func TestUpdate_MessageHookNeedsRestart(t *testing.T) {
+	running, err := load(t, config.LoadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, err := load(t, config.LoadOptions{Env: []string{"BLUEGUY_GIT_MESSAGE_HOOK=echo hi"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied, restart := running.Update(next, config.HostLive)
+	if len(applied) != 0 || len(restart) != 1 || running.Git.MessageHook != "" {
+		t.Errorf("expected the hook left for a restart, got %v %v", applied, restart)
+	}
+}
@@ -0,0 +1,254 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// workspaceFiles are looked for in the workspace root, and userFiles in the
+// user's config directory (e.g. ~/.config/blue-guy). The first one found
+// wins; TOML and YAML take the same keys.
+var (
+	workspaceFiles = []string{"blueguy.toml", "blueguy.yaml", "blueguy.yml"}
+	userFiles      = []string{"config.toml", "config.yaml", "config.yml"}
+)
+
+// LoadOptions says where to look for settings.
+type LoadOptions struct {
+	// Workspace is searched for blueguy.toml. Empty skips it.
+	Workspace string
+	// File, if set, is read instead of the workspace's config file.
+	File string
+	// UserDir overrides the user config directory. Empty means
+	// os.UserConfigDir()/blue-guy.
+	UserDir string
+	// Env defaults to os.Environ().
+	Env []string
+	// Flags holds command-line overrides, applied last.
+	Flags *Flags
+}
+
+// Load merges every source over the defaults. Errors name the source and
+// key at fault.
+func Load(opts LoadOptions) (*Config, error) {
+	cfg := Default()
+
+	userDir := opts.UserDir
+	if userDir == "" {
+		if dir, err := os.UserConfigDir(); err == nil {
+			userDir = filepath.Join(dir, "blue-guy")
+		}
+	}
+	// The workspace's own file is shared with every client, so it's the
+	// one file that isn't trusted
+	type file struct {
+		path    string
+		trusted bool
+	}
+	var files []file
+	if path := findFile(userDir, userFiles); path != "" {
+		files = append(files, file{path, true})
+	}
+	if opts.File != "" {
+		files = append(files, file{opts.File, true})
+	} else if path := findFile(opts.Workspace, workspaceFiles); path != "" {
+		files = append(files, file{path, false})
+	}
+	for _, f := range files {
+		if err := cfg.loadFile(f.path, f.trusted); err != nil {
+			return nil, err
+		}
+		cfg.Files = append(cfg.Files, f.path)
+	}
+
+	env := opts.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	if err := cfg.loadEnv(env); err != nil {
+		return nil, err
+	}
+
+	if opts.Flags != nil {
+		if err := opts.Flags.apply(&cfg); err != nil {
+			return nil, err
+		}
+	}
+	return &cfg, nil
+}
+
+func findFile(dir string, names []string) string {
+	if dir == "" {
+		return ""
+	}
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// loadFile applies a TOML or YAML file, chosen by extension. Unless the
+// file is trusted, settings that could turn the host against its owner --
+// running commands, pushing elsewhere, switching off the secret scanner --
+// are refused.
+func (c *Config) loadFile(path string, trusted bool) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return &Error{Source: path, Err: err}
+	}
+	var doc map[string]any
+	if ext := filepath.Ext(path); ext == ".yaml" || ext == ".yml" {
+		err = yaml.Unmarshal(data, &doc)
+	} else {
+		err = toml.Unmarshal(data, &doc)
+	}
+	if err != nil {
+		return &Error{Source: path, Err: err}
+	}
+
+	// Sorted so the first error reported doesn't depend on map order
+	sections := make([]string, 0, len(doc))
+	for name := range doc {
+		sections = append(sections, name)
+	}
+	sort.Strings(sections)
+	for _, section := range sections {
+		values, ok := doc[section].(map[string]any)
+		if !ok {
+			return &Error{Source: path, Key: section, Err: fmt.Errorf("expected a section like [%s]", section)}
+		}
+		names := make([]string, 0, len(values))
+		for name := range values {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			key := section + "." + name
+			if s := lookup(key); s != nil && s.trusted != "" && !trusted {
+				return &Error{Source: path, Key: key, Err: fmt.Errorf("%s, so it can only be set in your own config, the environment or a flag", s.trusted)}
+			}
+			if err := c.setValue(key, values[name]); err != nil {
+				return &Error{Source: path, Key: key, Err: err}
+			}
+		}
+	}
+	return nil
+}
+
+// setValue applies a decoded file value: a string, number, bool, or for
+// list settings an array.
+func (c *Config) setValue(key string, v any) error {
+	s := lookup(key)
+	if s == nil {
+		return fmt.Errorf("unknown setting")
+	}
+	switch v := v.(type) {
+	case string:
+		return s.set(c, v)
+	case bool, int, int64, uint64, float64:
+		return s.set(c, fmt.Sprint(v))
+	case []any:
+		if s.setList == nil {
+			return fmt.Errorf("expected a single value, got a list")
+		}
+		items := make([]string, len(v))
+		for i, item := range v {
+			str, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("expected a list of strings, got %v", item)
+			}
+			items[i] = str
+		}
+		s.setList(c, items)
+		return nil
+	default:
+		return fmt.Errorf("unsupported value %v", v)
+	}
+}
+
+// loadEnv applies BLUEGUY_<SECTION>_<NAME> variables.
+func (c *Config) loadEnv(env []string) error {
+	vars := make(map[string]string)
+	for _, kv := range env {
+		if name, value, ok := strings.Cut(kv, "="); ok && strings.HasPrefix(name, envPrefix) {
+			vars[name] = value
+		}
+	}
+	for _, s := range settings {
+		v, ok := vars[s.env()]
+		if !ok {
+			continue
+		}
+		if err := s.set(c, v); err != nil {
+			return &Error{Source: "$" + s.env(), Key: s.key, Err: err}
+		}
+	}
+	return nil
+}
+
+// Flags collects command-line overrides. Flags the user didn't pass leave
+// files and the environment alone.
+type Flags struct {
+	fs *flag.FlagSet
+}
+
+// RegisterFlags defines a flag for every setting that has one, defaulting
+// to the built-in value.
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	defaults := Default()
+	for _, s := range settings {
+		if s.flag == "" {
+			continue
+		}
+		def := s.get(&defaults)
+		switch s.kind {
+		case kindBool:
+			fs.Bool(s.flag, def == "true", s.usage)
+		case kindInt:
+			n, _ := strconv.Atoi(def)
+			fs.Int(s.flag, n, s.usage)
+		case kindDuration:
+			d, _ := time.ParseDuration(def)
+			fs.Duration(s.flag, d, s.usage)
+		default:
+			fs.String(s.flag, def, s.usage)
+		}
+	}
+	return &Flags{fs: fs}
+}
+
+// apply sets the settings for flags given on the command line.
+func (f *Flags) apply(c *Config) error {
+	var err error
+	f.fs.Visit(func(fl *flag.Flag) {
+		s := flagSetting(fl.Name)
+		if s == nil || err != nil {
+			return
+		}
+		if e := s.set(c, fl.Value.String()); e != nil {
+			err = &Error{Source: "--" + fl.Name, Err: e}
+		}
+	})
+	return err
+}
+
+func flagSetting(name string) *setting {
+	for _, s := range settings {
+		if s.flag == name {
+			return s
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package config
+
+// HostLive and ClientLive are the settings a running host or client picks
+// up when its config is reloaded. Changing any other setting takes a
+// restart.
+var (
+	HostLive = []string{
+		"git.commit_delay",
+		"git.structure_commit_delay",
+		"git.max_commit_delay",
+		"git.max_file_size",
+		"git.max_binary_size",
+		"cache.max_read_size",
+	}
+	ClientLive = []string{"network.timeout"}
+)
+
+// Update copies the settings in live from next into c, for a reload. It
+// reports which of them changed, and which other settings differ but were
+// left alone because they need a restart.
+func (c *Config) Update(next *Config, live []string) (applied, restart []string) {
+	isLive := make(map[string]bool, len(live))
+	for _, key := range live {
+		isLive[key] = true
+	}
+	for _, s := range settings {
+		v := s.get(next)
+		if s.get(c) == v {
+			continue
+		}
+		if !isLive[s.key] {
+			restart = append(restart, s.key)
+			continue
+		}
+		// next already parsed it, so this can't fail
+		s.set(c, v)
+		applied = append(applied, s.key)
+	}
+	return applied, restart
+}
@@ -0,0 +1,328 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/victorarias/blue-guy/internal/gitops"
+	"github.com/victorarias/blue-guy/internal/host"
+)
+
+// setting is one configurable value. The same table drives config files,
+// environment variables and flags, so they can't drift apart.
+type setting struct {
+	key   string // section.name, as written in config files
+	flag  string // command-line flag, empty if there is none
+	usage string
+	kind  kind // how the flag is shown in --help
+	set   func(c *Config, v string) error
+	get   func(c *Config) string
+	// setList takes an array from a config file whole, so entries may
+	// contain commas. Only list settings have it.
+	setList func(c *Config, v []string)
+	// trusted, if set, is why the setting can't come from the workspace's
+	// config file: every client can write to that.
+	trusted string
+}
+
+type kind int
+
+const (
+	kindString kind = iota
+	kindBool
+	kindInt
+	kindDuration
+)
+
+// env is the environment variable for the setting, e.g. BLUEGUY_GIT_COMMIT_DELAY.
+func (s *setting) env() string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(s.key, ".", "_"))
+}
+
+const envPrefix = "BLUEGUY_"
+
+var settings = []*setting{
+	intSetting("network.port", "port", "Port to listen on (host mode)",
+		func(c *Config) *int { return &c.Network.Port }, 1, 65535),
+	stringSetting("network.bind", "bind", "Address to listen on (host mode)",
+		func(c *Config) *string { return &c.Network.Bind }),
+	durationSetting("network.timeout", "timeout", "How long a filesystem call waits for the host before failing (client mode)",
+		func(c *Config) *time.Duration { return &c.Network.Timeout }),
+	durationSetting("network.shutdown_grace", "shutdown-grace", "How long a stopping host waits for clients to finish writing before its final commit (host mode)",
+		func(c *Config) *time.Duration { return &c.Network.ShutdownGrace }),
+
+	stringSetting("auth.name", "name", "Your name for commit credit (client mode, default: git config user.name)",
+		func(c *Config) *string { return &c.Auth.Name }),
+	stringSetting("auth.email", "email", "Your email for commit credit (client mode, default: git config user.email)",
+		func(c *Config) *string { return &c.Auth.Email }),
+
+	trusted(whyHooks, &setting{
+		key: "git.backend", flag: "git-backend",
+		usage: "How auto-commits are made: exec (git binary, runs your hooks) or go-git (in-process) (host mode)",
+		set: func(c *Config, v string) (err error) {
+			c.Git.Backend, err = gitops.ParseBackend(v)
+			return err
+		},
+		get: func(c *Config) string { return c.Git.Backend },
+	}),
+	trusted(whyCommands, stringSetting("git.message_hook", "commit-msg-hook", "Shell command that prints the auto-commit message, given the staged diff on stdin (host mode)",
+		func(c *Config) *string { return &c.Git.MessageHook })),
+	trusted(whyPush, &setting{
+		key: "git.push", flag: "push",
+		usage: "When to push auto-commits: commit, stop, never, or an interval like 10m (host mode)",
+		set: func(c *Config, v string) (err error) {
+			c.Git.Push, c.Git.PushInterval, err = gitops.ParsePushPolicy(v)
+			return err
+		},
+		get: func(c *Config) string {
+			if c.Git.Push == gitops.PushInterval {
+				return c.Git.PushInterval.String()
+			}
+			return string(c.Git.Push)
+		},
+	}),
+	trusted(whyPush, stringSetting("git.remote", "remote", "Remote to push the session branch to (host mode)",
+		func(c *Config) *string { return &c.Git.Remote })),
+	trusted(whyPush, stringSetting("git.refspec", "refspec", "Push refspec; {branch} is the session branch (default refs/heads/{branch}:refs/heads/{branch}) (host mode)",
+		func(c *Config) *string { return &c.Git.Refspec })),
+	{
+		key: "git.dirty", flag: "dirty",
+		usage: "Uncommitted changes at start: refuse, stash (restored on stop) or include in the session (host mode)",
+		set: func(c *Config, v string) (err error) {
+			c.Git.Dirty, err = gitops.ParseDirtyMode(v)
+			return err
+		},
+		get: func(c *Config) string { return string(c.Git.Dirty) },
+	},
+	trusted(whySecrets, &setting{
+		key: "git.secrets", flag: "secrets",
+		usage: "Possible credentials in changes: block the commit, hold-push (commit but stop pushing) or off (host mode)",
+		set: func(c *Config, v string) (err error) {
+			c.Git.Secrets, err = gitops.ParseSecretMode(v)
+			return err
+		},
+		get: func(c *Config) string { return string(c.Git.Secrets) },
+	}),
+	trusted(whySecrets, listSetting("git.secrets_allow_paths", "secrets-allow-paths", "Comma-separated globs of files not scanned for secrets, e.g. testdata/,*.example (host mode)",
+		func(c *Config) *[]string { return &c.Git.SecretsPaths })),
+	trusted(whySecrets, listSetting("git.secrets_allow", "secrets-allow", "Comma-separated regexps for known-harmless values the secret scanner should ignore (host mode)",
+		func(c *Config) *[]string { return &c.Git.SecretsAllow })),
+	durationSetting("git.commit_delay", "commit-delay", "Quiet period before an auto-commit (host mode)",
+		func(c *Config) *time.Duration { return &c.Git.CommitDelay }),
+	durationSetting("git.structure_commit_delay", "structure-commit-delay", "Quiet period before an auto-commit once files are created, deleted or renamed; 0 means the commit delay (host mode)",
+		func(c *Config) *time.Duration { return &c.Git.StructureCommitDelay }),
+	{
+		key: "git.max_commit_delay", flag: "max-commit-delay",
+		usage: "Longest continuous changes can hold an auto-commit back, or off (host mode)",
+		set: func(c *Config, v string) error {
+			if v == "off" {
+				c.Git.MaxCommitDelay = -1
+				return nil
+			}
+			d, err := time.ParseDuration(v)
+			if err != nil || d <= 0 {
+				return fmt.Errorf("want a duration like 1m, or off; got %q", v)
+			}
+			c.Git.MaxCommitDelay = d
+			return nil
+		},
+		get: func(c *Config) string {
+			if c.Git.MaxCommitDelay < 0 {
+				return "off"
+			}
+			return c.Git.MaxCommitDelay.String()
+		},
+	},
+	sizeSetting("git.max_file_size", "max-file-size", "Largest file auto-committed, or off (host mode)",
+		func(c *Config) *int64 { return &c.Git.MaxFileSize }, true),
+	sizeSetting("git.max_binary_size", "max-binary-size", "Largest binary file auto-committed, or off (host mode)",
+		func(c *Config) *int64 { return &c.Git.MaxBinarySize }, true),
+	boolSetting("git.lfs", "lfs", "Commit files over the size limits through Git LFS instead of skipping them (host mode)",
+		func(c *Config) *bool { return &c.Git.LFS }),
+	boolSetting("git.squash_on_stop", "squash-on-stop", "On shutdown, prompt for a message and squash the session into one commit (host mode)",
+		func(c *Config) *bool { return &c.Git.SquashOnStop }),
+	{
+		key: "git.integrate", flag: "integrate",
+		usage: "With --squash-on-stop: none, merge or rebase onto the original branch (host mode)",
+		set: func(c *Config, v string) (err error) {
+			c.Git.Integrate, err = gitops.ParseIntegrate(v)
+			return err
+		},
+		get: func(c *Config) string { return string(c.Git.Integrate) },
+	},
+
+	{
+		key: "watcher.backend", flag: "watcher",
+		usage: "File watcher backend: fsnotify or fanotify (Linux, needs CAP_SYS_ADMIN) (host mode)",
+		set: func(c *Config, v string) error {
+			if v != host.BackendFsnotify && v != host.BackendFanotify {
+				return fmt.Errorf("unknown watcher backend %q (want %s or %s)", v, host.BackendFsnotify, host.BackendFanotify)
+			}
+			c.Watcher.Backend = v
+			return nil
+		},
+		get: func(c *Config) string { return c.Watcher.Backend },
+	},
+	durationSetting("watcher.poll_interval", "poll-interval", "Rescan interval for directories beyond the OS watch limit (host mode)",
+		func(c *Config) *time.Duration { return &c.Watcher.PollInterval }),
+	intSetting("watcher.subscriber_buffer", "", "",
+		func(c *Config) *int { return &c.Watcher.SubscriberBuffer }, 1, 1<<20),
+
+	trusted(whyExport, &setting{
+		key: "workspace.export", flag: "export",
+		usage: "Share only this subdirectory of the repository; auto-commits stay inside it (host mode)",
+		set: func(c *Config, v string) error {
+			clean := filepath.ToSlash(filepath.Clean(v))
+			if filepath.IsAbs(v) || clean == ".." || strings.HasPrefix(clean, "../") {
+				return fmt.Errorf("want a subdirectory of the workspace, got %q", v)
+			}
+			if clean == "." {
+				clean = ""
+			}
+			c.Workspace.Export = clean
+			return nil
+		},
+		get: func(c *Config) string { return c.Workspace.Export },
+	}),
+
+	sizeSetting("cache.max_read_size", "", "",
+		func(c *Config) *int64 { return &c.Cache.MaxReadSize }, false),
+
+	durationSetting("rotation.turn", "rotate", "Driver turn length, e.g. 10m; enables mob rotation (host mode)",
+		func(c *Config) *time.Duration { return &c.Rotation.Turn }),
+	listSetting("rotation.roster", "roster", "Comma-separated driver names in turn order, matched against client --name or --email (host mode)",
+		func(c *Config) *[]string { return &c.Rotation.Roster }),
+	boolSetting("rotation.drivers_only", "drivers-only", "With --rotate: make everyone except the current driver read-only; advisory, since clients name themselves (host mode)",
+		func(c *Config) *bool { return &c.Rotation.DriversOnly }),
+}
+
+func lookup(key string) *setting {
+	for _, s := range settings {
+		if s.key == key {
+			return s
+		}
+	}
+	return nil
+}
+
+// Why settings are kept out of the workspace's config file.
+const (
+	whyCommands = "runs commands on the host"
+	whyHooks    = "decides whether the repository's git hooks run on the host"
+	whyPush     = "decides where the session is pushed"
+	whySecrets  = "controls the secret scanner"
+	whyExport   = "decides what the host shares"
+)
+
+// trusted keeps s out of the workspace's config file, for the given reason.
+func trusted(why string, s *setting) *setting {
+	s.trusted = why
+	return s
+}
+
+func stringSetting(key, flag, usage string, field func(*Config) *string) *setting {
+	return &setting{
+		key: key, flag: flag, usage: usage,
+		set: func(c *Config, v string) error { *field(c) = v; return nil },
+		get: func(c *Config) string { return *field(c) },
+	}
+}
+
+func intSetting(key, flag, usage string, field func(*Config) *int, lo, hi int) *setting {
+	return &setting{
+		key: key, flag: flag, usage: usage, kind: kindInt,
+		set: func(c *Config, v string) error {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < lo || n > hi {
+				return fmt.Errorf("want a number from %d to %d, got %q", lo, hi, v)
+			}
+			*field(c) = n
+			return nil
+		},
+		get: func(c *Config) string { return strconv.Itoa(*field(c)) },
+	}
+}
+
+func boolSetting(key, flag, usage string, field func(*Config) *bool) *setting {
+	return &setting{
+		key: key, flag: flag, usage: usage, kind: kindBool,
+		set: func(c *Config, v string) error {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("want true or false, got %q", v)
+			}
+			*field(c) = b
+			return nil
+		},
+		get: func(c *Config) string { return strconv.FormatBool(*field(c)) },
+	}
+}
+
+func durationSetting(key, flag, usage string, field func(*Config) *time.Duration) *setting {
+	return &setting{
+		key: key, flag: flag, usage: usage, kind: kindDuration,
+		set: func(c *Config, v string) error {
+			d, err := time.ParseDuration(v)
+			if err != nil || d < 0 {
+				return fmt.Errorf("want a duration like 5s or 10m, got %q", v)
+			}
+			*field(c) = d
+			return nil
+		},
+		get: func(c *Config) string { return field(c).String() },
+	}
+}
+
+// sizeSetting takes a size such as 10MB. Unless offOK, "off" is refused.
+func sizeSetting(key, flag, usage string, field func(*Config) *int64, offOK bool) *setting {
+	return &setting{
+		key: key, flag: flag, usage: usage,
+		set: func(c *Config, v string) error {
+			n, err := gitops.ParseSize(v)
+			if err != nil {
+				return err
+			}
+			if n < 0 && !offOK {
+				return fmt.Errorf("a limit is required, got %q", v)
+			}
+			*field(c) = n
+			return nil
+		},
+		get: func(c *Config) string {
+			switch n := *field(c); {
+			case n < 0:
+				return "off"
+			case n%(1<<20) == 0:
+				return fmt.Sprintf("%dMB", n>>20)
+			default:
+				return strconv.FormatInt(n, 10)
+			}
+		},
+	}
+}
+
+func listSetting(key, flag, usage string, field func(*Config) *[]string) *setting {
+	return &setting{
+		key: key, flag: flag, usage: usage,
+		set: func(c *Config, v string) error {
+			*field(c) = splitList(v)
+			return nil
+		},
+		setList: func(c *Config, v []string) { *field(c) = v },
+		get:     func(c *Config) string { return strings.Join(*field(c), ",") },
+	}
+}
+
+// splitList parses a comma-separated value, dropping empty entries.
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -0,0 +1,157 @@
+// Package control finds, creates and dials the Unix sockets host and client
+// processes serve the ControlService on. Sockets live in a per-user
+// directory only that user can enter, and connections from anyone else
+// are dropped.
+package control
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	pb "github.com/victorarias/blue-guy/internal/proto/gen"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// Roles reported in ControlStatus.Role and used in socket names.
+const (
+	RoleHost   = "host"
+	RoleClient = "client"
+)
+
+// Dir is where control sockets live: $XDG_RUNTIME_DIR/blue-guy, or
+// blue-guy-<uid> in the temp dir. BLUEGUY_CONTROL_DIR overrides it.
+func Dir() string {
+	if dir := os.Getenv("BLUEGUY_CONTROL_DIR"); dir != "" {
+		return dir
+	}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "blue-guy")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("blue-guy-%d", os.Getuid()))
+}
+
+// Listen creates the control socket for this process. Connections from
+// other users are closed before they're handed out.
+func Listen(role string) (net.Listener, string, error) {
+	dir := Dir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, "", fmt.Errorf("create control dir: %w", err)
+	}
+	// MkdirAll leaves an existing directory alone, so check it's still ours
+	if info, err := os.Stat(dir); err != nil {
+		return nil, "", err
+	} else if info.Mode().Perm()&0077 != 0 {
+		return nil, "", fmt.Errorf("control dir %s is accessible to other users (mode %s)", dir, info.Mode().Perm())
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("%s-%d.sock", role, os.Getpid()))
+	os.Remove(path) // left behind by an earlier process with our pid
+	lis, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, "", fmt.Errorf("listen on %s: %w", path, err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		lis.Close()
+		return nil, "", err
+	}
+	return &ownerListener{UnixListener: lis.(*net.UnixListener), uid: os.Getuid()}, path, nil
+}
+
+// ownerListener accepts connections only from the user it runs as. The
+// permissions on the directory and socket should already see to that;
+// this catches root and anything that loosened them.
+type ownerListener struct {
+	*net.UnixListener
+	uid int
+}
+
+func (l *ownerListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.AcceptUnix()
+		if err != nil {
+			return nil, err
+		}
+		uid, err := peerUID(conn)
+		if (err == nil && uid == l.uid) || errors.Is(err, errors.ErrUnsupported) {
+			return conn, nil
+		}
+		conn.Close()
+	}
+}
+
+// Endpoint is a control socket found in Dir.
+type Endpoint struct {
+	Path string
+	Role string
+	PID  int
+}
+
+// Find lists the control sockets of running processes, optionally only
+// those with the given role, removing sockets whose process has gone.
+func Find(role string) []Endpoint {
+	matches, _ := filepath.Glob(filepath.Join(Dir(), "*.sock"))
+	var found []Endpoint
+	for _, path := range matches {
+		r, pidStr, ok := strings.Cut(strings.TrimSuffix(filepath.Base(path), ".sock"), "-")
+		pid, err := strconv.Atoi(pidStr)
+		if !ok || err != nil || (role != "" && r != role) {
+			continue
+		}
+		if !alive(path) {
+			os.Remove(path)
+			continue
+		}
+		found = append(found, Endpoint{Path: path, Role: r, PID: pid})
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].PID < found[j].PID })
+	return found
+}
+
+// alive reports whether something is accepting connections on the socket.
+func alive(path string) bool {
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// Dial connects to a control socket.
+func Dial(path string) (pb.ControlServiceClient, func() error, error) {
+	conn, err := grpc.NewClient("passthrough:///"+path,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", addr)
+		}),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pb.NewControlServiceClient(conn), conn.Close, nil
+}
+
+// SetLogLevel implements ControlService.SetLogLevel for hosts and clients
+// alike: the level applies to every logger in the process.
+func SetLogLevel(req *pb.SetLogLevelRequest) (*pb.SetLogLevelResponse, error) {
+	level, err := zerolog.ParseLevel(strings.ToLower(req.Level))
+	if err != nil || req.Level == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown log level %q (want trace, debug, info, warn, error or disabled)", req.Level)
+	}
+	prev := zerolog.GlobalLevel()
+	zerolog.SetGlobalLevel(level)
+	return &pb.SetLogLevelResponse{Previous: prev.String(), Level: level.String()}, nil
+}
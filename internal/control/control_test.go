@@ -0,0 +1,64 @@
+package control_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/victorarias/blue-guy/internal/control"
+)
+
+func TestListen_OwnerOnlySocket(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "ctl")
+	t.Setenv("BLUEGUY_CONTROL_DIR", dir)
+
+	lis, path, err := control.Listen(control.RoleHost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+
+	if info, _ := os.Stat(dir); info.Mode().Perm() != 0700 {
+		t.Errorf("expected the dir to be 0700, got %s", info.Mode().Perm())
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("expected the socket to be 0600, got %s", info.Mode().Perm())
+	}
+
+	found := control.Find(control.RoleHost)
+	if len(found) != 1 || found[0].Path != path || found[0].PID != os.Getpid() {
+		t.Errorf("expected to find our socket, got %v", found)
+	}
+	if len(control.Find(control.RoleClient)) != 0 {
+		t.Error("expected no client sockets")
+	}
+}
+
+func TestFind_RemovesStaleSockets(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "ctl")
+	t.Setenv("BLUEGUY_CONTROL_DIR", dir)
+
+	lis, path, err := control.Listen(control.RoleClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis.(interface{ SetUnlinkOnClose(bool) }).SetUnlinkOnClose(false)
+	lis.Close() // the process "crashed", leaving its socket behind
+
+	if found := control.Find(""); len(found) != 0 {
+		t.Errorf("expected nothing running, got %v", found)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("expected the stale socket to be removed")
+	}
+}
+
+func TestListen_RefusesSharedDir(t *testing.T) {
+	dir := t.TempDir()
+	os.Chmod(dir, 0777)
+	t.Setenv("BLUEGUY_CONTROL_DIR", dir)
+
+	if _, _, err := control.Listen(control.RoleHost); err == nil {
+		t.Error("expected a world-accessible dir to be refused")
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !darwin
+
+package control
+
+import (
+	"errors"
+	"net"
+)
+
+// peerUID can't tell who connected on this platform, so the socket's file
+// permissions are the only check.
+func peerUID(*net.UnixConn) (int, error) {
+	return 0, errors.ErrUnsupported
+}
@@ -3,39 +3,121 @@ package gitops
 import (
 	"sync"
 	"time"
+
+	"github.com/victorarias/blue-guy/internal/clock"
 )
 
+// ChangeKind is the kind of change a Trigger is for.
+type ChangeKind int
+
+const (
+	// ChangeEdit is a file's contents changing.
+	ChangeEdit ChangeKind = iota
+	// ChangeStructure is a file being created, deleted or renamed.
+	ChangeStructure
+)
+
+// DebounceOptions configures a Debouncer.
+type DebounceOptions struct {
+	// Delay is the quiet period after the last Trigger.
+	Delay time.Duration
+	// StructureDelay is the quiet period once a burst includes a
+	// ChangeStructure, if shorter than Delay. 0 means Delay.
+	StructureDelay time.Duration
+	// MaxWait caps how long a burst of Triggers can hold the call back,
+	// measured from the first one. 0 means no cap.
+	MaxWait time.Duration
+	// Clock defaults to the real time.
+	Clock clock.Clock
+}
+
 // Debouncer calls a function after a quiet period.
-// Each call to Trigger resets the timer.
+// Each call to Trigger resets the timer, up to MaxWait after the first.
 type Debouncer struct {
-	delay   time.Duration
+	opts    DebounceOptions
 	fn      func()
-	timer   *time.Timer
 	mu      sync.Mutex
+	timer   clock.Timer
+	gen     int           // identifies the current timer, so a stale one is ignored
+	first   time.Time     // first Trigger since the last call
+	quiet   time.Duration // shortest quiet period any Trigger since then asked for
+	fireAt  time.Time
 	running sync.Mutex // serializes fn() execution
 }
 
 func NewDebouncer(delay time.Duration, fn func()) *Debouncer {
+	return NewDebouncerWith(DebounceOptions{Delay: delay}, fn)
+}
+
+func NewDebouncerWith(opts DebounceOptions, fn func()) *Debouncer {
+	opts.Clock = clock.Or(opts.Clock)
 	return &Debouncer{
-		delay: delay,
-		fn:    fn,
+		opts: opts,
+		fn:   fn,
 	}
 }
 
-func (d *Debouncer) fire() {
+func (d *Debouncer) fire(gen int) {
+	d.mu.Lock()
+	if gen != d.gen || d.timer == nil {
+		d.mu.Unlock()
+		return
+	}
+	d.reset()
+	d.mu.Unlock()
+
+	d.run()
+}
+
+func (d *Debouncer) run() {
 	d.running.Lock()
 	defer d.running.Unlock()
 	d.fn()
 }
 
+// Trigger schedules the call for an edit.
 func (d *Debouncer) Trigger() {
+	d.TriggerChange(ChangeEdit)
+}
+
+// TriggerChange schedules the call for a change of the given kind. The
+// quiet period is the shortest any change in the burst asks for, so an
+// edit right after creating a file doesn't push its commit back.
+func (d *Debouncer) TriggerChange(c ChangeKind) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
+	now := d.opts.Clock.Now()
+	quiet := d.opts.Delay
+	if c == ChangeStructure && d.opts.StructureDelay > 0 {
+		quiet = min(quiet, d.opts.StructureDelay)
+	}
 	if d.timer != nil {
 		d.timer.Stop()
+		quiet = min(quiet, d.quiet)
+	} else {
+		d.first = now
 	}
-	d.timer = time.AfterFunc(d.delay, d.fire)
+	d.quiet = quiet
+	d.fireAt = now.Add(quiet)
+	if d.opts.MaxWait > 0 {
+		if limit := d.first.Add(d.opts.MaxWait); d.fireAt.After(limit) {
+			d.fireAt = limit
+		}
+	}
+
+	d.gen++
+	gen := d.gen
+	d.timer = d.opts.Clock.AfterFunc(d.fireAt.Sub(now), func() { d.fire(gen) })
+}
+
+// SetDelays changes the quiet periods and cap; opts.Clock is ignored. A
+// call already scheduled keeps its time; the next Trigger uses the new
+// values.
+func (d *Debouncer) SetDelays(opts DebounceOptions) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.opts.Delay, d.opts.StructureDelay, d.opts.MaxWait = opts.Delay, opts.StructureDelay, opts.MaxWait
 }
 
 func (d *Debouncer) Stop() {
@@ -44,7 +126,7 @@ func (d *Debouncer) Stop() {
 
 	if d.timer != nil {
 		d.timer.Stop()
-		d.timer = nil
+		d.reset()
 	}
 }
 
@@ -54,11 +136,36 @@ func (d *Debouncer) Flush() {
 	pending := d.timer != nil
 	if pending {
 		d.timer.Stop()
-		d.timer = nil
+		d.reset()
 	}
 	d.mu.Unlock()
 
 	if pending {
-		d.fire()
+		d.run()
 	}
 }
+
+// Pending reports whether a call is scheduled, and when.
+func (d *Debouncer) Pending() (bool, time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.timer != nil, d.fireAt
+}
+
+// TimeToFire is how long until the scheduled call, 0 if none is.
+func (d *Debouncer) TimeToFire() time.Duration {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.timer == nil {
+		return 0
+	}
+	return max(d.fireAt.Sub(d.opts.Clock.Now()), 0)
+}
+
+// reset forgets the scheduled call. d.mu must be held.
+func (d *Debouncer) reset() {
+	d.timer = nil
+	d.first = time.Time{}
+	d.quiet = 0
+	d.fireAt = time.Time{}
+}
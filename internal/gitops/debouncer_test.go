@@ -1,74 +1,173 @@
 package gitops_test
 
 import (
-	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/victorarias/blue-guy/internal/clock"
 	"github.com/victorarias/blue-guy/internal/gitops"
 )
 
+var epoch = time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+
+// newDebouncer returns a debouncer on a fake clock and a counter of calls.
+// The fake clock runs timers inside Advance, so no sleeping or atomics.
+func newDebouncer(opts gitops.DebounceOptions) (*gitops.Debouncer, *clock.Fake, *int) {
+	c := clock.NewFake(epoch)
+	opts.Clock = c
+	called := new(int)
+	return gitops.NewDebouncerWith(opts, func() { *called++ }), c, called
+}
+
 func TestDebouncer_FiresAfterQuietPeriod(t *testing.T) {
-	var called atomic.Int32
-	d := gitops.NewDebouncer(50*time.Millisecond, func() {
-		called.Add(1)
-	})
+	d, c, called := newDebouncer(gitops.DebounceOptions{Delay: 50 * time.Millisecond})
 
 	d.Trigger()
-	time.Sleep(100 * time.Millisecond)
-
-	if got := called.Load(); got != 1 {
-		t.Errorf("expected 1 call, got %d", got)
+	c.Advance(49 * time.Millisecond)
+	if *called != 0 {
+		t.Fatalf("fired before the delay, got %d calls", *called)
+	}
+	c.Advance(time.Millisecond)
+	if *called != 1 {
+		t.Errorf("expected 1 call, got %d", *called)
 	}
 }
 
 func TestDebouncer_ResetsOnRetrigger(t *testing.T) {
-	var called atomic.Int32
-	d := gitops.NewDebouncer(50*time.Millisecond, func() {
-		called.Add(1)
-	})
+	d, c, called := newDebouncer(gitops.DebounceOptions{Delay: 50 * time.Millisecond})
 
 	d.Trigger()
-	time.Sleep(30 * time.Millisecond)
+	c.Advance(30 * time.Millisecond)
 	d.Trigger() // reset the timer
-	time.Sleep(30 * time.Millisecond)
+	c.Advance(30 * time.Millisecond)
 
 	// Should not have fired yet (only 30ms since last trigger)
-	if got := called.Load(); got != 0 {
-		t.Errorf("expected 0 calls at this point, got %d", got)
+	if *called != 0 {
+		t.Errorf("expected 0 calls at this point, got %d", *called)
+	}
+
+	c.Advance(20 * time.Millisecond)
+	if *called != 1 {
+		t.Errorf("expected 1 call after full delay, got %d", *called)
+	}
+}
+
+func TestDebouncer_SetDelays(t *testing.T) {
+	d, c, called := newDebouncer(gitops.DebounceOptions{Delay: time.Second})
+
+	d.SetDelays(gitops.DebounceOptions{Delay: 100 * time.Millisecond})
+	d.Trigger()
+	c.Advance(100 * time.Millisecond)
+	if *called != 1 {
+		t.Errorf("expected the new delay to apply, got %d calls", *called)
 	}
+}
+
+func TestDebouncer_StructureDelay(t *testing.T) {
+	d, c, called := newDebouncer(gitops.DebounceOptions{Delay: 5 * time.Second, StructureDelay: time.Second})
 
-	time.Sleep(40 * time.Millisecond)
-	if got := called.Load(); got != 1 {
-		t.Errorf("expected 1 call after full delay, got %d", got)
+	// Edits alone wait the full delay
+	d.Trigger()
+	c.Advance(time.Second)
+	if *called != 0 {
+		t.Fatalf("expected an edit to wait, got %d calls", *called)
+	}
+	c.Advance(4 * time.Second)
+	if *called != 1 {
+		t.Fatalf("expected a call after the edit delay, got %d", *called)
+	}
+
+	// A new file shortens the burst, even when edits follow it
+	d.TriggerChange(gitops.ChangeStructure)
+	c.Advance(500 * time.Millisecond)
+	d.Trigger()
+	if ttf := d.TimeToFire(); ttf != time.Second {
+		t.Errorf("expected the structure delay to hold for the burst, got %s", ttf)
+	}
+	c.Advance(time.Second)
+	if *called != 2 {
+		t.Errorf("expected a call after the structure delay, got %d", *called)
 	}
 }
 
 func TestDebouncer_Flush(t *testing.T) {
-	var called atomic.Int32
-	d := gitops.NewDebouncer(1*time.Second, func() {
-		called.Add(1)
-	})
+	d, c, called := newDebouncer(gitops.DebounceOptions{Delay: time.Second})
 
 	d.Trigger()
 	d.Flush() // should fire immediately
+	if *called != 1 {
+		t.Errorf("expected 1 call after flush, got %d", *called)
+	}
 
-	if got := called.Load(); got != 1 {
-		t.Errorf("expected 1 call after flush, got %d", got)
+	// The flushed timer doesn't fire again
+	c.Advance(time.Second)
+	if *called != 1 {
+		t.Errorf("expected no second call, got %d", *called)
+	}
+	d.Flush()
+	if *called != 1 {
+		t.Errorf("expected flush without a trigger to do nothing, got %d", *called)
 	}
 }
 
 func TestDebouncer_StopPreventsExecution(t *testing.T) {
-	var called atomic.Int32
-	d := gitops.NewDebouncer(50*time.Millisecond, func() {
-		called.Add(1)
-	})
+	d, c, called := newDebouncer(gitops.DebounceOptions{Delay: 50 * time.Millisecond})
 
 	d.Trigger()
 	d.Stop()
-	time.Sleep(100 * time.Millisecond)
+	c.Advance(time.Second)
 
-	if got := called.Load(); got != 0 {
-		t.Errorf("expected 0 calls after stop, got %d", got)
+	if *called != 0 {
+		t.Errorf("expected 0 calls after stop, got %d", *called)
+	}
+	if c.Pending() != 0 {
+		t.Errorf("expected the timer cancelled, %d pending", c.Pending())
+	}
+}
+
+func TestDebouncer_MaxWaitBoundsContinuousActivity(t *testing.T) {
+	d, c, called := newDebouncer(gitops.DebounceOptions{Delay: 5 * time.Second, MaxWait: 12 * time.Second})
+
+	// A change every 2s would hold a plain debouncer back forever
+	for i := 0; i < 6; i++ {
+		d.Trigger()
+		c.Advance(2 * time.Second)
+	}
+	if *called != 1 {
+		t.Fatalf("expected a call at the 12s cap, got %d", *called)
+	}
+
+	// The next burst starts a new cap
+	d.Trigger()
+	if ttf := d.TimeToFire(); ttf != 5*time.Second {
+		t.Errorf("expected the full delay for a new burst, got %s", ttf)
+	}
+	c.Advance(5 * time.Second)
+	if *called != 2 {
+		t.Errorf("expected a second call after the quiet period, got %d", *called)
+	}
+}
+
+func TestDebouncer_ReportsPendingAndTimeToFire(t *testing.T) {
+	d, c, _ := newDebouncer(gitops.DebounceOptions{Delay: 5 * time.Second, MaxWait: 8 * time.Second})
+
+	if pending, _ := d.Pending(); pending || d.TimeToFire() != 0 {
+		t.Error("expected nothing pending before a trigger")
+	}
+
+	d.Trigger()
+	c.Advance(4 * time.Second)
+	d.Trigger()
+	pending, at := d.Pending()
+	if !pending || !at.Equal(epoch.Add(8*time.Second)) {
+		t.Errorf("expected a call capped at 8s, got %v at %s", pending, at.Sub(epoch))
+	}
+	if ttf := d.TimeToFire(); ttf != 4*time.Second {
+		t.Errorf("expected 4s to go, got %s", ttf)
+	}
+
+	d.Stop()
+	if pending, _ := d.Pending(); pending {
+		t.Error("expected nothing pending after stop")
 	}
 }
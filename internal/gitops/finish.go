@@ -0,0 +1,221 @@
+package gitops
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// Integrate says what to do with the squashed session commit.
+type Integrate string
+
+const (
+	IntegrateNone   Integrate = "none"   // leave it on the session branch
+	IntegrateMerge  Integrate = "merge"  // merge the session branch into the original branch
+	IntegrateRebase Integrate = "rebase" // rebase onto the original branch, then fast-forward it
+)
+
+// ParseIntegrate validates an integration mode name. Empty means none.
+func ParseIntegrate(s string) (Integrate, error) {
+	switch Integrate(s) {
+	case "", IntegrateNone:
+		return IntegrateNone, nil
+	case IntegrateMerge, IntegrateRebase:
+		return Integrate(s), nil
+	default:
+		return "", fmt.Errorf("unknown integrate mode %q (want none, merge or rebase)", s)
+	}
+}
+
+// FinishOptions controls how a session is wrapped up.
+type FinishOptions struct {
+	// Branch is the session branch to finish. Empty picks the most recently
+	// committed mob/session-* branch.
+	Branch string
+	// Message is the commit message for the squashed commit. Required.
+	Message string
+	// Onto overrides the original branch recorded when the session started.
+	Onto      string
+	Integrate Integrate
+}
+
+// FinishResult reports what Finish did.
+type FinishResult struct {
+	Branch   string // session branch, now holding a single commit
+	Onto     string // branch the session started from
+	Commit   string // squashed commit (after rebase, if any)
+	Backup   string // ref preserving the raw auto-save history
+	Squashed int    // number of commits folded together
+}
+
+// baseConfigKey is where Start records the branch a session was created from.
+func baseConfigKey(branch string) string {
+	return "branch." + branch + ".mobBase"
+}
+
+// Finish squashes all commits of a session branch into one commit with the
+// given message, keeps the original history under refs/mob/backup/, and
+// optionally merges or rebases the result onto the branch the session
+// started from. Co-authors credited in the squashed commits are carried
+// over. The session must not be running.
+func Finish(root string, opts FinishOptions, log zerolog.Logger) (*FinishResult, error) {
+	l := log.With().Str("component", "gitops").Logger()
+
+	if strings.TrimSpace(opts.Message) == "" {
+		return nil, fmt.Errorf("a commit message is required")
+	}
+
+	branch := opts.Branch
+	if branch == "" {
+		latest, err := latestSessionBranch(root)
+		if err != nil {
+			return nil, err
+		}
+		branch = latest
+	}
+
+	onto := opts.Onto
+	if onto == "" {
+		out, err := runGit(root, "config", "--get", baseConfigKey(branch))
+		if err != nil {
+			return nil, fmt.Errorf("no original branch recorded for %s; pass one explicitly", branch)
+		}
+		onto = strings.TrimSpace(out)
+	}
+
+	tip, err := revParse(root, "refs/heads/"+branch)
+	if err != nil {
+		return nil, fmt.Errorf("session branch %s: %w", branch, err)
+	}
+	base, err := runGit(root, "merge-base", onto, tip)
+	if err != nil {
+		return nil, fmt.Errorf("merge-base %s %s: %w", onto, branch, err)
+	}
+	base = strings.TrimSpace(base)
+
+	countOut, err := runGit(root, "rev-list", "--count", base+".."+tip)
+	if err != nil {
+		return nil, fmt.Errorf("count session commits: %w", err)
+	}
+	count, _ := strconv.Atoi(strings.TrimSpace(countOut))
+	if count == 0 {
+		return nil, fmt.Errorf("session branch %s has no commits to squash", branch)
+	}
+
+	// Keep the raw history reachable before rewriting anything. Only the
+	// first finish creates the backup: on a second one the tip is already
+	// squashed, and the raw history is what the backup is for.
+	backup := "refs/mob/backup/" + strings.TrimPrefix(branch, "mob/")
+	if _, err := runGit(root, "update-ref", backup, tip, ""); err != nil {
+		if _, verr := revParse(root, backup); verr != nil {
+			return nil, fmt.Errorf("create backup ref: %w", err)
+		}
+		l.Info().Str("backup", backup).Msg("Keeping the backup from an earlier finish")
+	}
+
+	authors, err := sessionCoAuthors(root, base, tip)
+	if err != nil {
+		return nil, err
+	}
+	msg := strings.TrimSpace(opts.Message)
+	if len(authors) > 0 {
+		msg += "\n\n" + strings.Join(authors, "\n")
+	}
+
+	// Build the squashed commit directly from the tip's tree: no checkout,
+	// so the working tree is untouched even if it's on the session branch.
+	squashed, err := runGit(root, "commit-tree", tip+"^{tree}", "-p", base, "-m", msg)
+	if err != nil {
+		return nil, fmt.Errorf("commit-tree: %w", err)
+	}
+	squashed = strings.TrimSpace(squashed)
+	if _, err := runGit(root, "update-ref", "refs/heads/"+branch, squashed, tip); err != nil {
+		return nil, fmt.Errorf("move %s to squashed commit: %w", branch, err)
+	}
+
+	l.Info().Str("branch", branch).Int("commits", count).Str("backup", backup).Msg("Squashed session")
+
+	res := &FinishResult{
+		Branch:   branch,
+		Onto:     onto,
+		Commit:   squashed,
+		Backup:   backup,
+		Squashed: count,
+	}
+
+	switch opts.Integrate {
+	case IntegrateMerge:
+		if _, err := runGit(root, "checkout", onto); err != nil {
+			return res, fmt.Errorf("checkout %s: %w", onto, err)
+		}
+		if out, err := runGit(root, "merge", "--no-edit", branch); err != nil {
+			runGit(root, "merge", "--abort")
+			return res, fmt.Errorf("merge %s into %s: %w: %s", branch, onto, err, strings.TrimSpace(out))
+		}
+		l.Info().Str("into", onto).Msg("Merged session")
+	case IntegrateRebase:
+		if out, err := runGit(root, "rebase", onto, branch); err != nil {
+			runGit(root, "rebase", "--abort")
+			return res, fmt.Errorf("rebase %s onto %s: %w: %s", branch, onto, err, strings.TrimSpace(out))
+		}
+		if _, err := runGit(root, "checkout", onto); err != nil {
+			return res, fmt.Errorf("checkout %s: %w", onto, err)
+		}
+		if _, err := runGit(root, "merge", "--ff-only", branch); err != nil {
+			return res, fmt.Errorf("fast-forward %s: %w", onto, err)
+		}
+		if res.Commit, err = revParse(root, "refs/heads/"+branch); err != nil {
+			return res, err
+		}
+		l.Info().Str("onto", onto).Msg("Rebased session")
+	}
+
+	return res, nil
+}
+
+// latestSessionBranch returns the mob/session-* branch with the newest commit.
+func latestSessionBranch(root string) (string, error) {
+	out, err := runGit(root, "for-each-ref", "--sort=-committerdate", "--count=1",
+		"--format=%(refname:short)", "refs/heads/"+sessionPrefix+"*")
+	if err != nil {
+		return "", fmt.Errorf("list session branches: %w", err)
+	}
+	branch := strings.TrimSpace(out)
+	if branch == "" {
+		return "", fmt.Errorf("no mob/session-* branches found")
+	}
+	return branch, nil
+}
+
+// sessionCoAuthors collects the unique Co-authored-by trailers of base..tip.
+func sessionCoAuthors(root, base, tip string) ([]string, error) {
+	out, err := runGit(root, "log", "--format=%(trailers:key=Co-authored-by)", base+".."+tip)
+	if err != nil {
+		return nil, fmt.Errorf("read co-authors: %w", err)
+	}
+	seen := make(map[string]struct{})
+	var authors []string
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if _, ok := seen[line]; !ok {
+			seen[line] = struct{}{}
+			authors = append(authors, line)
+		}
+	}
+	sort.Strings(authors)
+	return authors, nil
+}
+
+func revParse(root, rev string) (string, error) {
+	out, err := runGit(root, "rev-parse", "--verify", "--quiet", rev)
+	if err != nil {
+		return "", fmt.Errorf("resolve %s: %w", rev, err)
+	}
+	return strings.TrimSpace(out), nil
+}
@@ -0,0 +1,114 @@
+package gitops_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/victorarias/blue-guy/internal/gitops"
+	"github.com/victorarias/blue-guy/internal/identity"
+)
+
+// runSession makes a session with one auto-commit per edit, then stops it.
+func runSession(t *testing.T, dir, id string, edits ...string) {
+	t.Helper()
+	g := startSession(t, dir, id)
+	alice := identity.Identity{Name: "Alice", Email: "alice@example.com"}
+	for _, e := range edits {
+		os.WriteFile(filepath.Join(dir, e), []byte(e+"\n"), 0644)
+		g.RecordContribution(alice, e)
+		g.NotifyChange()
+		g.Flush()
+	}
+	g.Stop()
+}
+
+func TestFinish_SquashesAndKeepsBackup(t *testing.T) {
+	dir := initRepo(t)
+	runSession(t, dir, "abc", "a.txt", "b.txt", "c.txt")
+	rawTip := strings.TrimSpace(git(t, dir, "rev-parse", "mob/session-abc"))
+
+	res, err := gitops.Finish(dir, gitops.FinishOptions{Message: "Add letters"}, zerolog.Nop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Branch != "mob/session-abc" || res.Onto != "main" || res.Squashed != 3 {
+		t.Errorf("unexpected result %+v", res)
+	}
+	if got := strings.TrimSpace(git(t, dir, "rev-list", "--count", "main..mob/session-abc")); got != "1" {
+		t.Errorf("expected 1 commit on session branch, got %s", got)
+	}
+	if got := strings.TrimSpace(git(t, dir, "rev-parse", res.Backup)); got != rawTip {
+		t.Errorf("backup ref %s points at %s, want %s", res.Backup, got, rawTip)
+	}
+	msg := git(t, dir, "log", "-1", "--format=%B", "mob/session-abc")
+	if !strings.HasPrefix(msg, "Add letters\n\nCo-authored-by: Alice <alice@example.com>") {
+		t.Errorf("unexpected squashed message:\n%s", msg)
+	}
+	if got := strings.TrimSpace(git(t, dir, "rev-parse", "--abbrev-ref", "HEAD")); got != "main" {
+		t.Errorf("finish without integrate should not switch branches, on %s", got)
+	}
+
+	// Finishing again rewords the squash but keeps the raw history
+	if _, err := gitops.Finish(dir, gitops.FinishOptions{Message: "Add letters, again"}, zerolog.Nop()); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(git(t, dir, "rev-parse", res.Backup)); got != rawTip {
+		t.Errorf("second finish moved the backup to %s, want %s", got, rawTip)
+	}
+}
+
+func TestFinish_Merge(t *testing.T) {
+	dir := initRepo(t)
+	runSession(t, dir, "abc", "a.txt")
+
+	_, err := gitops.Finish(dir, gitops.FinishOptions{
+		Message:   "Add a",
+		Integrate: gitops.IntegrateMerge,
+	}, zerolog.Nop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if subject := strings.TrimSpace(git(t, dir, "log", "-1", "--format=%s", "main")); subject != "Add a" {
+		t.Errorf("expected main to fast-forward to the squashed commit, got %q", subject)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a.txt")); err != nil {
+		t.Error("a.txt should be in the working tree after merge")
+	}
+}
+
+func TestFinish_RebaseOntoMovedBranch(t *testing.T) {
+	dir := initRepo(t)
+	runSession(t, dir, "abc", "a.txt")
+
+	// Someone moved main on while the mob was working
+	os.WriteFile(filepath.Join(dir, "other.txt"), []byte("x\n"), 0644)
+	git(t, dir, "add", "-A")
+	git(t, dir, "commit", "-q", "-m", "other work")
+
+	_, err := gitops.Finish(dir, gitops.FinishOptions{
+		Message:   "Add a",
+		Integrate: gitops.IntegrateRebase,
+	}, zerolog.Nop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log := git(t, dir, "log", "--format=%s", "main")
+	if !strings.HasPrefix(log, "Add a\nother work\ninitial\n") {
+		t.Errorf("expected linear history on main, got:\n%s", log)
+	}
+}
+
+func TestFinish_RequiresMessage(t *testing.T) {
+	dir := initRepo(t)
+	runSession(t, dir, "abc", "a.txt")
+
+	if _, err := gitops.Finish(dir, gitops.FinishOptions{}, zerolog.Nop()); err == nil {
+		t.Error("expected error without a message")
+	}
+}
@@ -4,62 +4,186 @@ import (
 	"context"
 	"fmt"
 	"os/exec"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/rs/zerolog"
+	"github.com/victorarias/blue-guy/internal/clock"
+	"github.com/victorarias/blue-guy/internal/identity"
 )
 
-const commitDelay = 5 * time.Second
+const (
+	defaultCommitDelay    = 5 * time.Second
+	defaultMaxCommitDelay = time.Minute
+)
+
+// Options configures optional GitOps behaviour. The zero value is usable.
+type Options struct {
+	// MessageHook is a shell command that writes the commit message to
+	// stdout. It receives the staged diff on stdin. Empty means the built-in
+	// summary is used.
+	MessageHook string
+	// Resume continues an existing session branch instead of creating one.
+	Resume bool
+	// Backend selects how commits are made: BackendExec (default) or
+	// BackendGoGit.
+	Backend string
+	// Push controls when and where auto-commits are pushed.
+	Push PushOptions
+	// Dirty says what to do with uncommitted changes found at Start. The
+	// zero value refuses to start.
+	Dirty DirtyMode
+	// Secrets controls scanning staged changes for credentials.
+	Secrets SecretOptions
+	// Files keeps large files and binaries out of commits.
+	Files FileGuardOptions
+	// CommitDelay is the quiet period before an auto-commit. 0 means 5s.
+	CommitDelay time.Duration
+	// StructureCommitDelay is the quiet period once files have been
+	// created, deleted or renamed, if shorter. 0 means CommitDelay.
+	StructureCommitDelay time.Duration
+	// MaxCommitDelay bounds how long continuous changes can hold an
+	// auto-commit back. 0 means a minute; negative means no bound.
+	MaxCommitDelay time.Duration
+	// Clock schedules auto-commits and push retries. nil means the system
+	// clock.
+	Clock clock.Clock
+}
 
 type GitOps struct {
-	root       string
-	sessionID  string
-	branch     string
-	origBranch string
-	debouncer  *Debouncer
-	commitMu   sync.Mutex // serializes commitAndPush calls
-	log        zerolog.Logger
+	root         string
+	sessionID    string
+	opts         Options
+	repo         repo
+	branch       string
+	stash        string // stash commit holding pre-session changes, if any
+	origBranch   string // branch, or commit when origDetached, to go back to
+	origDetached bool
+	debouncer    *Debouncer
+	pusher       *pusher
+	secrets      *secretScanner // nil when scanning is off
+	guard        *fileGuard
+	clock        clock.Clock
+	commitMu     sync.Mutex // serializes commitAndPush calls
+	log          zerolog.Logger
+
+	// contributions tracks which clients touched which paths since the last
+	// commit, keyed by repo-relative path (files or directories).
+	contribMu     sync.Mutex
+	contributions map[string]map[identity.Identity]struct{}
+
+	pushMu     sync.Mutex
+	pushListen func(PushState)
+
+	blobs     blobCache // last blob read through a snapshot
+	snapshots snapshotCache
+
+	secretsMu     sync.Mutex
+	secretsListen func(SecretReport)
+	lastSecrets   string // findings last reported, so retries don't repeat them
 }
 
-func New(root string, sessionID string, log zerolog.Logger) (*GitOps, error) {
+func New(root string, sessionID string, opts Options, log zerolog.Logger) (*GitOps, error) {
 	l := log.With().Str("component", "gitops").Logger()
 
 	// Check if git is available and this is a repo
 	if _, err := runGit(root, "rev-parse", "--git-dir"); err != nil {
 		return nil, fmt.Errorf("not a git repository: %w", err)
 	}
+	r, err := newRepo(root, opts.Backend)
+	if err != nil {
+		return nil, err
+	}
+	guard, err := newFileGuard(root, opts.Files, opts.Backend)
+	if err != nil {
+		return nil, err
+	}
+	var secrets *secretScanner
+	if opts.Secrets.Mode != SecretsOff {
+		if secrets, err = newSecretScanner(opts.Secrets); err != nil {
+			return nil, err
+		}
+	}
 
 	return &GitOps{
 		root:      root,
 		sessionID: sessionID,
-		branch:    "mob/session-" + sessionID,
+		opts:      opts,
+		repo:      r,
+		secrets:   secrets,
+		guard:     guard,
+		clock:     clock.Or(opts.Clock),
+		branch:    sessionPrefix + sessionID,
 		log:       l,
+
+		contributions: make(map[string]map[identity.Identity]struct{}),
 	}, nil
 }
 
-// Start creates the mob branch and begins auto-commit lifecycle.
-func (g *GitOps) Start(ctx context.Context) error {
-	// Record current branch to restore later
-	out, err := runGit(g.root, "rev-parse", "--abbrev-ref", "HEAD")
+// Start creates (or, with Resume, checks out) the mob branch and begins
+// auto-commit lifecycle. It refuses to start with a *StateError when the
+// repository is mid-merge/rebase, locked, or dirty under DirtyRefuse.
+func (g *GitOps) Start(ctx context.Context) (err error) {
+	// Record where we are (and set local changes aside) to restore later
+	stashed, err := g.preflight()
+	if stashed {
+		// Failing from here on mustn't leave the user's changes stashed
+		// away with nothing saying so
+		included := err == nil && g.opts.Dirty == DirtyInclude
+		defer func() {
+			if err != nil {
+				g.abandonStart(included)
+			}
+		}()
+	}
 	if err != nil {
-		return fmt.Errorf("get current branch: %w", err)
+		return err
 	}
-	g.origBranch = strings.TrimSpace(out)
 
-	// Create and switch to mob branch
-	if _, err := runGit(g.root, "checkout", "-b", g.branch); err != nil {
+	if g.opts.Resume {
+		// If we crashed on the session branch, go back to where the session
+		// originally started rather than staying on it
+		if base, err := runGit(g.root, "config", "--get", baseConfigKey(g.branch)); err == nil {
+			g.origBranch = strings.TrimSpace(base)
+			_, err := runGit(g.root, "show-ref", "--verify", "--quiet", "refs/heads/"+g.origBranch)
+			g.origDetached = err != nil
+		}
+		if err := g.resumeBranch(); err != nil {
+			return err
+		}
+	} else if _, err := runGit(g.root, "checkout", "-b", g.branch); err != nil {
+		// Create and switch to mob branch
 		return fmt.Errorf("create mob branch %s: %w", g.branch, err)
 	}
 
+	// Remember where the session came from so Finish can squash onto it later
+	if _, err := runGit(g.root, "config", baseConfigKey(g.branch), g.origBranch); err != nil {
+		g.log.Warn().Err(err).Msg("Failed to record original branch")
+	}
+
+	action := "Created mob branch"
+	if g.opts.Resume {
+		action = "Resumed mob branch"
+	}
 	g.log.Info().
 		Str("branch", g.branch).
 		Str("from", g.origBranch).
-		Msg("Created mob branch")
+		Msg(action)
+
+	// Pushes get their own repo handle so they can run alongside commits
+	pushRepo, err := newRepo(g.root, g.opts.Backend)
+	if err != nil {
+		return err
+	}
+	g.pusher = newPusher(pushRepo, g.branch, g.opts.Push, g.clock, g.pushChanged, g.log)
+	g.pusher.Start()
 
 	// Set up debounced auto-commit
-	g.debouncer = NewDebouncer(commitDelay, func() {
+	debounce := commitDelays(g.opts)
+	debounce.Clock = g.clock
+	g.debouncer = NewDebouncerWith(debounce, func() {
 		if err := g.commitAndPush(); err != nil {
 			g.log.Warn().Err(err).Msg("Auto-commit failed")
 		}
@@ -68,10 +192,64 @@ func (g *GitOps) Start(ctx context.Context) error {
 	return nil
 }
 
+// commitDelays is the debounce delays and cap for opts, defaults filled in.
+func commitDelays(opts Options) DebounceOptions {
+	d := DebounceOptions{
+		Delay:          opts.CommitDelay,
+		StructureDelay: max(opts.StructureCommitDelay, 0),
+		MaxWait:        opts.MaxCommitDelay,
+	}
+	if d.Delay <= 0 {
+		d.Delay = defaultCommitDelay
+	}
+	if d.MaxWait == 0 {
+		d.MaxWait = defaultMaxCommitDelay
+	}
+	d.MaxWait = max(d.MaxWait, 0)
+	return d
+}
+
+// Reconfigure applies the settings that can change mid-session: the commit
+// delays and the file size limits. The rest of opts, the message hook
+// included, is ignored; changing it takes a restart.
+func (g *GitOps) Reconfigure(opts Options) {
+	g.commitMu.Lock()
+	defer g.commitMu.Unlock()
+
+	g.opts.CommitDelay, g.opts.MaxCommitDelay = opts.CommitDelay, opts.MaxCommitDelay
+	g.opts.StructureCommitDelay = opts.StructureCommitDelay
+	g.opts.Files.MaxFileSize, g.opts.Files.MaxBinarySize = opts.Files.MaxFileSize, opts.Files.MaxBinarySize
+	g.guard.setLimits(g.opts.Files)
+	if g.debouncer != nil {
+		g.debouncer.SetDelays(commitDelays(g.opts))
+	}
+}
+
 // NotifyChange should be called when files change. It triggers a debounced commit.
 func (g *GitOps) NotifyChange() {
+	g.NotifyChangeOf(ChangeEdit)
+}
+
+// NotifyChangeOf is NotifyChange for a known kind of change, which picks
+// the commit delay.
+func (g *GitOps) NotifyChangeOf(c ChangeKind) {
 	if g.debouncer != nil {
-		g.debouncer.Trigger()
+		g.debouncer.TriggerChange(c)
+	}
+}
+
+// PendingCommit reports whether an auto-commit is scheduled, and when.
+func (g *GitOps) PendingCommit() (bool, time.Time) {
+	if g.debouncer == nil {
+		return false, time.Time{}
+	}
+	return g.debouncer.Pending()
+}
+
+// Flush commits pending changes now instead of waiting for the quiet period.
+func (g *GitOps) Flush() {
+	if g.debouncer != nil {
+		g.debouncer.Flush()
 	}
 }
 
@@ -85,52 +263,253 @@ func (g *GitOps) Stop() {
 	if err := g.commitAndPush(); err != nil {
 		g.log.Warn().Err(err).Msg("Final commit failed")
 	}
+	if g.pusher != nil {
+		g.pusher.Stop()
+	}
+
+	// Restore original branch (or detached commit) and pre-session changes
+	g.restorePrior()
+}
+
+// RecordContribution notes that a client changed path, relative to the
+// root (the exported subdirectory, not the top of the repository).
+// Contributors are credited with Co-authored-by trailers on the next commit
+// that includes the path.
+func (g *GitOps) RecordContribution(id identity.Identity, path string) {
+	if id.Email == "" {
+		// A trailer without an email isn't attributable to anyone
+		return
+	}
+	path = strings.Trim(path, "/")
 
-	// Restore original branch
-	if g.origBranch != "" {
-		if _, err := runGit(g.root, "checkout", g.origBranch); err != nil {
-			g.log.Error().Err(err).Str("branch", g.origBranch).Msg("Failed to restore original branch")
-		} else {
-			g.log.Info().Str("branch", g.origBranch).Msg("Restored original branch")
+	g.contribMu.Lock()
+	defer g.contribMu.Unlock()
+
+	ids, ok := g.contributions[path]
+	if !ok {
+		ids = make(map[identity.Identity]struct{})
+		g.contributions[path] = ids
+	}
+	ids[id] = struct{}{}
+}
+
+// takeContributions hands back everything recorded so far and starts a fresh
+// record, so contributions made while a commit is in progress go to the next one.
+func (g *GitOps) takeContributions() map[string]map[identity.Identity]struct{} {
+	g.contribMu.Lock()
+	defer g.contribMu.Unlock()
+
+	taken := g.contributions
+	g.contributions = make(map[string]map[identity.Identity]struct{})
+	return taken
+}
+
+// restoreContributions merges contributions back after a failed commit.
+func (g *GitOps) restoreContributions(taken map[string]map[identity.Identity]struct{}) {
+	g.contribMu.Lock()
+	defer g.contribMu.Unlock()
+
+	for path, ids := range taken {
+		cur, ok := g.contributions[path]
+		if !ok {
+			g.contributions[path] = ids
+			continue
+		}
+		for id := range ids {
+			cur[id] = struct{}{}
+		}
+	}
+}
+
+// coAuthors returns the contributors whose recorded paths cover at least one
+// staged file, sorted for stable commit messages. A recorded directory covers
+// every file beneath it.
+func coAuthors(contributions map[string]map[identity.Identity]struct{}, staged []string) []identity.Identity {
+	seen := make(map[identity.Identity]struct{})
+	for path, ids := range contributions {
+		for _, f := range staged {
+			if f == path || path == "" || strings.HasPrefix(f, path+"/") {
+				for id := range ids {
+					seen[id] = struct{}{}
+				}
+				break
+			}
 		}
 	}
+
+	authors := make([]identity.Identity, 0, len(seen))
+	for id := range seen {
+		authors = append(authors, id)
+	}
+	sort.Slice(authors, func(i, j int) bool {
+		return authors[i].String() < authors[j].String()
+	})
+	return authors
+}
+
+// withTrailers appends trailers and Co-authored-by lines as the final
+// paragraph of msg, where git expects them.
+func withTrailers(msg string, trailers []string, authors []identity.Identity) string {
+	if len(trailers) == 0 && len(authors) == 0 {
+		return msg
+	}
+	var b strings.Builder
+	b.WriteString(msg)
+	b.WriteString("\n\n")
+	for _, t := range trailers {
+		fmt.Fprintf(&b, "%s\n", t)
+	}
+	for _, a := range authors {
+		fmt.Fprintf(&b, "Co-authored-by: %s\n", a)
+	}
+	return b.String()
+}
+
+// Root returns the repository directory.
+func (g *GitOps) Root() string { return g.root }
+
+// Branch returns the session branch name.
+func (g *GitOps) Branch() string { return g.branch }
+
+// PushState reports how pushing to the remote is going.
+func (g *GitOps) PushState() PushState {
+	if g.pusher == nil {
+		return PushState{}
+	}
+	return g.pusher.State()
+}
+
+// OnPushState registers fn to be called whenever the push state changes.
+func (g *GitOps) OnPushState(fn func(PushState)) {
+	g.pushMu.Lock()
+	defer g.pushMu.Unlock()
+	g.pushListen = fn
+}
+
+func (g *GitOps) pushChanged(st PushState) {
+	g.pushMu.Lock()
+	fn := g.pushListen
+	g.pushMu.Unlock()
+	if fn != nil {
+		fn(st)
+	}
+}
+
+// Checkpoint commits everything now, cancelling any pending debounced
+// commit. An empty message is generated from the changes as usual; trailers
+// (e.g. "Mob-Driver: alice") are added next to the Co-authored-by lines.
+// Nothing is committed if there are no changes.
+func (g *GitOps) Checkpoint(message string, trailers ...string) error {
+	if g.debouncer != nil {
+		g.debouncer.Stop()
+	}
+	return g.commit(message, trailers)
 }
 
 func (g *GitOps) commitAndPush() error {
+	return g.commit("", nil)
+}
+
+// commit stages and commits all changes, then pushes. An empty message means
+// one is generated from the staged changes.
+func (g *GitOps) commit(message string, trailers []string) (err error) {
 	g.commitMu.Lock()
 	defer g.commitMu.Unlock()
 
+	contributions := g.takeContributions()
+	defer func() {
+		if err != nil {
+			g.restoreContributions(contributions)
+		}
+	}()
+
 	// Stage all changes
-	if _, err := runGit(g.root, "add", "-A"); err != nil {
+	if err := g.repo.AddAll(); err != nil {
 		return fmt.Errorf("git add: %w", err)
 	}
 
 	// Check if there are staged changes
-	if _, err := runGit(g.root, "diff", "--cached", "--quiet"); err == nil {
+	if staged, err := g.repo.HasStaged(); err != nil {
+		return fmt.Errorf("git diff: %w", err)
+	} else if !staged {
 		// No changes to commit
 		return nil
 	}
 
+	changes, err := g.repo.Staged()
+	if err != nil {
+		return fmt.Errorf("git diff: %w", err)
+	}
+	if changes, err = g.guardLargeFiles(changes); err != nil {
+		return err
+	} else if len(changes) == 0 {
+		return nil
+	}
+	staged := make([]string, len(changes))
+	for i, c := range changes {
+		staged[i] = c.path
+	}
+
+	findings, err := g.scanSecrets()
+	if err != nil {
+		return err
+	}
+	holdPush := len(findings) > 0 && g.opts.Secrets.Mode == SecretsHoldPush
+	if len(findings) > 0 && !holdPush {
+		// Leave the index as it was so nothing else picks the secret up
+		if err := g.repo.Unstage(); err != nil {
+			g.log.Warn().Err(err).Msg("Failed to unstage blocked changes")
+		}
+		g.reportSecrets(SecretReport{Mode: SecretsBlock, Findings: findings})
+		return &SecretsError{Findings: findings}
+	}
+
+	// Hold pushing before the commit exists, so no tick or retry can send it
+	if holdPush && g.pusher != nil {
+		g.pusher.Hold("possible secrets committed in " + findingPaths(findings))
+	}
+
 	// Commit
-	ts := time.Now().Format("15:04:05")
-	msg := fmt.Sprintf("mob: auto-save at %s", ts)
-	if _, err := runGit(g.root, "commit", "-m", msg); err != nil {
+	msg := message
+	if msg == "" {
+		msg = g.commitMessage(changes)
+	}
+	authors := coAuthors(contributions, staged)
+	if err := g.repo.Commit(withTrailers(msg, trailers, authors)); err != nil {
 		return fmt.Errorf("git commit: %w", err)
 	}
 
-	g.log.Info().Str("msg", msg).Msg("Auto-committed")
+	g.log.Info().Str("msg", firstLine(msg)).Int("coauthors", len(authors)).Msg("Auto-committed")
 
-	// Push (best effort — don't fail if remote is unavailable)
-	if _, err := runGit(g.root, "push", "-u", "origin", g.branch); err != nil {
-		g.log.Warn().Err(err).Msg("Push failed (no remote?)")
+	if holdPush && g.pusher != nil {
+		head, _ := g.Head()
+		g.reportSecrets(SecretReport{Mode: SecretsHoldPush, Findings: findings, Commit: head})
+	} else if len(findings) == 0 {
+		g.reportSecrets(SecretReport{})
+	}
+
+	// Push in the background, per the push policy
+	if g.pusher != nil {
+		g.pusher.Committed()
 	}
 
 	return nil
 }
 
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
 func runGit(dir string, args ...string) (string, error) {
+	return runGitEnv(dir, nil, args...)
+}
+
+// runGitEnv is runGit with env as the environment; nil means this process's.
+func runGitEnv(dir string, env []string, args ...string) (string, error) {
 	cmd := exec.Command("git", args...)
 	cmd.Dir = dir
+	cmd.Env = env
 	out, err := cmd.CombinedOutput()
 	return string(out), err
 }
@@ -0,0 +1,331 @@
+package gitops_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog"
+	"github.com/victorarias/blue-guy/internal/gitops"
+	"github.com/victorarias/blue-guy/internal/identity"
+)
+
+// initRepo creates a repository with one commit on main.
+func initRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	git(t, dir, "init", "-q", "-b", "main")
+	git(t, dir, "config", "user.name", "Host")
+	git(t, dir, "config", "user.email", "host@example.com")
+	git(t, dir, "config", "commit.gpgsign", "false")
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("hello\n"), 0644)
+	git(t, dir, "add", "-A")
+	git(t, dir, "commit", "-q", "-m", "initial")
+	return dir
+}
+
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return string(out)
+}
+
+func startSession(t *testing.T, dir, id string) *gitops.GitOps {
+	t.Helper()
+	return startSessionWith(t, dir, id, gitops.Options{})
+}
+
+func startSessionWith(t *testing.T, dir, id string, opts gitops.Options) *gitops.GitOps {
+	t.Helper()
+	g, err := gitops.New(dir, id, opts, zerolog.Nop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+// forEachBackend runs a test once per git backend, so both implementations
+// are held to the same behaviour.
+func forEachBackend(t *testing.T, test func(t *testing.T, opts gitops.Options)) {
+	for _, backend := range []string{gitops.BackendExec, gitops.BackendGoGit} {
+		t.Run(backend, func(t *testing.T) {
+			test(t, gitops.Options{Backend: backend})
+		})
+	}
+}
+
+func TestStop_CommitsAndRestoresBranch(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, opts gitops.Options) {
+		dir := initRepo(t)
+		g := startSessionWith(t, dir, "abc", opts)
+
+		os.WriteFile(filepath.Join(dir, "new.txt"), []byte("x\n"), 0644)
+		g.Stop()
+
+		if got := strings.TrimSpace(git(t, dir, "rev-parse", "--abbrev-ref", "HEAD")); got != "main" {
+			t.Errorf("expected to be back on main, got %s", got)
+		}
+		files := git(t, dir, "show", "--name-only", "--format=", "mob/session-abc")
+		if !strings.Contains(files, "new.txt") {
+			t.Errorf("expected new.txt in session commit, got %q", files)
+		}
+	})
+}
+
+func TestCommit_CreditsContributorsOfStagedFiles(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, opts gitops.Options) {
+		dir := initRepo(t)
+		g := startSessionWith(t, dir, "abc", opts)
+
+		alice := identity.Identity{Name: "Alice", Email: "alice@example.com"}
+		bob := identity.Identity{Name: "Bob", Email: "bob@example.com"}
+		carol := identity.Identity{Name: "Carol", Email: "carol@example.com"}
+
+		os.MkdirAll(filepath.Join(dir, "pkg"), 0755)
+		os.WriteFile(filepath.Join(dir, "pkg", "a.go"), []byte("package pkg\n"), 0644)
+		os.WriteFile(filepath.Join(dir, "b.txt"), []byte("b\n"), 0644)
+		g.RecordContribution(alice, "/pkg")    // directory covers pkg/a.go
+		g.RecordContribution(bob, "b.txt")     // file
+		g.RecordContribution(bob, "/b.txt")    // duplicates collapse
+		g.RecordContribution(carol, "nope.md") // touched but nothing staged
+		g.RecordContribution(identity.Identity{Name: "NoEmail"}, "b.txt")
+		g.Stop()
+
+		msg := git(t, dir, "log", "-1", "--format=%B", "mob/session-abc")
+		want := "Co-authored-by: Alice <alice@example.com>\nCo-authored-by: Bob <bob@example.com>\n"
+		if !strings.Contains(msg, want) {
+			t.Errorf("expected trailers %q in message:\n%s", want, msg)
+		}
+		if strings.Contains(msg, "Carol") || strings.Contains(msg, "NoEmail") {
+			t.Errorf("unexpected co-author in message:\n%s", msg)
+		}
+	})
+}
+
+func TestCommit_SummarizesChanges(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, opts gitops.Options) {
+		dir := initRepo(t)
+		os.WriteFile(filepath.Join(dir, "old.txt"), []byte("a\nb\n"), 0644)
+		git(t, dir, "add", "-A")
+		git(t, dir, "commit", "-q", "-m", "add old")
+		g := startSessionWith(t, dir, "abc", opts)
+
+		os.MkdirAll(filepath.Join(dir, "pkg"), 0755)
+		os.WriteFile(filepath.Join(dir, "pkg", "new.go"), []byte("package pkg\n\nfunc F() {}\n"), 0644)
+		os.WriteFile(filepath.Join(dir, "README.md"), []byte("hello\nworld\n"), 0644)
+		os.Remove(filepath.Join(dir, "old.txt"))
+		g.Stop()
+
+		msg := git(t, dir, "log", "-1", "--format=%B", "mob/session-abc")
+		wantSubject := "mob: add 1, update 1 and delete 1 files in /, pkg (+4 -2)"
+		if subject, _, _ := strings.Cut(msg, "\n"); subject != wantSubject {
+			t.Errorf("got subject %q, want %q", subject, wantSubject)
+		}
+		for _, line := range []string{"A pkg/new.go (+3 -0)", "M README.md (+1 -0)", "D old.txt (+0 -2)"} {
+			if !strings.Contains(msg, line) {
+				t.Errorf("expected %q in body:\n%s", line, msg)
+			}
+		}
+	})
+}
+
+func TestCommit_SingleFileSubject(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, opts gitops.Options) {
+		dir := initRepo(t)
+		g := startSessionWith(t, dir, "abc", opts)
+
+		os.WriteFile(filepath.Join(dir, "README.md"), []byte("hello\nthere\n"), 0644)
+		g.Stop()
+
+		msg := strings.TrimSpace(git(t, dir, "log", "-1", "--format=%B", "mob/session-abc"))
+		if msg != "mob: update README.md (+1 -0)" {
+			t.Errorf("unexpected message %q", msg)
+		}
+	})
+}
+
+func TestCommit_MessageHook(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, opts gitops.Options) {
+		dir := initRepo(t)
+		opts.MessageHook = `printf 'hooked: %s lines\n' "$(grep -c '^+[^+]')"`
+		g := startSessionWith(t, dir, "abc", opts)
+
+		os.WriteFile(filepath.Join(dir, "README.md"), []byte("hello\nthere\nfriend\n"), 0644)
+		g.Stop()
+
+		msg := strings.TrimSpace(git(t, dir, "log", "-1", "--format=%B", "mob/session-abc"))
+		if msg != "hooked: 2 lines" {
+			t.Errorf("expected hook output as message, got %q", msg)
+		}
+	})
+}
+
+func TestCommit_HookGetsWholeLinesOfALongDiff(t *testing.T) {
+	dir := initRepo(t)
+	input := filepath.Join(t.TempDir(), "diff")
+	g := startSessionWith(t, dir, "abc", gitops.Options{MessageHook: "cat > '" + input + "'; echo hooked"})
+
+	// Three-byte characters, so a cut by size alone lands mid-character
+	line := strings.Repeat("€", 1000) + "\n"
+	os.WriteFile(filepath.Join(dir, "big.txt"), []byte(strings.Repeat(line, 100)), 0644)
+	g.Stop()
+
+	diff, err := os.ReadFile(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !utf8.Valid(diff) || len(diff) > 256<<10 {
+		t.Errorf("expected at most 256KB of valid UTF-8, got %d bytes", len(diff))
+	}
+	lines := strings.Split(strings.TrimSuffix(string(diff), "\n"), "\n")
+	if last := lines[len(lines)-1]; !strings.HasPrefix(last, "[diff truncated: first ") {
+		t.Errorf("expected a truncation note, got %q", last)
+	}
+	if cut := lines[len(lines)-2]; cut != "+"+strings.TrimSuffix(line, "\n") {
+		t.Errorf("expected the diff cut at a line break, got a line of %d bytes", len(cut))
+	}
+}
+
+func TestCommit_FailingHookFallsBackToAutoSave(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, opts gitops.Options) {
+		dir := initRepo(t)
+		opts.MessageHook = "exit 3"
+		g := startSessionWith(t, dir, "abc", opts)
+
+		os.WriteFile(filepath.Join(dir, "README.md"), []byte("changed\n"), 0644)
+		g.Stop()
+
+		msg := git(t, dir, "log", "-1", "--format=%B", "mob/session-abc")
+		if !strings.HasPrefix(msg, "mob: auto-save at ") {
+			t.Errorf("expected auto-save fallback, got %q", msg)
+		}
+	})
+}
+
+func TestReconfigure_KeepsTheMessageHook(t *testing.T) {
+	dir := initRepo(t)
+	g := startSession(t, dir, "abc")
+	defer g.Stop()
+
+	g.Reconfigure(gitops.Options{MessageHook: "echo hooked"})
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("changed\n"), 0644)
+	if err := g.Checkpoint(""); err != nil {
+		t.Fatal(err)
+	}
+	if msg := git(t, dir, "log", "-1", "--format=%B", "HEAD"); strings.HasPrefix(msg, "hooked") {
+		t.Errorf("expected a hook to take a restart, got %q", msg)
+	}
+}
+
+func TestCheckpoint_UsesMessageAndTrailers(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, opts gitops.Options) {
+		dir := initRepo(t)
+		g := startSessionWith(t, dir, "abc", opts)
+		defer g.Stop()
+
+		os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a\n"), 0644)
+		g.RecordContribution(identity.Identity{Name: "Alice", Email: "alice@example.com"}, "a.txt")
+		if err := g.Checkpoint("mob: handoff from alice to bob", "Mob-Driver: alice"); err != nil {
+			t.Fatal(err)
+		}
+
+		msg := git(t, dir, "log", "-1", "--format=%B", "mob/session-abc")
+		want := "mob: handoff from alice to bob\n\nMob-Driver: alice\nCo-authored-by: Alice <alice@example.com>\n"
+		if !strings.HasPrefix(msg, want) {
+			t.Errorf("got message %q, want %q", msg, want)
+		}
+		trailers := git(t, dir, "log", "-1", "--format=%(trailers:key=Mob-Driver,valueonly)", "mob/session-abc")
+		if strings.TrimSpace(trailers) != "alice" {
+			t.Errorf("Mob-Driver should parse as a git trailer, got %q", trailers)
+		}
+	})
+}
+
+func TestCommit_PushesToOrigin(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, opts gitops.Options) {
+		remote := t.TempDir()
+		git(t, remote, "init", "-q", "--bare")
+		dir := initRepo(t)
+		git(t, dir, "remote", "add", "origin", remote)
+		g := startSessionWith(t, dir, "abc", opts)
+
+		os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a\n"), 0644)
+		g.Stop()
+
+		local := git(t, dir, "rev-parse", "mob/session-abc")
+		if got := git(t, remote, "rev-parse", "mob/session-abc"); got != local {
+			t.Errorf("remote has %s, want %s", got, local)
+		}
+		if got := strings.TrimSpace(git(t, dir, "config", "branch.mob/session-abc.remote")); got != "origin" {
+			t.Errorf("expected upstream to be set, got %q", got)
+		}
+		if got := strings.TrimSpace(git(t, dir, "config", "branch.mob/session-abc.mobBase")); got != "main" {
+			t.Errorf("setting the upstream lost the recorded base branch, got %q", got)
+		}
+	})
+}
+
+func TestCommit_FromSubdirectory(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, opts gitops.Options) {
+		top := initRepo(t)
+		dir := filepath.Join(top, "app")
+		os.MkdirAll(dir, 0755)
+		g := startSessionWith(t, dir, "abc", opts)
+
+		os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0644)
+		g.Stop()
+
+		msg := strings.TrimSpace(git(t, top, "log", "-1", "--format=%B", "mob/session-abc"))
+		if msg != "mob: add main.go (+1 -0)" {
+			t.Errorf("expected paths relative to the root, got %q", msg)
+		}
+	})
+}
+
+func TestCommit_ExportedSubtreeOnly(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, opts gitops.Options) {
+		top := initRepo(t)
+		dir := filepath.Join(top, "app")
+		os.MkdirAll(dir, 0755)
+		os.WriteFile(filepath.Join(dir, "old.go"), []byte("package old\n"), 0644)
+		git(t, top, "add", "-A")
+		git(t, top, "commit", "-q", "-m", "app")
+
+		// Host-side edits elsewhere neither block the start nor get committed
+		os.WriteFile(filepath.Join(top, "README.md"), []byte("edited\n"), 0644)
+		g := startSessionWith(t, dir, "abc", opts)
+		os.WriteFile(filepath.Join(top, "other.txt"), []byte("mine\n"), 0644)
+		git(t, top, "add", "other.txt")
+		// Staged on purpose, with more unstaged on top, like add -p leaves it
+		git(t, top, "add", "README.md")
+		os.WriteFile(filepath.Join(top, "README.md"), []byte("edited\nagain\n"), 0644)
+
+		os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0644)
+		os.Remove(filepath.Join(dir, "old.go"))
+		g.Stop()
+
+		files := strings.Fields(git(t, top, "show", "--name-only", "--format=", "mob/session-abc"))
+		if len(files) != 2 || files[0] != "app/main.go" || files[1] != "app/old.go" {
+			t.Errorf("expected only the changes under app/ committed, got %v", files)
+		}
+		status := git(t, top, "status", "--porcelain")
+		if !strings.Contains(status, "MM README.md") || !strings.Contains(status, "A  other.txt") {
+			t.Errorf("expected the host's edits and staging left alone, got:\n%s", status)
+		}
+		if staged := git(t, top, "show", ":README.md"); staged != "edited\n" {
+			t.Errorf("expected the host's staged README.md kept, got %q", staged)
+		}
+	})
+}
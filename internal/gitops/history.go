@@ -0,0 +1,325 @@
+package gitops
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxDiffSize     = 1 << 20 // patch bytes returned by Diff
+	defaultLogLimit = 50
+)
+
+// ErrInvalidArgument marks a revision or path a client shouldn't pass to git.
+var ErrInvalidArgument = errors.New("invalid argument")
+
+// FileStatus is one entry of `git status`, with paths relative to the root.
+type FileStatus struct {
+	Path     string
+	OrigPath string // source of a rename or copy
+	Staging  byte   // porcelain X: ' ', 'M', 'A', 'D', 'R', '?', ...
+	Worktree byte   // porcelain Y
+}
+
+// Commit is one entry of the session log.
+type Commit struct {
+	Hash        string
+	AuthorName  string
+	AuthorEmail string
+	Time        time.Time
+	Subject     string
+	Body        string
+	CoAuthors   []string // "Name <email>" from Co-authored-by trailers
+}
+
+// BlameLine attributes one line of a file.
+type BlameLine struct {
+	Line        int // 1-based
+	Hash        string
+	AuthorName  string
+	AuthorEmail string
+	Time        time.Time
+	Summary     string
+	Text        string
+}
+
+// Status lists uncommitted changes under the root, including untracked files.
+func (g *GitOps) Status() ([]FileStatus, error) {
+	prefix, err := runGit(g.root, "rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, fmt.Errorf("rev-parse: %w", err)
+	}
+	prefix = strings.TrimSpace(prefix)
+
+	out, err := runGit(g.root, "status", "--porcelain", "-z", "--untracked-files=all", "--", ".")
+	if err != nil {
+		return nil, fmt.Errorf("git status: %w", err)
+	}
+
+	// -z: "XY path\0", with renames and copies followed by "orig\0"
+	var files []FileStatus
+	fields := strings.Split(strings.TrimSuffix(out, "\x00"), "\x00")
+	for i := 0; i < len(fields); i++ {
+		rec := fields[i]
+		if len(rec) < 4 {
+			continue
+		}
+		fs := FileStatus{Staging: rec[0], Worktree: rec[1], Path: strings.TrimPrefix(rec[3:], prefix)}
+		if (fs.Staging == 'R' || fs.Staging == 'C') && i+1 < len(fields) {
+			i++
+			fs.OrigPath = strings.TrimPrefix(fields[i], prefix)
+		}
+		files = append(files, fs)
+	}
+	return files, nil
+}
+
+// Diff returns a unified diff. With commit set it's the changes that commit
+// made; otherwise it's the working tree against HEAD, or against the point
+// the session started from when sinceStart is set. paths limits the diff.
+// The patch is cut at 1MB; truncated reports whether that happened.
+func (g *GitOps) Diff(commit string, sinceStart bool, paths []string) (patch string, truncated bool, err error) {
+	args := []string{"-c", "core.quotePath=false"}
+	switch {
+	case commit != "":
+		hash, err := g.sessionCommit(commit)
+		if err != nil {
+			return "", false, err
+		}
+		args = append(args, "show", "--format=", "--patch", "--no-color", "--no-ext-diff", "--relative", hash)
+	case sinceStart:
+		base, err := g.sessionBase()
+		if err != nil {
+			return "", false, err
+		}
+		args = append(args, "diff", "--no-color", "--no-ext-diff", "--relative", base)
+	default:
+		args = append(args, "diff", "--no-color", "--no-ext-diff", "--relative", "HEAD")
+	}
+
+	args = append(args, "--")
+	for _, p := range paths {
+		clean, err := cleanPath(p)
+		if err != nil {
+			return "", false, err
+		}
+		args = append(args, clean)
+	}
+
+	out, err := runGit(g.root, args...)
+	if err != nil {
+		return "", false, fmt.Errorf("git diff: %w: %s", err, strings.TrimSpace(out))
+	}
+	if len(out) > maxDiffSize {
+		return out[:maxDiffSize], true, nil
+	}
+	return out, false, nil
+}
+
+// Log lists the session's commits, newest first. limit <= 0 means 50;
+// a non-empty file limits it to commits touching that path.
+func (g *GitOps) Log(limit int, file string) ([]Commit, error) {
+	if limit <= 0 {
+		limit = defaultLogLimit
+	}
+	rng := g.branch
+	if base, err := g.sessionBase(); err == nil {
+		rng = base + ".." + g.branch
+	}
+
+	// Fields separated by \x1f, commits by \x00 (-z)
+	format := "%H%x1f%an%x1f%ae%x1f%at%x1f%s%x1f%b%x1f%(trailers:key=Co-authored-by,valueonly,separator=%x1e)"
+	args := []string{"log", "-z", "-n", strconv.Itoa(limit), "--format=" + format, rng, "--"}
+	if file != "" {
+		clean, err := cleanPath(file)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, clean)
+	}
+
+	out, err := runGit(g.root, args...)
+	if err != nil {
+		return nil, fmt.Errorf("git log: %w: %s", err, strings.TrimSpace(out))
+	}
+
+	var commits []Commit
+	for _, rec := range strings.Split(out, "\x00") {
+		f := strings.Split(strings.TrimPrefix(rec, "\n"), "\x1f")
+		if len(f) != 7 {
+			continue
+		}
+		secs, _ := strconv.ParseInt(f[3], 10, 64)
+		c := Commit{
+			Hash:        f[0],
+			AuthorName:  f[1],
+			AuthorEmail: f[2],
+			Time:        time.Unix(secs, 0),
+			Subject:     f[4],
+			Body:        strings.TrimSpace(f[5]),
+		}
+		for _, a := range strings.Split(strings.TrimSpace(f[6]), "\x1e") {
+			if a = strings.TrimSpace(a); a != "" {
+				c.CoAuthors = append(c.CoAuthors, a)
+			}
+		}
+		commits = append(commits, c)
+	}
+	return commits, nil
+}
+
+// Blame attributes each line of file at rev, or of the working copy when rev
+// is empty (uncommitted lines have an all-zero hash).
+func (g *GitOps) Blame(file, rev string) ([]BlameLine, error) {
+	clean, err := cleanPath(file)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"blame", "--porcelain"}
+	if rev != "" {
+		hash, err := g.sessionCommit(rev)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, hash)
+	}
+	args = append(args, "--", clean)
+
+	out, err := runGit(g.root, args...)
+	if err != nil {
+		return nil, fmt.Errorf("git blame: %w: %s", err, strings.TrimSpace(out))
+	}
+	return parseBlame(out), nil
+}
+
+// parseBlame reads `git blame --porcelain`. Commit details are only printed
+// the first time a commit appears, so they're remembered by hash.
+func parseBlame(out string) []BlameLine {
+	type info struct {
+		name, email, summary string
+		time                 time.Time
+	}
+	commits := make(map[string]*info)
+
+	var lines []BlameLine
+	var cur *BlameLine
+	for _, l := range strings.Split(out, "\n") {
+		if cur == nil {
+			// Header: "<hash> <orig-line> <final-line> [<group-size>]"
+			f := strings.Fields(l)
+			if len(f) < 3 {
+				continue
+			}
+			n, _ := strconv.Atoi(f[2])
+			cur = &BlameLine{Hash: f[0], Line: n}
+			if commits[f[0]] == nil {
+				commits[f[0]] = &info{}
+			}
+			continue
+		}
+
+		ci := commits[cur.Hash]
+		key, val, _ := strings.Cut(l, " ")
+		switch {
+		case strings.HasPrefix(l, "\t"):
+			cur.Text = l[1:]
+			cur.AuthorName, cur.AuthorEmail = ci.name, ci.email
+			cur.Time, cur.Summary = ci.time, ci.summary
+			lines = append(lines, *cur)
+			cur = nil
+		case key == "author":
+			ci.name = val
+		case key == "author-mail":
+			ci.email = strings.Trim(val, "<>")
+		case key == "author-time":
+			secs, _ := strconv.ParseInt(val, 10, 64)
+			ci.time = time.Unix(secs, 0)
+		case key == "summary":
+			ci.summary = val
+		}
+	}
+	return lines
+}
+
+// sessionBase is the commit the session branched from.
+func (g *GitOps) sessionBase() (string, error) {
+	if g.origBranch == "" {
+		return "", fmt.Errorf("session has not started")
+	}
+	out, err := runGit(g.root, "merge-base", g.origBranch, g.branch)
+	if err != nil {
+		return "", fmt.Errorf("merge-base: %w", err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// sessionCommit resolves rev to a commit clients may look at: one made on
+// the session branch since it started, or the start itself. The host's
+// older history, its stash, and rev:path blobs from outside the root are
+// all refused.
+func (g *GitOps) sessionCommit(rev string) (string, error) {
+	if err := checkRev(rev); err != nil {
+		return "", err
+	}
+	hash, err := revParse(g.root, rev+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("%w: unknown commit %q", ErrInvalidArgument, rev)
+	}
+	base, err := g.sessionBase()
+	if err != nil {
+		return "", err
+	}
+	if hash == base {
+		return hash, nil
+	}
+	onBranch, err := isAncestor(g.root, hash, g.branch)
+	if err != nil {
+		return "", err
+	}
+	beforeStart, err := isAncestor(g.root, hash, base)
+	if err != nil {
+		return "", err
+	}
+	if !onBranch || beforeStart {
+		return "", fmt.Errorf("%w: %s is not in the session's history", ErrInvalidArgument, short(hash))
+	}
+	return hash, nil
+}
+
+// isAncestor reports whether commit a is reachable from b.
+func isAncestor(root, a, b string) (bool, error) {
+	out, err := runGit(root, "merge-base", "--is-ancestor", a, b)
+	var exit *exec.ExitError
+	if errors.As(err, &exit) && exit.ExitCode() == 1 {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("merge-base: %w: %s", err, strings.TrimSpace(out))
+	}
+	return true, nil
+}
+
+// checkRev rejects revisions git could mistake for options.
+func checkRev(rev string) error {
+	if strings.HasPrefix(rev, "-") || strings.ContainsAny(rev, " \t\n\x00") {
+		return fmt.Errorf("%w: revision %q", ErrInvalidArgument, rev)
+	}
+	return nil
+}
+
+// cleanPath makes a client path root-relative, refusing to leave the root.
+func cleanPath(p string) (string, error) {
+	clean := strings.TrimPrefix(path.Clean("/"+p), "/")
+	if clean == "" {
+		return ".", nil
+	}
+	if strings.HasPrefix(clean, ".git/") || clean == ".git" {
+		return "", fmt.Errorf("%w: path %q", ErrInvalidArgument, p)
+	}
+	return clean, nil
+}
@@ -0,0 +1,169 @@
+package gitops_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/victorarias/blue-guy/internal/gitops"
+	"github.com/victorarias/blue-guy/internal/identity"
+)
+
+func TestStatus_ListsUncommittedChanges(t *testing.T) {
+	dir := initRepo(t)
+	g := startSession(t, dir, "abc")
+	defer g.Stop()
+
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("changed\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "new.txt"), []byte("new\n"), 0644)
+
+	files, err := g.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, f := range files {
+		got[f.Path] = string([]byte{f.Staging, f.Worktree})
+	}
+	if got["README.md"] != " M" || got["new.txt"] != "??" || len(got) != 2 {
+		t.Errorf("unexpected status %v", got)
+	}
+}
+
+func TestDiff_WorkingTreeCommitAndSinceStart(t *testing.T) {
+	dir := initRepo(t)
+	g := startSession(t, dir, "abc")
+	defer g.Stop()
+
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("first\n"), 0644)
+	if err := g.Checkpoint("add a"); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("hello\nagain\n"), 0644)
+
+	patch, _, err := g.Diff("", false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(patch, "+again") || strings.Contains(patch, "a.txt") {
+		t.Errorf("working tree diff should only show README.md:\n%s", patch)
+	}
+
+	patch, _, err = g.Diff("HEAD", false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(patch, "+first") || strings.Contains(patch, "again") {
+		t.Errorf("commit diff should only show a.txt:\n%s", patch)
+	}
+
+	patch, _, err = g.Diff("", true, []string{"/a.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(patch, "+first") || strings.Contains(patch, "README") {
+		t.Errorf("diff since start limited to a.txt:\n%s", patch)
+	}
+}
+
+func TestLog_SessionCommitsWithCoAuthors(t *testing.T) {
+	dir := initRepo(t)
+	g := startSession(t, dir, "abc")
+	defer g.Stop()
+
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a\n"), 0644)
+	g.RecordContribution(identity.Identity{Name: "Alice", Email: "alice@example.com"}, "a.txt")
+	g.Checkpoint("add a")
+	os.WriteFile(filepath.Join(dir, "b.txt"), []byte("b\n"), 0644)
+	g.Checkpoint("add b")
+
+	commits, err := g.Log(0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 2 {
+		t.Fatalf("expected only the 2 session commits, got %+v", commits)
+	}
+	if commits[0].Subject != "add b" || commits[1].Subject != "add a" {
+		t.Errorf("expected newest first, got %q, %q", commits[0].Subject, commits[1].Subject)
+	}
+	if len(commits[1].CoAuthors) != 1 || commits[1].CoAuthors[0] != "Alice <alice@example.com>" {
+		t.Errorf("expected Alice as co-author, got %v", commits[1].CoAuthors)
+	}
+
+	commits, _ = g.Log(0, "a.txt")
+	if len(commits) != 1 || commits[0].Subject != "add a" {
+		t.Errorf("expected path filter to keep only 'add a', got %+v", commits)
+	}
+}
+
+func TestBlame_AttributesLines(t *testing.T) {
+	dir := initRepo(t)
+	g := startSession(t, dir, "abc")
+	defer g.Stop()
+
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("hello\nmob line\n"), 0644)
+	g.Checkpoint("mob edit")
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("hello\nmob line\nuncommitted\n"), 0644)
+
+	lines, err := g.Blame("README.md", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %+v", lines)
+	}
+	if lines[0].Summary != "initial" || lines[1].Summary != "mob edit" || lines[1].AuthorEmail != "host@example.com" {
+		t.Errorf("unexpected attribution %+v", lines[:2])
+	}
+	if strings.Trim(lines[2].Hash, "0") != "" || lines[2].Text != "uncommitted" {
+		t.Errorf("expected the last line to be uncommitted, got %+v", lines[2])
+	}
+}
+
+func TestHistory_RejectsOptionLikeArguments(t *testing.T) {
+	dir := initRepo(t)
+	g := startSession(t, dir, "abc")
+	defer g.Stop()
+
+	if _, _, err := g.Diff("--output=/tmp/x", false, nil); !errors.Is(err, gitops.ErrInvalidArgument) {
+		t.Errorf("expected ErrInvalidArgument for an option-like commit, got %v", err)
+	}
+	if _, err := g.Blame(".git/config", ""); !errors.Is(err, gitops.ErrInvalidArgument) {
+		t.Errorf("expected ErrInvalidArgument for a .git path, got %v", err)
+	}
+}
+
+func TestHistory_OnlySessionCommits(t *testing.T) {
+	dir := initRepo(t)
+	os.MkdirAll(filepath.Join(dir, "app"), 0755)
+	os.WriteFile(filepath.Join(dir, "app", "main.go"), []byte("package main\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "private.txt"), []byte("host only\n"), 0644)
+	git(t, dir, "add", "-A")
+	git(t, dir, "commit", "-q", "-m", "app")
+	// Uncommitted work the host stashed away
+	os.WriteFile(filepath.Join(dir, "private.txt"), []byte("stashed secret\n"), 0644)
+	git(t, dir, "stash", "-q")
+
+	g := startSessionWith(t, filepath.Join(dir, "app"), "abc", gitops.Options{})
+	defer g.Stop()
+	os.WriteFile(filepath.Join(dir, "app", "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644)
+	if err := g.Checkpoint("add main"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, rev := range []string{"HEAD:private.txt", "HEAD:../private.txt", "refs/stash", "stash@{0}", "HEAD~2"} {
+		patch, _, err := g.Diff(rev, false, nil)
+		if !errors.Is(err, gitops.ErrInvalidArgument) {
+			t.Errorf("Diff(%q): expected ErrInvalidArgument, got %v\n%s", rev, err, patch)
+		}
+		if _, err := g.Blame("main.go", rev); !errors.Is(err, gitops.ErrInvalidArgument) {
+			t.Errorf("Blame(%q): expected ErrInvalidArgument, got %v", rev, err)
+		}
+	}
+	if patch, _, err := g.Diff("HEAD", false, nil); err != nil || !strings.Contains(patch, "+func main") {
+		t.Errorf("expected the session's own commit, got %v\n%s", err, patch)
+	}
+}
@@ -0,0 +1,228 @@
+package gitops
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	defaultMaxFileSize   = 50 << 20 // GitHub warns above this and refuses 100MB
+	defaultMaxBinarySize = 5 << 20
+)
+
+// FileGuardOptions keeps large files and binaries out of auto-commits. The
+// zero value skips files over 50MB and binaries over 5MB.
+type FileGuardOptions struct {
+	// MaxFileSize is the largest file committed. 0 means 50MB; negative
+	// means no limit.
+	MaxFileSize int64
+	// MaxBinarySize is the largest binary file committed. 0 means 5MB;
+	// negative means no limit.
+	MaxBinarySize int64
+	// LFS commits offending files through Git LFS instead of skipping
+	// them. It needs git-lfs and the exec backend, whose git runs the
+	// LFS filters.
+	LFS bool
+}
+
+// LargeFile is a file the guard kept out of a commit, or sent to LFS.
+type LargeFile struct {
+	Path   string
+	Size   int64
+	Binary bool
+	LFS    bool // committed through Git LFS rather than skipped
+}
+
+// ParseSize parses a size such as "10MB", "512k" or "1G" (binary units), a
+// plain byte count, or "off" meaning no limit (-1).
+func ParseSize(s string) (int64, error) {
+	u := strings.TrimSpace(strings.ToUpper(s))
+	if u == "OFF" {
+		return -1, nil
+	}
+	num := strings.TrimRight(strings.TrimSuffix(u, "B"), "KMG")
+	unit := strings.TrimSuffix(strings.TrimPrefix(u, num), "B")
+	n, err := strconv.ParseInt(strings.TrimSpace(num), 10, 64)
+	if err != nil || n <= 0 || len(unit) > 1 {
+		return 0, fmt.Errorf("invalid size %q (want e.g. 500KB, 10MB, 1GB or off)", s)
+	}
+	switch unit {
+	case "K":
+		n <<= 10
+	case "M":
+		n <<= 20
+	case "G":
+		n <<= 30
+	}
+	return n, nil
+}
+
+// fileGuard applies FileGuardOptions to staged changes.
+type fileGuard struct {
+	root      string
+	maxSize   int64
+	maxBinary int64
+	lfs       bool
+
+	mu     sync.Mutex
+	listen func([]LargeFile)
+	last   string // files last reported, so every retry doesn't repeat them
+}
+
+func newFileGuard(root string, opts FileGuardOptions, backend string) (*fileGuard, error) {
+	g := &fileGuard{root: root, lfs: opts.LFS}
+	g.setLimits(opts)
+	if opts.LFS {
+		if backend == BackendGoGit {
+			return nil, fmt.Errorf("git LFS needs the %s backend", BackendExec)
+		}
+		if out, err := runGit(root, "lfs", "version"); err != nil {
+			return nil, fmt.Errorf("git LFS requested but not available: %s", strings.TrimSpace(out))
+		}
+	}
+	return g, nil
+}
+
+// setLimits applies the size limits in opts, filling in defaults. Called
+// under commitMu once the guard is in use.
+func (fg *fileGuard) setLimits(opts FileGuardOptions) {
+	fg.maxSize, fg.maxBinary = opts.MaxFileSize, opts.MaxBinarySize
+	if fg.maxSize == 0 {
+		fg.maxSize = defaultMaxFileSize
+	}
+	if fg.maxBinary == 0 {
+		fg.maxBinary = defaultMaxBinarySize
+	}
+}
+
+// check finds staged files over the limits. Files LFS already tracks are
+// fine, since the index only holds their pointers.
+func (fg *fileGuard) check(changes []stagedChange) []LargeFile {
+	var large []LargeFile
+	for _, c := range changes {
+		if c.status == 'D' {
+			continue
+		}
+		info, err := os.Lstat(filepath.Join(fg.root, c.path))
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		size := info.Size()
+		over := (fg.maxSize > 0 && size > fg.maxSize) || (c.binary && fg.maxBinary > 0 && size > fg.maxBinary)
+		if !over || fg.lfsTracked(c.path) {
+			continue
+		}
+		large = append(large, LargeFile{Path: c.path, Size: size, Binary: c.binary})
+	}
+	return large
+}
+
+func (fg *fileGuard) lfsTracked(path string) bool {
+	out, err := runGit(fg.root, "check-attr", "filter", "--", path)
+	return err == nil && strings.HasSuffix(strings.TrimSpace(out), ": filter: lfs")
+}
+
+// toLFS tracks files with LFS and stages them again through its filter.
+func (fg *fileGuard) toLFS(files []LargeFile) error {
+	args := []string{"lfs", "track", "--filename", "--"}
+	paths := []string{}
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	if out, err := runGit(fg.root, append(args, paths...)...); err != nil {
+		return fmt.Errorf("git lfs track: %w: %s", err, strings.TrimSpace(out))
+	}
+	// The files are already staged whole; renormalizing swaps in pointers
+	add := append([]string{"add", "--renormalize", "--"}, paths...)
+	if out, err := runGit(fg.root, add...); err != nil {
+		return fmt.Errorf("git add: %w: %s", err, strings.TrimSpace(out))
+	}
+	// git lfs track writes the repository's top-level .gitattributes, even
+	// for an exported subtree, and the pointers are useless without it
+	if out, err := runGit(fg.root, "add", "-A", "--", ":(top,glob)**/.gitattributes"); err != nil {
+		return fmt.Errorf("git add .gitattributes: %w: %s", err, strings.TrimSpace(out))
+	}
+	return nil
+}
+
+// report tells the listener about files it hasn't heard about yet, and
+// says whether they were new. An empty report clears the record.
+func (fg *fileGuard) report(files []LargeFile) bool {
+	key := fmt.Sprint(files)
+	fg.mu.Lock()
+	if len(files) == 0 || key == fg.last {
+		if len(files) == 0 {
+			fg.last = ""
+		}
+		fg.mu.Unlock()
+		return false
+	}
+	fg.last = key
+	fn := fg.listen
+	fg.mu.Unlock()
+
+	if fn != nil {
+		fn(files)
+	}
+	return true
+}
+
+// OnLargeFiles registers fn to be called with files kept out of a commit
+// (or sent to LFS) for being too large. The same files are reported once.
+func (g *GitOps) OnLargeFiles(fn func([]LargeFile)) {
+	g.guard.mu.Lock()
+	defer g.guard.mu.Unlock()
+	g.guard.listen = fn
+}
+
+// guardLargeFiles unstages files over the limits, or sends them to LFS, and
+// returns the changes still to be committed.
+func (g *GitOps) guardLargeFiles(changes []stagedChange) ([]stagedChange, error) {
+	large := g.guard.check(changes)
+	if len(large) == 0 {
+		g.guard.report(nil)
+		return changes, nil
+	}
+
+	if g.guard.lfs {
+		if err := g.guard.toLFS(large); err != nil {
+			return nil, err
+		}
+		for i := range large {
+			large[i].LFS = true
+		}
+		if g.guard.report(large) {
+			for _, f := range large {
+				g.log.Info().Str("path", f.Path).Int64("size", f.Size).Msg("Committing through Git LFS")
+			}
+		}
+		return changes, nil
+	}
+
+	skip := make(map[string]bool, len(large))
+	paths := make([]string, len(large))
+	for i, f := range large {
+		skip[f.Path] = true
+		paths[i] = f.Path
+	}
+	if err := g.repo.Unstage(paths...); err != nil {
+		return nil, fmt.Errorf("unstage large files: %w", err)
+	}
+	if g.guard.report(large) {
+		for _, f := range large {
+			g.log.Warn().Str("path", f.Path).Int64("size", f.Size).Bool("binary", f.Binary).Msg("Too large to auto-commit, skipping")
+		}
+	}
+
+	kept := changes[:0:0]
+	for _, c := range changes {
+		if !skip[c.path] {
+			kept = append(kept, c)
+		}
+	}
+	return kept, nil
+}
@@ -0,0 +1,135 @@
+package gitops_test
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/victorarias/blue-guy/internal/gitops"
+)
+
+func TestParseSize(t *testing.T) {
+	for in, want := range map[string]int64{
+		"100": 100, "512k": 512 << 10, "10MB": 10 << 20, "1 GB": 1 << 30, "off": -1,
+	} {
+		if got, err := gitops.ParseSize(in); err != nil || got != want {
+			t.Errorf("ParseSize(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "MB", "-5MB", "10TB", "ten"} {
+		if _, err := gitops.ParseSize(bad); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
+
+func TestLargeFiles_SkippedAndReported(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, opts gitops.Options) {
+		dir := initRepo(t)
+		opts.Files = gitops.FileGuardOptions{MaxFileSize: 1000, MaxBinarySize: 100}
+		g := startSessionWith(t, dir, "abc", opts)
+		defer g.Stop()
+
+		var mu sync.Mutex
+		var reports [][]gitops.LargeFile
+		g.OnLargeFiles(func(files []gitops.LargeFile) {
+			mu.Lock()
+			defer mu.Unlock()
+			reports = append(reports, files)
+		})
+
+		os.WriteFile(filepath.Join(dir, "big.txt"), bytes.Repeat([]byte("line\n"), 400), 0644)
+		os.WriteFile(filepath.Join(dir, "blob.bin"), append([]byte{0}, bytes.Repeat([]byte{1}, 200)...), 0644)
+		os.WriteFile(filepath.Join(dir, "icon.bin"), []byte{0, 1, 2}, 0644)
+		os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("small\n"), 0644)
+		if err := g.Checkpoint(""); err != nil {
+			t.Fatal(err)
+		}
+
+		committed := git(t, dir, "show", "--name-only", "--format=", "HEAD")
+		if committed != "icon.bin\nnotes.txt\n" {
+			t.Errorf("expected only the small files committed, got %q", committed)
+		}
+		if status := git(t, dir, "status", "--porcelain"); !strings.Contains(status, "?? big.txt") || !strings.Contains(status, "?? blob.bin") {
+			t.Errorf("expected the large files left untracked, got %q", status)
+		}
+
+		// Nothing else to commit, and the same files aren't reported twice
+		if err := g.Checkpoint(""); err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if len(reports) != 1 || len(reports[0]) != 2 {
+			t.Fatalf("expected one report of two files, got %+v", reports)
+		}
+		if f := reports[0][1]; f.Path != "blob.bin" || !f.Binary || f.Size != 201 || f.LFS {
+			t.Errorf("unexpected report %+v", f)
+		}
+	})
+}
+
+func TestLargeFiles_NoLimit(t *testing.T) {
+	dir := initRepo(t)
+	g := startSessionWith(t, dir, "abc", gitops.Options{Files: gitops.FileGuardOptions{MaxFileSize: -1, MaxBinarySize: -1}})
+	defer g.Stop()
+
+	os.WriteFile(filepath.Join(dir, "big.bin"), append([]byte{0}, bytes.Repeat([]byte{1}, 6<<20)...), 0644)
+	if err := g.Checkpoint(""); err != nil {
+		t.Fatal(err)
+	}
+	if committed := git(t, dir, "show", "--name-only", "--format=", "HEAD"); committed != "big.bin\n" {
+		t.Errorf("expected the file committed, got %q", committed)
+	}
+}
+
+func TestLargeFiles_LFS(t *testing.T) {
+	if exec.Command("git", "lfs", "version").Run() != nil {
+		dir := initRepo(t)
+		_, err := gitops.New(dir, "abc", gitops.Options{Files: gitops.FileGuardOptions{LFS: true}}, zerolog.Nop())
+		if err == nil {
+			t.Error("expected LFS to be refused without git-lfs")
+		}
+		t.Skip("git-lfs not installed")
+	}
+
+	dir := initRepo(t)
+	git(t, dir, "lfs", "install", "--local")
+	g := startSessionWith(t, dir, "abc", gitops.Options{Files: gitops.FileGuardOptions{MaxBinarySize: 100, LFS: true}})
+	defer g.Stop()
+
+	os.WriteFile(filepath.Join(dir, "blob.bin"), append([]byte{0}, bytes.Repeat([]byte{1}, 200)...), 0644)
+	if err := g.Checkpoint(""); err != nil {
+		t.Fatal(err)
+	}
+	if blob := git(t, dir, "show", "HEAD:blob.bin"); !strings.Contains(blob, "git-lfs") {
+		t.Errorf("expected an LFS pointer committed, got %q", blob)
+	}
+}
+
+func TestLargeFiles_Reconfigure(t *testing.T) {
+	dir := initRepo(t)
+	g := startSessionWith(t, dir, "abc", gitops.Options{Files: gitops.FileGuardOptions{MaxFileSize: 100}})
+	defer g.Stop()
+
+	os.WriteFile(filepath.Join(dir, "big.txt"), bytes.Repeat([]byte("a"), 200), 0644)
+	if err := g.Checkpoint(""); err != nil {
+		t.Fatal(err)
+	}
+	if committed := git(t, dir, "show", "--name-only", "--format=", "HEAD"); committed == "big.txt\n" {
+		t.Fatal("expected the file held back")
+	}
+
+	g.Reconfigure(gitops.Options{Files: gitops.FileGuardOptions{MaxFileSize: 1000}})
+	if err := g.Checkpoint(""); err != nil {
+		t.Fatal(err)
+	}
+	if committed := git(t, dir, "show", "--name-only", "--format=", "HEAD"); committed != "big.txt\n" {
+		t.Errorf("expected the file committed under the new limit, got %q", committed)
+	}
+}
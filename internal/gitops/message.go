@@ -0,0 +1,197 @@
+package gitops
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	hookTimeout     = 30 * time.Second
+	maxHookDiffSize = 256 << 10 // diff bytes piped to the message hook
+	maxListedFiles  = 20        // files listed in the message body
+)
+
+// stagedChange is one file in the index diff.
+type stagedChange struct {
+	status  byte // 'A', 'M', 'D', 'T'
+	path    string
+	added   int
+	deleted int
+	binary  bool
+}
+
+// summarize builds a commit message describing the change set, e.g.
+//
+//	mob: add 1 and update 2 files in /, internal/host (+42 -7)
+//
+//	A internal/host/poller.go (+40 -0)
+//	M internal/host/watcher.go (+2 -7)
+//	M README.md (+0 -0)
+func summarize(changes []stagedChange) string {
+	var added, modified, deleted, linesAdded, linesDeleted int
+	dirs := make(map[string]struct{})
+	for _, c := range changes {
+		switch c.status {
+		case 'A':
+			added++
+		case 'D':
+			deleted++
+		default:
+			modified++
+		}
+		linesAdded += c.added
+		linesDeleted += c.deleted
+		dirs[path.Dir(c.path)] = struct{}{}
+	}
+
+	var subject string
+	if len(changes) == 1 {
+		subject = fmt.Sprintf("mob: %s %s", verb(changes[0].status), changes[0].path)
+	} else {
+		var parts []string
+		for _, p := range []struct {
+			verb string
+			n    int
+		}{{"add", added}, {"update", modified}, {"delete", deleted}} {
+			if p.n > 0 {
+				parts = append(parts, fmt.Sprintf("%s %d", p.verb, p.n))
+			}
+		}
+		subject = fmt.Sprintf("mob: %s files in %s", joinAnd(parts), describeDirs(dirs))
+	}
+	subject += fmt.Sprintf(" (+%d -%d)", linesAdded, linesDeleted)
+
+	var b strings.Builder
+	b.WriteString(subject)
+	if len(changes) > 1 {
+		b.WriteString("\n\n")
+		for i, c := range changes {
+			if i == maxListedFiles {
+				fmt.Fprintf(&b, "... and %d more\n", len(changes)-maxListedFiles)
+				break
+			}
+			if c.binary {
+				fmt.Fprintf(&b, "%c %s (binary)\n", c.status, c.path)
+			} else {
+				fmt.Fprintf(&b, "%c %s (+%d -%d)\n", c.status, c.path, c.added, c.deleted)
+			}
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func verb(status byte) string {
+	switch status {
+	case 'A':
+		return "add"
+	case 'D':
+		return "delete"
+	default:
+		return "update"
+	}
+}
+
+func joinAnd(parts []string) string {
+	if len(parts) <= 1 {
+		return strings.Join(parts, "")
+	}
+	return strings.Join(parts[:len(parts)-1], ", ") + " and " + parts[len(parts)-1]
+}
+
+func describeDirs(dirs map[string]struct{}) string {
+	if len(dirs) > 3 {
+		return fmt.Sprintf("%d directories", len(dirs))
+	}
+	names := make([]string, 0, len(dirs))
+	for d := range dirs {
+		if d == "." {
+			d = "/"
+		}
+		names = append(names, d)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// runMessageHook asks the configured hook for a commit message. The hook runs
+// through the shell in the repository root with the staged diff on stdin and
+// the generated summary in BLUEGUY_SUMMARY; its trimmed stdout is the message.
+func (g *GitOps) runMessageHook(summary string) (string, error) {
+	diff, err := runGit(g.root, "diff", "--cached", "--relative")
+	if err != nil {
+		return "", fmt.Errorf("diff for hook: %w", err)
+	}
+	diff = truncateDiff(diff, maxHookDiffSize)
+
+	ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", g.opts.MessageHook)
+	cmd.Dir = g.root
+	cmd.Stdin = strings.NewReader(diff)
+	cmd.Env = append(os.Environ(),
+		"BLUEGUY_SESSION="+g.sessionID,
+		"BLUEGUY_BRANCH="+g.branch,
+		"BLUEGUY_SUMMARY="+summary,
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("message hook: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	msg := strings.TrimSpace(string(out))
+	if msg == "" {
+		return "", fmt.Errorf("message hook produced no output")
+	}
+	return msg, nil
+}
+
+// truncateDiff cuts diff to at most limit bytes at a line break, so the hook
+// never sees half a line or half a character, and says so on a last line.
+func truncateDiff(diff string, limit int) string {
+	if len(diff) <= limit {
+		return diff
+	}
+	const noteRoom = 64 // for the note below
+	cut := diff[:max(limit-noteRoom, 0)]
+	if i := strings.LastIndexByte(cut, '\n'); i >= 0 {
+		cut = cut[:i+1]
+	} else {
+		// One huge line: stop before the first byte of a split character
+		for len(cut) > 0 && !utf8.RuneStart(diff[len(cut)]) {
+			cut = cut[:len(cut)-1]
+		}
+		cut += "\n"
+	}
+	return cut + fmt.Sprintf("[diff truncated: first %d of %d bytes]\n", len(cut), len(diff))
+}
+
+// commitMessage describes the staged changes, using the hook when configured.
+// Any failure falls back to the plain timestamped auto-save message.
+func (g *GitOps) commitMessage(changes []stagedChange) string {
+	fallback := fmt.Sprintf("mob: auto-save at %s", g.clock.Now().Format("15:04:05"))
+	if len(changes) == 0 {
+		return fallback
+	}
+	summary := summarize(changes)
+
+	if g.opts.MessageHook == "" {
+		return summary
+	}
+	msg, err := g.runMessageHook(summary)
+	if err != nil {
+		g.log.Warn().Err(err).Msg("Commit message hook failed")
+		return fallback
+	}
+	return msg
+}
@@ -0,0 +1,297 @@
+package gitops
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DirtyMode says what Start does with uncommitted changes it finds.
+type DirtyMode string
+
+const (
+	DirtyRefuse  DirtyMode = "refuse"  // don't start the session
+	DirtyStash   DirtyMode = "stash"   // stash them now, restore them on Stop
+	DirtyInclude DirtyMode = "include" // carry them into the session's first commit
+)
+
+// ParseDirtyMode validates a dirty-tree mode name. Empty means refuse.
+func ParseDirtyMode(s string) (DirtyMode, error) {
+	switch DirtyMode(s) {
+	case "", DirtyRefuse:
+		return DirtyRefuse, nil
+	case DirtyStash, DirtyInclude:
+		return DirtyMode(s), nil
+	default:
+		return "", fmt.Errorf("unknown dirty mode %q (want refuse, stash or include)", s)
+	}
+}
+
+// RepoState is what the working copy looked like before the session started.
+type RepoState struct {
+	Branch      string   // checked-out branch, empty when detached
+	Head        string   // commit HEAD points at
+	Dirty       []string // `git status --porcelain` lines
+	Operation   string   // in-progress merge, rebase, ... (empty if none)
+	IndexLocked bool     // index.lock exists
+	lockPath    string
+}
+
+func (s *RepoState) Detached() bool { return s.Branch == "" }
+
+// ref is what to check out to get back to this state.
+func (s *RepoState) ref() string {
+	if s.Detached() {
+		return s.Head
+	}
+	return s.Branch
+}
+
+// Report describes the state in a few human-readable lines.
+func (s *RepoState) Report() string {
+	var b strings.Builder
+	if s.Detached() {
+		fmt.Fprintf(&b, "HEAD is detached at %s\n", short(s.Head))
+	} else {
+		fmt.Fprintf(&b, "On branch %s at %s\n", s.Branch, short(s.Head))
+	}
+	if s.Operation != "" {
+		fmt.Fprintf(&b, "A %s is in progress; finish or abort it first\n", s.Operation)
+	}
+	if s.IndexLocked {
+		fmt.Fprintf(&b, "The index is locked (%s); another git process is running, or remove the stale lock\n", s.lockPath)
+	}
+	if len(s.Dirty) > 0 {
+		fmt.Fprintf(&b, "%d uncommitted change(s):\n", len(s.Dirty))
+		for i, line := range s.Dirty {
+			if i == 10 {
+				fmt.Fprintf(&b, "  ... and %d more\n", len(s.Dirty)-10)
+				break
+			}
+			fmt.Fprintf(&b, "  %s\n", line)
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// StateError means the repository isn't in a state a session can start from.
+type StateError struct {
+	Reason string
+	State  *RepoState
+}
+
+func (e *StateError) Error() string {
+	return e.Reason + "\n" + e.State.Report()
+}
+
+// inspect captures the repository state relevant to starting a session.
+func inspect(root string) (*RepoState, error) {
+	head, err := revParse(root, "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("repository has no commits yet: %w", err)
+	}
+	st := &RepoState{Head: head}
+
+	if out, err := runGit(root, "symbolic-ref", "--quiet", "--short", "HEAD"); err == nil {
+		st.Branch = strings.TrimSpace(out)
+	}
+
+	for _, op := range []struct{ path, name string }{
+		{"rebase-merge", "rebase"},
+		{"rebase-apply", "rebase"},
+		{"MERGE_HEAD", "merge"},
+		{"CHERRY_PICK_HEAD", "cherry-pick"},
+		{"REVERT_HEAD", "revert"},
+		{"BISECT_LOG", "bisect"},
+	} {
+		if exists(gitPath(root, op.path)) {
+			st.Operation = op.name
+			break
+		}
+	}
+
+	st.lockPath = gitPath(root, "index.lock")
+	st.IndexLocked = exists(st.lockPath)
+
+	// Only changes under root matter: an exported subtree's session leaves
+	// the rest of the repository alone
+	out, err := runGit(root, "status", "--porcelain", "--untracked-files=all", "--", ".")
+	if err != nil {
+		return nil, fmt.Errorf("git status: %w", err)
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if strings.TrimSpace(line) != "" {
+			st.Dirty = append(st.Dirty, line)
+		}
+	}
+	return st, nil
+}
+
+// gitPath resolves a path inside the git directory (handles worktrees).
+func gitPath(root, name string) string {
+	out, err := runGit(root, "rev-parse", "--git-path", name)
+	if err != nil {
+		return filepath.Join(root, ".git", name)
+	}
+	p := strings.TrimSpace(out)
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(root, p)
+	}
+	return p
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func short(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	return sha
+}
+
+// stashConfigKey is where the stash protecting pre-session changes is recorded, so
+// it can be found again after a crash.
+func stashConfigKey(branch string) string {
+	return "branch." + branch + ".mobStash"
+}
+
+// preflight checks the repository before the session branch is created and
+// sets uncommitted changes aside according to the dirty mode. stashed
+// reports whether it did, even when it then fails.
+func (g *GitOps) preflight() (stashed bool, err error) {
+	st, err := inspect(g.root)
+	if err != nil {
+		return false, err
+	}
+	g.origBranch, g.origDetached = st.ref(), st.Detached()
+
+	if st.Operation != "" {
+		return false, &StateError{Reason: "cannot start a mob session during a " + st.Operation, State: st}
+	}
+	if st.IndexLocked {
+		return false, &StateError{Reason: "cannot start a mob session while the index is locked", State: st}
+	}
+	if len(st.Dirty) == 0 {
+		return false, nil
+	}
+	if g.opts.Resume && st.Branch == g.branch {
+		// Recovering from a crash: the changes are the session's own unsaved
+		// work, and anything stashed at the original start is still recorded.
+		if sha, err := runGit(g.root, "config", "--get", stashConfigKey(g.branch)); err == nil {
+			g.stash = strings.TrimSpace(sha)
+		}
+		return false, nil
+	}
+
+	switch g.opts.Dirty {
+	case DirtyStash, DirtyInclude:
+	default:
+		return false, &StateError{
+			Reason: "working tree has uncommitted changes; commit them, or start with --dirty=stash or --dirty=include",
+			State:  st,
+		}
+	}
+
+	// Stash everything under the root (including untracked files) so Stop
+	// can put back exactly what was there. Include mode re-applies it
+	// right away so the changes become part of the session.
+	msg := fmt.Sprintf("blue-guy: pre-session changes for %s", g.branch)
+	if out, err := runGit(g.root, "stash", "push", "--include-untracked", "-m", msg, "--", "."); err != nil {
+		return false, fmt.Errorf("stash uncommitted changes: %w: %s", err, strings.TrimSpace(out))
+	}
+	sha, err := revParse(g.root, "refs/stash")
+	if err != nil {
+		return true, err
+	}
+	g.stash = sha
+	if _, err := runGit(g.root, "config", stashConfigKey(g.branch), sha); err != nil {
+		g.log.Warn().Err(err).Msg("Failed to record stash")
+	}
+
+	if g.opts.Dirty == DirtyInclude {
+		if out, err := runGit(g.root, "stash", "apply", "--index", sha); err != nil {
+			return true, fmt.Errorf("re-apply changes: %w: %s", err, strings.TrimSpace(out))
+		}
+	}
+
+	g.log.Info().
+		Str("mode", string(g.opts.Dirty)).
+		Int("changes", len(st.Dirty)).
+		Str("stash", short(sha)).
+		Msg("Set aside uncommitted changes; they will be restored on stop")
+	return true, nil
+}
+
+// restorePrior puts the working copy back the way preflight found it: the
+// original branch (or detached commit) with any stashed changes re-applied.
+func (g *GitOps) restorePrior() {
+	if g.origBranch == "" || !g.checkoutPrior() || g.stash == "" {
+		return
+	}
+	if out, err := runGit(g.root, "stash", "apply", "--index", g.stash); err != nil {
+		g.log.Error().Err(err).
+			Str("stash", g.stash).
+			Str("output", strings.TrimSpace(out)).
+			Msg("Failed to restore pre-session changes; recover them with `git stash apply " + g.stash + "`")
+		return
+	}
+	g.forgetStash()
+	g.log.Info().Msg("Restored pre-session changes")
+}
+
+// abandonStart undoes a Start that failed after preflight stashed changes.
+// Include mode has already put them back in the tree, which the checkout
+// carries along, so the stash only needs dropping.
+func (g *GitOps) abandonStart(included bool) {
+	if !included {
+		g.restorePrior()
+		return
+	}
+	if g.checkoutPrior() {
+		g.forgetStash()
+	}
+}
+
+// checkoutPrior switches back to the original branch or commit, saying how
+// to recover stashed changes if it can't.
+func (g *GitOps) checkoutPrior() bool {
+	ref := g.origBranch
+	args := []string{"checkout", ref}
+	if g.origDetached {
+		args = []string{"checkout", "--detach", ref}
+	}
+	if out, err := runGit(g.root, args...); err != nil {
+		g.log.Error().Err(err).Str("ref", ref).Str("output", strings.TrimSpace(out)).Msg("Failed to restore original branch")
+		if g.stash != "" {
+			g.log.Error().Str("stash", g.stash).Msg("Pre-session changes are still stashed; recover them with `git stash apply " + g.stash + "`")
+		}
+		return false
+	}
+	g.log.Info().Str("ref", ref).Msg("Restored original branch")
+	return true
+}
+
+// forgetStash drops the stash once its changes are back in the tree.
+func (g *GitOps) forgetStash() {
+	g.dropStash()
+	g.stash = ""
+	runGit(g.root, "config", "--unset", stashConfigKey(g.branch))
+}
+
+// dropStash removes our entry from the stash list once it has been re-applied.
+func (g *GitOps) dropStash() {
+	out, err := runGit(g.root, "stash", "list", "--format=%H")
+	if err != nil {
+		return
+	}
+	for i, sha := range strings.Split(strings.TrimSpace(out), "\n") {
+		if sha == g.stash {
+			runGit(g.root, "stash", "drop", fmt.Sprintf("stash@{%d}", i))
+			return
+		}
+	}
+}
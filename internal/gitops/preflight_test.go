@@ -0,0 +1,168 @@
+package gitops_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/victorarias/blue-guy/internal/gitops"
+)
+
+// dirty leaves a staged edit, an unstaged edit and an untracked file.
+func dirty(t *testing.T, dir string) {
+	t.Helper()
+	os.WriteFile(filepath.Join(dir, "staged.txt"), []byte("staged\n"), 0644)
+	git(t, dir, "add", "staged.txt")
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("edited\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "scratch.txt"), []byte("mine\n"), 0644)
+}
+
+func startErr(t *testing.T, dir string, opts gitops.Options) error {
+	t.Helper()
+	g, err := gitops.New(dir, "abc", opts, zerolog.Nop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g.Start(context.Background())
+}
+
+func TestStart_RefusesDirtyTreeByDefault(t *testing.T) {
+	dir := initRepo(t)
+	dirty(t, dir)
+	before := git(t, dir, "status", "--porcelain")
+
+	err := startErr(t, dir, gitops.Options{})
+	var stateErr *gitops.StateError
+	if !errors.As(err, &stateErr) {
+		t.Fatalf("expected a StateError, got %v", err)
+	}
+	if len(stateErr.State.Dirty) != 3 || !strings.Contains(err.Error(), "scratch.txt") {
+		t.Errorf("expected report to list the 3 changes, got:\n%s", err)
+	}
+	if got := git(t, dir, "status", "--porcelain"); got != before {
+		t.Errorf("refusing must not touch the tree:\n%s\nvs\n%s", before, got)
+	}
+	if got := strings.TrimSpace(git(t, dir, "rev-parse", "--abbrev-ref", "HEAD")); got != "main" {
+		t.Errorf("expected to stay on main, got %s", got)
+	}
+}
+
+func TestStart_StashModeRestoresChangesOnStop(t *testing.T) {
+	dir := initRepo(t)
+	dirty(t, dir)
+	before := git(t, dir, "status", "--porcelain")
+
+	g := startSessionWith(t, dir, "abc", gitops.Options{Dirty: gitops.DirtyStash})
+	if got := git(t, dir, "status", "--porcelain"); got != "" {
+		t.Fatalf("expected a clean tree during the session, got:\n%s", got)
+	}
+
+	os.WriteFile(filepath.Join(dir, "mob.txt"), []byte("mob\n"), 0644)
+	g.Stop()
+
+	if got := git(t, dir, "status", "--porcelain"); got != before {
+		t.Errorf("expected pre-session state back:\n%s\ngot:\n%s", before, got)
+	}
+	if got := git(t, dir, "stash", "list"); got != "" {
+		t.Errorf("expected stash to be dropped, got %q", got)
+	}
+	files := git(t, dir, "show", "--name-only", "--format=", "mob/session-abc")
+	if strings.Contains(files, "scratch.txt") || !strings.Contains(files, "mob.txt") {
+		t.Errorf("expected only mob work in the session, got %q", files)
+	}
+}
+
+func TestStart_FailureAfterStashingRestoresChanges(t *testing.T) {
+	for _, mode := range []gitops.DirtyMode{gitops.DirtyStash, gitops.DirtyInclude} {
+		t.Run(string(mode), func(t *testing.T) {
+			dir := initRepo(t)
+			git(t, dir, "branch", "mob/session-abc") // so creating it fails
+			dirty(t, dir)
+			before := git(t, dir, "status", "--porcelain")
+
+			if err := startErr(t, dir, gitops.Options{Dirty: mode}); err == nil {
+				t.Fatal("expected the existing branch to fail the start")
+			}
+			if got := git(t, dir, "status", "--porcelain"); got != before {
+				t.Errorf("expected the changes back:\n%s\ngot:\n%s", before, got)
+			}
+			if got := git(t, dir, "stash", "list"); got != "" {
+				t.Errorf("expected nothing left stashed, got %q", got)
+			}
+		})
+	}
+}
+
+func TestStart_IncludeModeCommitsChangesAndRestoresThem(t *testing.T) {
+	dir := initRepo(t)
+	dirty(t, dir)
+	before := git(t, dir, "status", "--porcelain")
+
+	g := startSessionWith(t, dir, "abc", gitops.Options{Dirty: gitops.DirtyInclude})
+	g.Stop()
+
+	files := git(t, dir, "show", "--name-only", "--format=", "mob/session-abc")
+	for _, f := range []string{"README.md", "staged.txt", "scratch.txt"} {
+		if !strings.Contains(files, f) {
+			t.Errorf("expected %s in the session commit, got %q", f, files)
+		}
+	}
+	if got := git(t, dir, "status", "--porcelain"); got != before {
+		t.Errorf("expected pre-session state back:\n%s\ngot:\n%s", before, got)
+	}
+}
+
+func TestStart_DetachedHeadIsRestored(t *testing.T) {
+	dir := initRepo(t)
+	head := strings.TrimSpace(git(t, dir, "rev-parse", "HEAD"))
+	git(t, dir, "checkout", "-q", "--detach", head)
+
+	g := startSession(t, dir, "abc")
+	os.WriteFile(filepath.Join(dir, "new.txt"), []byte("x\n"), 0644)
+	g.Stop()
+
+	if got := strings.TrimSpace(git(t, dir, "rev-parse", "--abbrev-ref", "HEAD")); got != "HEAD" {
+		t.Errorf("expected a detached HEAD again, got %s", got)
+	}
+	if got := strings.TrimSpace(git(t, dir, "rev-parse", "HEAD")); got != head {
+		t.Errorf("expected HEAD at %s, got %s", head, got)
+	}
+}
+
+func TestStart_RefusesInProgressMerge(t *testing.T) {
+	dir := initRepo(t)
+	git(t, dir, "checkout", "-q", "-b", "other")
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("other\n"), 0644)
+	git(t, dir, "commit", "-q", "-am", "other")
+	git(t, dir, "checkout", "-q", "main")
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("main\n"), 0644)
+	git(t, dir, "commit", "-q", "-am", "main")
+	// Conflicts, leaving the merge in progress (so not via the helper)
+	merge := exec.Command("git", "merge", "other")
+	merge.Dir = dir
+	if merge.Run() == nil {
+		t.Fatal("expected the merge to conflict")
+	}
+
+	err := startErr(t, dir, gitops.Options{Dirty: gitops.DirtyInclude})
+	var stateErr *gitops.StateError
+	if !errors.As(err, &stateErr) || stateErr.State.Operation != "merge" {
+		t.Fatalf("expected a merge StateError, got %v", err)
+	}
+}
+
+func TestStart_RefusesLockedIndex(t *testing.T) {
+	dir := initRepo(t)
+	os.WriteFile(filepath.Join(dir, ".git", "index.lock"), nil, 0644)
+
+	err := startErr(t, dir, gitops.Options{})
+	var stateErr *gitops.StateError
+	if !errors.As(err, &stateErr) || !stateErr.State.IndexLocked {
+		t.Fatalf("expected a locked-index StateError, got %v", err)
+	}
+}
@@ -0,0 +1,245 @@
+package gitops
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/victorarias/blue-guy/internal/clock"
+)
+
+// PushPolicy says when auto-commits are pushed.
+type PushPolicy string
+
+const (
+	PushOnCommit PushPolicy = "commit"   // after every auto-commit
+	PushInterval PushPolicy = "interval" // at most every PushOptions.Interval
+	PushOnStop   PushPolicy = "stop"     // once, when the session stops
+	PushNever    PushPolicy = "never"
+)
+
+const (
+	defaultRemote  = "origin"
+	defaultRefspec = "refs/heads/{branch}:refs/heads/{branch}"
+
+	minPushBackoff = 5 * time.Second
+	maxPushBackoff = 5 * time.Minute
+)
+
+// ParsePushPolicy parses "commit", "stop", "never", or a duration such as
+// "10m" meaning push every interval. Empty means commit.
+func ParsePushPolicy(s string) (PushPolicy, time.Duration, error) {
+	switch PushPolicy(s) {
+	case "", PushOnCommit:
+		return PushOnCommit, 0, nil
+	case PushOnStop, PushNever:
+		return PushPolicy(s), 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return "", 0, fmt.Errorf("unknown push policy %q (want commit, stop, never or an interval like 10m)", s)
+	}
+	return PushInterval, d, nil
+}
+
+// PushOptions configures pushing. The zero value pushes the session branch
+// to origin after every commit.
+type PushOptions struct {
+	Policy PushPolicy
+	// Interval is the push period for PushInterval.
+	Interval time.Duration
+	// Remote defaults to origin.
+	Remote string
+	// Refspec defaults to pushing the session branch to the same name.
+	// "{branch}" is replaced with the session branch name.
+	Refspec string
+}
+
+// PushState summarises pushing for status displays.
+type PushState struct {
+	Policy    PushPolicy
+	Remote    string
+	Pending   bool      // there are commits the remote doesn't have yet
+	LastPush  time.Time // zero if nothing was pushed yet
+	LastError string    // empty after a successful push
+	Failures  int       // consecutive failed attempts
+	NextRetry time.Time // zero unless backing off after a failure
+	Held      string    // why pushing is on hold, empty normally
+}
+
+// pusher pushes in the background so a slow or unreachable remote never
+// holds up commits. It has its own repo handle for the same reason.
+type pusher struct {
+	repo     repo
+	opts     PushOptions
+	refspec  string
+	disabled bool // no such remote
+	clock    clock.Clock
+	log      zerolog.Logger
+	onChange func(PushState)
+
+	kick chan struct{}
+	quit chan struct{}
+	done chan struct{}
+
+	mu      sync.Mutex
+	state   PushState
+	commits uint64 // bumped by every Committed, so a push knows what it covered
+}
+
+func newPusher(r repo, branch string, opts PushOptions, clk clock.Clock, onChange func(PushState), log zerolog.Logger) *pusher {
+	if opts.Policy == "" {
+		opts.Policy = PushOnCommit
+	}
+	if opts.Remote == "" {
+		opts.Remote = defaultRemote
+	}
+	if opts.Refspec == "" {
+		opts.Refspec = defaultRefspec
+	}
+	p := &pusher{
+		repo:     r,
+		opts:     opts,
+		refspec:  strings.ReplaceAll(opts.Refspec, "{branch}", branch),
+		clock:    clk,
+		log:      log,
+		onChange: onChange,
+		kick:     make(chan struct{}, 1),
+		quit:     make(chan struct{}),
+		done:     make(chan struct{}),
+		state:    PushState{Policy: opts.Policy, Remote: opts.Remote},
+	}
+
+	if opts.Policy != PushNever && !r.HasRemote(opts.Remote) {
+		// Say so once rather than failing after every commit
+		p.disabled = true
+		p.state.LastError = fmt.Sprintf("no remote %q configured", opts.Remote)
+		log.Info().Str("remote", opts.Remote).Msg("No remote configured; commits stay local")
+	}
+	return p
+}
+
+// Start begins pushing in the background. The interval ticker starts here
+// rather than in the goroutine, so it counts from the session's start.
+func (p *pusher) Start() {
+	var tick clock.Ticker
+	if p.opts.Policy == PushInterval {
+		tick = p.clock.NewTicker(p.opts.Interval)
+	}
+	go p.run(tick)
+}
+
+// Committed records a new local commit and pushes it if the policy says so.
+func (p *pusher) Committed() {
+	p.update(func(s *PushState) {
+		p.commits++
+		s.Pending = true
+	})
+	if p.opts.Policy == PushOnCommit && !p.disabled {
+		select {
+		case p.kick <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Stop ends background pushing and, unless the policy is never, makes a
+// final attempt to push whatever is pending.
+func (p *pusher) Stop() {
+	close(p.quit)
+	<-p.done
+	if st := p.State(); p.opts.Policy != PushNever && !p.disabled && st.Pending && st.Held == "" {
+		p.push()
+	}
+}
+
+// Hold stops all pushing for the rest of the session, e.g. because a commit
+// may contain a secret that mustn't reach the remote.
+func (p *pusher) Hold(reason string) {
+	p.update(func(s *PushState) { s.Held = reason })
+	p.log.Warn().Str("reason", reason).Msg("Pushing on hold")
+}
+
+func (p *pusher) State() PushState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.state
+}
+
+func (p *pusher) run(ticker clock.Ticker) {
+	defer close(p.done)
+
+	var tick <-chan time.Time
+	if ticker != nil {
+		defer ticker.Stop()
+		tick = ticker.C()
+	}
+	var retry <-chan time.Time
+
+	for {
+		select {
+		case <-p.quit:
+			return
+		case <-p.kick:
+			if retry != nil {
+				continue // the retry will pick this commit up too
+			}
+		case <-tick:
+			if retry != nil {
+				continue
+			}
+		case <-retry:
+			retry = nil
+		}
+
+		if st := p.State(); p.disabled || !st.Pending || st.Held != "" {
+			continue
+		}
+		if err := p.push(); err != nil {
+			retry = p.clock.After(p.State().NextRetry.Sub(p.clock.Now()))
+		}
+	}
+}
+
+// push makes one attempt and updates the state, scheduling the next retry
+// with exponential backoff on failure.
+func (p *pusher) push() error {
+	p.mu.Lock()
+	covered := p.commits
+	p.mu.Unlock()
+	err := p.repo.Push(p.opts.Remote, p.refspec)
+	if err != nil {
+		p.update(func(s *PushState) {
+			s.Failures++
+			s.LastError = err.Error()
+			backoff := minPushBackoff << min(s.Failures-1, 6)
+			s.NextRetry = p.clock.Now().Add(min(backoff, maxPushBackoff))
+		})
+		st := p.State()
+		p.log.Warn().Err(err).Int("failures", st.Failures).Time("retry", st.NextRetry).Msg("Push failed")
+		return err
+	}
+
+	p.update(func(s *PushState) {
+		// A commit made while pushing is still to go
+		s.Pending = p.commits != covered
+		s.LastPush = p.clock.Now()
+		s.LastError = ""
+		s.Failures = 0
+		s.NextRetry = time.Time{}
+	})
+	p.log.Debug().Str("remote", p.opts.Remote).Msg("Pushed")
+	return nil
+}
+
+func (p *pusher) update(fn func(*PushState)) {
+	p.mu.Lock()
+	fn(&p.state)
+	st := p.state
+	p.mu.Unlock()
+	if p.onChange != nil {
+		p.onChange(st)
+	}
+}
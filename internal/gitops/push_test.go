@@ -0,0 +1,258 @@
+package gitops_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/victorarias/blue-guy/internal/clock"
+	"github.com/victorarias/blue-guy/internal/gitops"
+)
+
+// withRemote adds a bare repository as the named remote.
+func withRemote(t *testing.T, dir, name string) string {
+	t.Helper()
+	remote := t.TempDir()
+	git(t, remote, "init", "-q", "--bare")
+	git(t, dir, "remote", "add", name, remote)
+	return remote
+}
+
+func remoteHas(remote, ref string) bool {
+	cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", ref)
+	cmd.Dir = remote
+	return cmd.Run() == nil
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestParsePushPolicy(t *testing.T) {
+	for in, want := range map[string]gitops.PushPolicy{
+		"": gitops.PushOnCommit, "commit": gitops.PushOnCommit, "stop": gitops.PushOnStop,
+		"never": gitops.PushNever, "10m": gitops.PushInterval,
+	} {
+		got, _, err := gitops.ParsePushPolicy(in)
+		if err != nil || got != want {
+			t.Errorf("ParsePushPolicy(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, d, _ := gitops.ParsePushPolicy("90s"); d != 90*time.Second {
+		t.Errorf("expected a 90s interval, got %s", d)
+	}
+	for _, bad := range []string{"sometimes", "-5m", "0s"} {
+		if _, _, err := gitops.ParsePushPolicy(bad); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
+
+func TestPush_EveryCommitIsAsync(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, opts gitops.Options) {
+		dir := initRepo(t)
+		remote := withRemote(t, dir, "origin")
+		g := startSessionWith(t, dir, "abc", opts)
+		defer g.Stop()
+
+		os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a\n"), 0644)
+		g.NotifyChange()
+		g.Flush()
+
+		waitFor(t, "push", func() bool { return remoteHas(remote, "mob/session-abc") })
+		waitFor(t, "push state", func() bool {
+			st := g.PushState()
+			return !st.Pending && !st.LastPush.IsZero() && st.LastError == ""
+		})
+	})
+}
+
+func TestPush_OnStopOnly(t *testing.T) {
+	dir := initRepo(t)
+	remote := withRemote(t, dir, "origin")
+	c := clock.NewFake(epoch)
+	g := startSessionWith(t, dir, "abc", gitops.Options{Clock: c, Push: gitops.PushOptions{Policy: gitops.PushOnStop}})
+
+	// Committing doesn't wake the pusher and it has no timers, so once the
+	// clock has moved on nothing can be on its way
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a\n"), 0644)
+	g.NotifyChange()
+	g.Flush()
+	c.Advance(time.Hour)
+	if remoteHas(remote, "mob/session-abc") {
+		t.Fatal("expected nothing pushed before stop")
+	}
+	if !g.PushState().Pending {
+		t.Error("expected the commit to be pending")
+	}
+
+	g.Stop()
+	if !remoteHas(remote, "mob/session-abc") {
+		t.Error("expected the session to be pushed on stop")
+	}
+}
+
+func TestPush_Never(t *testing.T) {
+	dir := initRepo(t)
+	remote := withRemote(t, dir, "origin")
+	g := startSessionWith(t, dir, "abc", gitops.Options{Push: gitops.PushOptions{Policy: gitops.PushNever}})
+
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a\n"), 0644)
+	g.Stop()
+
+	if remoteHas(remote, "mob/session-abc") {
+		t.Error("expected nothing pushed")
+	}
+}
+
+func TestPush_Interval(t *testing.T) {
+	dir := initRepo(t)
+	remote := withRemote(t, dir, "origin")
+	c := clock.NewFake(epoch)
+	g := startSessionWith(t, dir, "abc", gitops.Options{Clock: c, Push: gitops.PushOptions{
+		Policy:   gitops.PushInterval,
+		Interval: 10 * time.Minute,
+	}})
+	defer g.Stop()
+
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a\n"), 0644)
+	g.NotifyChange()
+	g.Flush()
+	c.Advance(10*time.Minute - time.Second)
+	if remoteHas(remote, "mob/session-abc") || !g.PushState().Pending {
+		t.Fatal("expected nothing pushed before the interval")
+	}
+	c.Advance(time.Second)
+	waitFor(t, "interval push", func() bool { return remoteHas(remote, "mob/session-abc") })
+}
+
+func TestPush_CommitDuringPush(t *testing.T) {
+	dir := initRepo(t)
+	remote := withRemote(t, dir, "origin")
+
+	// The remote holds the first push it gets once armed until released
+	fifos := t.TempDir()
+	entered, release := filepath.Join(fifos, "entered"), filepath.Join(fifos, "release")
+	for _, f := range []string{entered, release} {
+		if err := syscall.Mkfifo(f, 0600); err != nil {
+			t.Skip("no fifos:", err)
+		}
+	}
+	arm, held := filepath.Join(fifos, "arm"), filepath.Join(fifos, "held")
+	hook := "#!/bin/sh\nif [ -e " + arm + " ] && [ ! -e " + held + " ]; then\n" +
+		"\ttouch " + held + "\n\techo > " + entered + "\n\tread x < " + release + "\nfi\n"
+	if err := os.WriteFile(filepath.Join(remote, "hooks", "pre-receive"), []byte(hook), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	g := startSessionWith(t, dir, "abc", gitops.Options{})
+	defer g.Stop()
+	os.WriteFile(arm, nil, 0644)
+
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a\n"), 0644)
+	g.NotifyChange()
+	g.Flush()
+	if _, err := os.ReadFile(entered); err != nil {
+		t.Fatal(err)
+	}
+
+	// Committed while the first push is still going
+	os.WriteFile(filepath.Join(dir, "b.txt"), []byte("b\n"), 0644)
+	g.NotifyChange()
+	g.Flush()
+	if err := os.WriteFile(release, []byte("go\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	head := strings.TrimSpace(git(t, dir, "rev-parse", "mob/session-abc"))
+	waitFor(t, "the second commit pushed", func() bool {
+		out, _ := exec.Command("git", "-C", remote, "rev-parse", "mob/session-abc").Output()
+		return strings.TrimSpace(string(out)) == head && !g.PushState().Pending
+	})
+}
+
+func TestPush_CustomRemoteAndRefspec(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, opts gitops.Options) {
+		dir := initRepo(t)
+		backup := withRemote(t, dir, "backup")
+		opts.Push = gitops.PushOptions{
+			Remote:  "backup",
+			Refspec: "refs/heads/{branch}:refs/heads/saved/{branch}",
+		}
+		g := startSessionWith(t, dir, "abc", opts)
+
+		os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a\n"), 0644)
+		g.Stop()
+
+		if !remoteHas(backup, "refs/heads/saved/mob/session-abc") {
+			t.Error("expected the branch under saved/ on the backup remote")
+		}
+	})
+}
+
+func TestPush_MissingRemoteIsQuiet(t *testing.T) {
+	dir := initRepo(t)
+	c := clock.NewFake(epoch)
+	g := startSessionWith(t, dir, "abc", gitops.Options{Clock: c})
+	defer g.Stop()
+
+	st := g.PushState()
+	if !strings.Contains(st.LastError, "no remote") {
+		t.Errorf("expected the missing remote to be reported, got %+v", st)
+	}
+
+	// A disabled pusher is never woken, so there's nothing to wait for
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a\n"), 0644)
+	g.NotifyChange()
+	g.Flush()
+	c.Advance(time.Hour)
+	if st := g.PushState(); st.Failures != 0 || !st.Pending {
+		t.Errorf("expected no push attempts and a pending commit, got %+v", st)
+	}
+}
+
+func TestPush_FailureBacksOff(t *testing.T) {
+	dir := initRepo(t)
+	git(t, dir, "remote", "add", "origin", filepath.Join(t.TempDir(), "missing.git"))
+	c := clock.NewFake(epoch)
+	g := startSessionWith(t, dir, "abc", gitops.Options{Clock: c})
+	defer g.Stop()
+
+	var changes atomic.Int32
+	g.OnPushState(func(gitops.PushState) { changes.Add(1) })
+
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a\n"), 0644)
+	g.NotifyChange()
+	g.Flush()
+	waitFor(t, "failed push", func() bool { return g.PushState().Failures == 1 && c.Pending() == 1 })
+
+	st := g.PushState()
+	if st.LastError == "" || !st.Pending {
+		t.Errorf("expected an error and a pending commit, got %+v", st)
+	}
+	if !st.NextRetry.Equal(epoch.Add(5 * time.Second)) {
+		t.Errorf("expected a retry in 5s, got %s", st.NextRetry.Sub(epoch))
+	}
+	if changes.Load() == 0 {
+		t.Error("expected push state changes to be reported")
+	}
+
+	// The retry fails too and doubles the backoff
+	c.Advance(5 * time.Second)
+	waitFor(t, "second failed push", func() bool { return g.PushState().Failures == 2 })
+	if st := g.PushState(); !st.NextRetry.Equal(epoch.Add(15 * time.Second)) {
+		t.Errorf("expected the next retry 10s later, got %s", st.NextRetry.Sub(epoch))
+	}
+}
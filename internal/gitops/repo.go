@@ -0,0 +1,317 @@
+package gitops
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Backend names for Options.Backend.
+const (
+	BackendExec  = "exec"   // run the git binary (honours the user's config and hooks)
+	BackendGoGit = "go-git" // in-process, no git binary or hooks on the commit path
+)
+
+// ParseBackend validates a backend name. Empty means exec.
+func ParseBackend(s string) (string, error) {
+	switch s {
+	case "", BackendExec:
+		return BackendExec, nil
+	case BackendGoGit:
+		return s, nil
+	default:
+		return "", fmt.Errorf("unknown git backend %q (want %s or %s)", s, BackendExec, BackendGoGit)
+	}
+}
+
+// repo is the set of git operations on the auto-commit path, which runs
+// after every quiet period. Branch lifecycle, stashing and Finish are rarer
+// and always use the git binary.
+//
+// The GitOps root may be a subdirectory of the repository (an exported
+// subtree); everything below is confined to it unless it says otherwise.
+type repo interface {
+	// AddAll stages every change under the root, like `git add -A .`.
+	AddAll() error
+	// HasStaged reports whether the index differs from HEAD under the root.
+	HasStaged() (bool, error)
+	// Staged lists staged files under the GitOps root with line counts,
+	// sorted by path. Paths are relative to the root.
+	Staged() ([]stagedChange, error)
+	// StagedLines lists the lines staged changes add under the root, with
+	// their line numbers in the new file. Binary files are left out.
+	StagedLines() ([]addedLine, error)
+	// Unstage resets the index to HEAD under the root, leaving the working
+	// tree alone. Paths, relative to the root, limit it to those files.
+	Unstage(paths ...string) error
+	// Commit records the index on the current branch. Only the root's part
+	// of it is committed: whatever else is staged stays staged, except
+	// .gitattributes files that apply to the root.
+	Commit(msg string) error
+	// HasRemote reports whether a remote is configured.
+	HasRemote(name string) bool
+	// Push pushes refspec to remote, making its destination the upstream.
+	Push(remote, refspec string) error
+}
+
+// addedLine is a line a staged change adds.
+type addedLine struct {
+	path string // relative to the root
+	line int    // 1-based, in the new file
+	text string
+}
+
+func newRepo(root, backend string) (repo, error) {
+	backend, err := ParseBackend(backend)
+	if err != nil {
+		return nil, err
+	}
+	if backend == BackendGoGit {
+		return openGoGitRepo(root)
+	}
+	prefix, err := runGit(root, "rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(prefix))
+	}
+	return execRepo{root: root, prefix: strings.TrimSpace(prefix)}, nil
+}
+
+// RepoRoot returns the top of the working tree root belongs to, or empty
+// if root isn't in a git repository.
+func RepoRoot(root string) string {
+	out, err := runGit(root, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
+
+// execRepo shells out to git for every operation.
+type execRepo struct {
+	root   string
+	prefix string // root relative to the top, with a trailing slash; "" at the top
+}
+
+func (r execRepo) AddAll() error {
+	if out, err := runGit(r.root, "add", "-A", "--", "."); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(out))
+	}
+	return nil
+}
+
+func (r execRepo) HasStaged() (bool, error) {
+	_, err := runGit(r.root, "diff", "--cached", "--quiet", "--", ".")
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return true, nil
+	}
+	return false, err
+}
+
+func (r execRepo) Staged() ([]stagedChange, error) {
+	statusOut, err := runGit(r.root, "diff", "--cached", "--name-status", "--no-renames", "--relative", "-z")
+	if err != nil {
+		return nil, fmt.Errorf("name-status: %w", err)
+	}
+	numstatOut, err := runGit(r.root, "diff", "--cached", "--numstat", "--no-renames", "--relative", "-z")
+	if err != nil {
+		return nil, fmt.Errorf("numstat: %w", err)
+	}
+
+	// --name-status -z: "<status>\0<path>\0" pairs
+	fields := strings.Split(strings.TrimSuffix(statusOut, "\x00"), "\x00")
+	changes := make([]stagedChange, 0, len(fields)/2)
+	index := make(map[string]int)
+	for i := 0; i+1 < len(fields); i += 2 {
+		if fields[i] == "" {
+			continue
+		}
+		index[fields[i+1]] = len(changes)
+		changes = append(changes, stagedChange{status: fields[i][0], path: fields[i+1]})
+	}
+
+	// --numstat -z: "<added>\t<deleted>\t<path>\0", "-" counts for binaries
+	for _, rec := range strings.Split(numstatOut, "\x00") {
+		parts := strings.SplitN(rec, "\t", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		i, ok := index[parts[2]]
+		if !ok {
+			continue
+		}
+		if parts[0] == "-" {
+			changes[i].binary = true
+			continue
+		}
+		changes[i].added, _ = strconv.Atoi(parts[0])
+		changes[i].deleted, _ = strconv.Atoi(parts[1])
+	}
+	return changes, nil
+}
+
+func (r execRepo) StagedLines() ([]addedLine, error) {
+	out, err := runGit(r.root, "-c", "core.quotePath=false", "diff", "--cached", "--relative",
+		"--no-renames", "--no-color", "--no-ext-diff", "-U0", "--src-prefix=a/", "--dst-prefix=b/")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(out))
+	}
+	return parseAddedLines(out), nil
+}
+
+// parseAddedLines reads the "+" lines out of a zero-context patch. Hunk
+// headers give the line counts, so an added line that happens to start
+// with "++" isn't mistaken for a file header.
+func parseAddedLines(patch string) []addedLine {
+	var lines []addedLine
+	var file string
+	var line, oldLeft, newLeft int
+	for _, l := range strings.Split(patch, "\n") {
+		if oldLeft > 0 || newLeft > 0 {
+			switch {
+			case strings.HasPrefix(l, "+"):
+				lines = append(lines, addedLine{path: file, line: line, text: l[1:]})
+				line++
+				newLeft--
+			case strings.HasPrefix(l, "-"):
+				oldLeft--
+			}
+			continue
+		}
+		switch {
+		case strings.HasPrefix(l, "+++ "):
+			file = strings.TrimPrefix(strings.TrimPrefix(l, "+++ "), "b/")
+		case strings.HasPrefix(l, "@@ "):
+			// "@@ -<start>[,<count>] +<start>[,<count>] @@"
+			f := strings.Fields(l)
+			if len(f) < 3 {
+				continue
+			}
+			_, oldLeft = hunkRange(f[1])
+			line, newLeft = hunkRange(f[2])
+		}
+	}
+	return lines
+}
+
+func hunkRange(s string) (start, count int) {
+	first, rest, ok := strings.Cut(s[1:], ",")
+	start, _ = strconv.Atoi(first)
+	count = 1
+	if ok {
+		count, _ = strconv.Atoi(rest)
+	}
+	return start, count
+}
+
+func (r execRepo) Unstage(paths ...string) error {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	args := append([]string{"reset", "-q", "--"}, paths...)
+	if out, err := runGit(r.root, args...); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(out))
+	}
+	return nil
+}
+
+func (r execRepo) Commit(msg string) error {
+	var env []string
+	if r.prefix != "" {
+		index, cleanup, err := r.rootIndex()
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		if index != "" {
+			env = append(os.Environ(), "GIT_INDEX_FILE="+index)
+		}
+	}
+	if out, err := runGitEnv(r.root, env, "commit", "-m", msg); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(out))
+	}
+	return nil
+}
+
+// rootIndex copies the index to a scratch file and resets everything staged
+// outside the root there, so a commit from it leaves what the host staged
+// elsewhere -- `add -p` hunks and all -- in the real index. It returns ""
+// when nothing outside is staged.
+func (r execRepo) rootIndex() (string, func(), error) {
+	noop := func() {}
+	// Without --relative, paths are from the top whatever the directory
+	out, err := runGit(r.root, "diff", "--cached", "--name-only", "--no-renames", "-z")
+	if err != nil {
+		return "", noop, fmt.Errorf("%w: %s", err, strings.TrimSpace(out))
+	}
+	var specs []string
+	for _, p := range strings.Split(out, "\x00") {
+		if p != "" && !inRoot(r.prefix, p) {
+			specs = append(specs, ":(top,literal)"+p)
+		}
+	}
+	if len(specs) == 0 {
+		return "", noop, nil
+	}
+
+	index, err := runGit(r.root, "rev-parse", "--path-format=absolute", "--git-path", "index")
+	if err != nil {
+		return "", noop, fmt.Errorf("%w: %s", err, strings.TrimSpace(index))
+	}
+	data, err := os.ReadFile(strings.TrimSpace(index))
+	if err != nil {
+		return "", noop, err
+	}
+	dir, err := os.MkdirTemp("", "blueguy-index-")
+	if err != nil {
+		return "", noop, err
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+	scratch := filepath.Join(dir, "index")
+	if err := os.WriteFile(scratch, data, 0600); err != nil {
+		cleanup()
+		return "", noop, err
+	}
+	env := append(os.Environ(), "GIT_INDEX_FILE="+scratch)
+	if out, err := runGitEnv(r.root, env, append([]string{"reset", "-q", "--"}, specs...)...); err != nil {
+		cleanup()
+		return "", noop, fmt.Errorf("%w: %s", err, strings.TrimSpace(out))
+	}
+	return scratch, cleanup, nil
+}
+
+// inRoot reports whether p, relative to the top of the repository, belongs
+// in a commit of the root at prefix: it's under the root, or it's a
+// .gitattributes file in the root's ancestry deciding how its files are
+// stored (git lfs track writes the top-level one).
+func inRoot(prefix, p string) bool {
+	if prefix == "" {
+		return true
+	}
+	prefix = strings.TrimSuffix(prefix, "/") + "/"
+	if strings.HasPrefix(p, prefix) {
+		return true
+	}
+	if path.Base(p) != ".gitattributes" {
+		return false
+	}
+	dir := path.Dir(p)
+	return dir == "." || strings.HasPrefix(prefix, dir+"/")
+}
+
+func (r execRepo) HasRemote(name string) bool {
+	_, err := runGit(r.root, "remote", "get-url", name)
+	return err == nil
+}
+
+func (r execRepo) Push(remote, refspec string) error {
+	if out, err := runGit(r.root, "push", "-u", remote, refspec); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(out))
+	}
+	return nil
+}
@@ -0,0 +1,437 @@
+package gitops
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// binarySniffLen is how much of a file git inspects for NUL bytes when
+// deciding whether it's binary.
+const binarySniffLen = 8000
+
+// goGitRepo implements repo in-process with go-git. It reads the same
+// repository the git binary does, so the two can be mixed freely.
+type goGitRepo struct {
+	repo   *git.Repository
+	wt     *git.Worktree
+	prefix string // root relative to the worktree top, "" at the top
+
+	// st is the worktree status, which means hashing every file on a big
+	// repository, so it's kept until the index changes. A commit stages,
+	// then reads it several times.
+	st git.Status
+}
+
+func openGoGitRepo(root string) (*goGitRepo, error) {
+	r, err := git.PlainOpenWithOptions(root, &git.PlainOpenOptions{
+		DetectDotGit:          true,
+		EnableDotGitCommonDir: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("open repository: %w", err)
+	}
+	wt, err := r.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("open worktree: %w", err)
+	}
+
+	top, err := filepath.EvalSymlinks(wt.Filesystem.Root())
+	if err != nil {
+		return nil, err
+	}
+	abs, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, err
+	}
+	prefix, err := filepath.Rel(top, abs)
+	if err != nil {
+		return nil, err
+	}
+	if prefix == "." {
+		prefix = ""
+	}
+	return &goGitRepo{repo: r, wt: wt, prefix: filepath.ToSlash(prefix)}, nil
+}
+
+// status returns the worktree status, computing it only if the index has
+// changed since it was last asked for.
+func (r *goGitRepo) status() (git.Status, error) {
+	if r.st == nil {
+		st, err := r.wt.Status()
+		if err != nil {
+			return nil, err
+		}
+		r.st = st
+	}
+	return r.st, nil
+}
+
+func (r *goGitRepo) AddAll() error {
+	r.st = nil
+	if r.prefix != "" {
+		return r.wt.AddWithOptions(&git.AddOptions{Path: r.prefix})
+	}
+	return r.wt.AddWithOptions(&git.AddOptions{All: true})
+}
+
+func (r *goGitRepo) HasStaged() (bool, error) {
+	staged, err := r.staged(true)
+	return len(staged) > 0, err
+}
+
+// staged lists worktree paths with staged changes, under the root if
+// inside is true and outside it otherwise.
+func (r *goGitRepo) staged(inside bool) ([]string, error) {
+	st, err := r.status()
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for path, fs := range st {
+		if fs.Staging == git.Unmodified || fs.Staging == git.Untracked {
+			continue
+		}
+		if _, ok := r.relative(path); ok == inside {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+func (r *goGitRepo) Staged() ([]stagedChange, error) {
+	st, err := r.status()
+	if err != nil {
+		return nil, err
+	}
+	idx, err := r.repo.Storer.Index()
+	if err != nil {
+		return nil, fmt.Errorf("read index: %w", err)
+	}
+	tree, err := r.headTree()
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []stagedChange
+	for path, fs := range st {
+		var status byte
+		switch fs.Staging {
+		case git.Added:
+			status = 'A'
+		case git.Deleted:
+			status = 'D'
+		case git.Modified, git.Renamed, git.Copied:
+			status = 'M'
+		default:
+			continue
+		}
+		rel, ok := r.relative(path)
+		if !ok {
+			continue
+		}
+
+		var before, after []byte
+		if tree != nil && status != 'A' {
+			if f, err := tree.File(path); err == nil {
+				if before, err = blobContents(r.repo, f.Hash); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if status != 'D' {
+			if e, err := idx.Entry(path); err == nil {
+				if after, err = blobContents(r.repo, e.Hash); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		c := stagedChange{status: status, path: rel}
+		if isBinary(before) || isBinary(after) {
+			c.binary = true
+		} else {
+			c.added, c.deleted = countLines(string(before), string(after))
+		}
+		changes = append(changes, c)
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].path < changes[j].path })
+	return changes, nil
+}
+
+func (r *goGitRepo) StagedLines() ([]addedLine, error) {
+	st, err := r.status()
+	if err != nil {
+		return nil, err
+	}
+	idx, err := r.repo.Storer.Index()
+	if err != nil {
+		return nil, fmt.Errorf("read index: %w", err)
+	}
+	tree, err := r.headTree()
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []addedLine
+	for path, fs := range st {
+		if fs.Staging != git.Added && fs.Staging != git.Modified {
+			continue
+		}
+		rel, ok := r.relative(path)
+		if !ok {
+			continue
+		}
+		var before, after []byte
+		if tree != nil {
+			if f, err := tree.File(path); err == nil {
+				if before, err = blobContents(r.repo, f.Hash); err != nil {
+					return nil, err
+				}
+			}
+		}
+		e, err := idx.Entry(path)
+		if err != nil {
+			continue
+		}
+		if after, err = blobContents(r.repo, e.Hash); err != nil {
+			return nil, err
+		}
+		if isBinary(before) || isBinary(after) {
+			continue
+		}
+
+		n := 1
+		for _, d := range diff.Do(string(before), string(after)) {
+			text := strings.TrimSuffix(d.Text, "\n")
+			switch d.Type {
+			case diffmatchpatch.DiffEqual:
+				n += strings.Count(text, "\n") + 1
+			case diffmatchpatch.DiffInsert:
+				for _, l := range strings.Split(text, "\n") {
+					lines = append(lines, addedLine{path: rel, line: n, text: l})
+					n++
+				}
+			}
+		}
+	}
+
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].path != lines[j].path {
+			return lines[i].path < lines[j].path
+		}
+		return lines[i].line < lines[j].line
+	})
+	return lines, nil
+}
+
+func (r *goGitRepo) Unstage(paths ...string) error {
+	files := make([]string, len(paths))
+	for i, p := range paths {
+		files[i] = path.Join(r.prefix, p)
+	}
+	if len(paths) == 0 && r.prefix != "" {
+		var err error
+		if files, err = r.staged(true); err != nil || len(files) == 0 {
+			return err
+		}
+	}
+	return r.reset(files)
+}
+
+// reset resets the index to HEAD for files (worktree paths), or all of it
+// when there are none.
+func (r *goGitRepo) reset(files []string) error {
+	r.st = nil
+	head, err := r.repo.Head()
+	if err != nil {
+		return err
+	}
+	return r.wt.Reset(&git.ResetOptions{Commit: head.Hash(), Mode: git.MixedReset, Files: files})
+}
+
+// headTree is HEAD's tree, or nil before the first commit.
+func (r *goGitRepo) headTree() (*object.Tree, error) {
+	head, err := r.repo.Head()
+	if err != nil {
+		return nil, nil
+	}
+	commit, err := r.repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, err
+	}
+	return commit.Tree()
+}
+
+// relative maps a worktree path to one under the GitOps root, like
+// `git diff --relative`.
+func (r *goGitRepo) relative(path string) (string, bool) {
+	if r.prefix == "" {
+		return path, true
+	}
+	if rel, ok := strings.CutPrefix(path, r.prefix+"/"); ok {
+		return rel, true
+	}
+	return "", false
+}
+
+func (r *goGitRepo) Commit(msg string) error {
+	defer func() { r.st = nil }()
+	var outside []string
+	if r.prefix != "" {
+		staged, err := r.staged(false)
+		if err != nil {
+			return err
+		}
+		for _, p := range staged {
+			if !inRoot(r.prefix, p) {
+				outside = append(outside, p)
+			}
+		}
+	}
+	if len(outside) == 0 {
+		_, err := r.wt.Commit(cleanMessage(msg), &git.CommitOptions{})
+		return err
+	}
+
+	// Commit with what's staged outside the root reset, then put it back,
+	// so the host's own staging survives
+	orig, err := r.repo.Storer.Index()
+	if err != nil {
+		return fmt.Errorf("read index: %w", err)
+	}
+	if err := r.reset(outside); err != nil {
+		return err
+	}
+	_, commitErr := r.wt.Commit(cleanMessage(msg), &git.CommitOptions{})
+	idx, err := r.repo.Storer.Index()
+	if err != nil {
+		return fmt.Errorf("read index: %w", err)
+	}
+	for _, p := range outside {
+		idx.Remove(p)
+		if e, err := orig.Entry(p); err == nil {
+			idx.Entries = append(idx.Entries, e)
+		}
+	}
+	sort.Slice(idx.Entries, func(i, j int) bool { return idx.Entries[i].Name < idx.Entries[j].Name })
+	if err := r.repo.Storer.SetIndex(idx); err != nil {
+		return fmt.Errorf("restore index: %w", err)
+	}
+	return commitErr
+}
+
+func (r *goGitRepo) HasRemote(name string) bool {
+	_, err := r.repo.Remote(name)
+	return err == nil
+}
+
+func (r *goGitRepo) Push(remote, refspec string) error {
+	spec := config.RefSpec(refspec)
+	if err := spec.Validate(); err != nil {
+		return err
+	}
+	err := r.repo.Push(&git.PushOptions{
+		RemoteName: remote,
+		RefSpecs:   []config.RefSpec{spec},
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return err
+	}
+
+	// Equivalent of push -u, written only once
+	src, dst := plumbing.ReferenceName(spec.Src()), spec.Dst("")
+	if !src.IsBranch() || !dst.IsBranch() {
+		return nil
+	}
+	branch := src.Short()
+	cfg, err := r.repo.Config()
+	if err != nil {
+		return err
+	}
+	b, ok := cfg.Branches[branch]
+	if !ok {
+		b = &config.Branch{Name: branch}
+		cfg.Branches[branch] = b
+	}
+	if b.Remote == remote && b.Merge == dst {
+		return nil
+	}
+	b.Remote, b.Merge = remote, dst
+	return r.repo.SetConfig(cfg)
+}
+
+func blobContents(r *git.Repository, hash plumbing.Hash) ([]byte, error) {
+	blob, err := r.BlobObject(hash)
+	if err != nil {
+		return nil, fmt.Errorf("read blob %s: %w", hash, err)
+	}
+	rd, err := blob.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer rd.Close()
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(rd); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func isBinary(b []byte) bool {
+	if len(b) > binarySniffLen {
+		b = b[:binarySniffLen]
+	}
+	return bytes.IndexByte(b, 0) >= 0
+}
+
+// countLines returns the lines added and deleted between two texts.
+func countLines(before, after string) (added, deleted int) {
+	for _, d := range diff.Do(before, after) {
+		n := strings.Count(d.Text, "\n")
+		if !strings.HasSuffix(d.Text, "\n") && d.Text != "" {
+			n++
+		}
+		switch d.Type {
+		case diffmatchpatch.DiffInsert:
+			added += n
+		case diffmatchpatch.DiffDelete:
+			deleted += n
+		}
+	}
+	return added, deleted
+}
+
+// cleanMessage tidies a message the way `git commit` does by default:
+// trailing whitespace and surrounding blank lines go, runs of blank lines
+// collapse to one, and it ends in a newline.
+func cleanMessage(msg string) string {
+	var lines []string
+	blank := false
+	for _, line := range strings.Split(msg, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			blank = len(lines) > 0
+			continue
+		}
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,90 @@
+package gitops
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ResumeLatest asks ResolveSession for the most recent session.
+const ResumeLatest = "latest"
+
+const sessionPrefix = "mob/session-"
+
+// ResolveSession turns a --resume argument into a session ID. ResumeLatest
+// picks the mob/session-* branch with the newest commit; anything else must
+// name an existing session branch, locally or on origin.
+func ResolveSession(root, id string) (string, error) {
+	if id == ResumeLatest {
+		branch, err := latestSessionBranch(root)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimPrefix(branch, sessionPrefix), nil
+	}
+
+	branch := sessionPrefix + id
+	if _, err := revParse(root, "refs/heads/"+branch); err == nil {
+		return id, nil
+	}
+	if _, err := revParse(root, "refs/remotes/origin/"+branch); err == nil {
+		return id, nil
+	}
+	return "", fmt.Errorf("no session branch %s found", branch)
+}
+
+// resumeBranch switches to an existing session branch, creating it from the
+// remote if only that exists, and fast-forwards it if the remote moved on
+// (e.g. another host continued the session and pushed).
+func (g *GitOps) resumeBranch() error {
+	remoteRef := "refs/remotes/origin/" + g.branch
+
+	// Best effort: there may be no remote, or we may be offline
+	if _, err := runGit(g.root, "fetch", "origin", g.branch); err != nil {
+		g.log.Debug().Err(err).Msg("Fetch of session branch failed")
+	}
+
+	if _, err := revParse(g.root, "refs/heads/"+g.branch); err != nil {
+		if _, err := revParse(g.root, remoteRef); err != nil {
+			return fmt.Errorf("session branch %s not found", g.branch)
+		}
+		if _, err := runGit(g.root, "checkout", "-b", g.branch, "--track", "origin/"+g.branch); err != nil {
+			return fmt.Errorf("check out %s from origin: %w", g.branch, err)
+		}
+		return nil
+	}
+
+	if current, _ := runGit(g.root, "rev-parse", "--abbrev-ref", "HEAD"); strings.TrimSpace(current) != g.branch {
+		if out, err := runGit(g.root, "checkout", g.branch); err != nil {
+			return fmt.Errorf("check out %s: %w: %s", g.branch, err, strings.TrimSpace(out))
+		}
+	}
+
+	if _, err := revParse(g.root, remoteRef); err != nil {
+		return nil // never pushed
+	}
+	if _, err := runGit(g.root, "merge-base", "--is-ancestor", "HEAD", remoteRef); err != nil {
+		// Local is ahead or the two diverged; leave it to the next push to sort out
+		g.log.Warn().Str("branch", g.branch).Msg("Local session branch is not behind origin; not fast-forwarding")
+		return nil
+	}
+	if out, err := runGit(g.root, "merge", "--ff-only", remoteRef); err != nil {
+		return fmt.Errorf("fast-forward %s: %w: %s", g.branch, err, strings.TrimSpace(out))
+	}
+	g.log.Info().Str("branch", g.branch).Msg("Fast-forwarded session branch from origin")
+	return nil
+}
+
+// InterruptedSession returns the ID of the session whose branch is checked
+// out, which happens when a host exits without restoring the original
+// branch (e.g. after a crash). Empty if HEAD isn't on a session branch.
+func InterruptedSession(root string) string {
+	out, err := runGit(root, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return ""
+	}
+	branch := strings.TrimSpace(out)
+	if !strings.HasPrefix(branch, sessionPrefix) {
+		return ""
+	}
+	return strings.TrimPrefix(branch, sessionPrefix)
+}
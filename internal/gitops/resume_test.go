@@ -0,0 +1,97 @@
+package gitops_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/victorarias/blue-guy/internal/gitops"
+)
+
+func TestResume_ContinuesExistingBranch(t *testing.T) {
+	dir := initRepo(t)
+	runSession(t, dir, "abc", "a.txt")
+	before := strings.TrimSpace(git(t, dir, "rev-parse", "mob/session-abc"))
+
+	id, err := gitops.ResolveSession(dir, gitops.ResumeLatest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "abc" {
+		t.Fatalf("expected latest session abc, got %s", id)
+	}
+
+	g := startSessionWith(t, dir, id, gitops.Options{Resume: true})
+	if got := strings.TrimSpace(git(t, dir, "rev-parse", "--abbrev-ref", "HEAD")); got != "mob/session-abc" {
+		t.Fatalf("expected to be on the session branch, got %s", got)
+	}
+	os.WriteFile(filepath.Join(dir, "b.txt"), []byte("b\n"), 0644)
+	g.Stop()
+
+	if parent := strings.TrimSpace(git(t, dir, "rev-parse", "mob/session-abc~1")); parent != before {
+		t.Errorf("resumed commit should build on %s, got parent %s", before, parent)
+	}
+	if got := strings.TrimSpace(git(t, dir, "rev-parse", "--abbrev-ref", "HEAD")); got != "main" {
+		t.Errorf("expected to be back on main, got %s", got)
+	}
+}
+
+func TestResume_AfterCrashRestoresOriginalBranch(t *testing.T) {
+	dir := initRepo(t)
+	g := startSession(t, dir, "abc")
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a\n"), 0644)
+	g.Checkpoint("")
+	// No Stop: the host died while on the session branch
+
+	if id := gitops.InterruptedSession(dir); id != "abc" {
+		t.Fatalf("expected interrupted session abc, got %q", id)
+	}
+
+	g = startSessionWith(t, dir, "abc", gitops.Options{Resume: true})
+	g.Stop()
+
+	if got := strings.TrimSpace(git(t, dir, "rev-parse", "--abbrev-ref", "HEAD")); got != "main" {
+		t.Errorf("expected the recorded original branch main, got %s", got)
+	}
+}
+
+func TestResume_FastForwardsFromRemote(t *testing.T) {
+	remote := t.TempDir()
+	git(t, remote, "init", "-q", "--bare")
+
+	dir := initRepo(t)
+	git(t, dir, "remote", "add", "origin", remote)
+	runSession(t, dir, "abc", "a.txt") // pushes mob/session-abc
+
+	// Another machine continues the session and pushes
+	other := t.TempDir()
+	git(t, other, "clone", "-q", "-b", "mob/session-abc", remote, ".")
+	git(t, other, "config", "user.name", "Other")
+	git(t, other, "config", "user.email", "other@example.com")
+	os.WriteFile(filepath.Join(other, "b.txt"), []byte("b\n"), 0644)
+	git(t, other, "add", "-A")
+	git(t, other, "commit", "-q", "-m", "from elsewhere")
+	git(t, other, "push", "-q", "origin", "mob/session-abc")
+	remoteTip := strings.TrimSpace(git(t, other, "rev-parse", "HEAD"))
+
+	g := startSessionWith(t, dir, "abc", gitops.Options{Resume: true})
+	defer g.Stop()
+
+	if got := strings.TrimSpace(git(t, dir, "rev-parse", "HEAD")); got != remoteTip {
+		t.Errorf("expected fast-forward to %s, got %s", remoteTip, got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "b.txt")); err != nil {
+		t.Error("b.txt from the remote should be in the working tree")
+	}
+}
+
+func TestResolveSession_Unknown(t *testing.T) {
+	dir := initRepo(t)
+	if _, err := gitops.ResolveSession(dir, "nope"); err == nil {
+		t.Error("expected error for unknown session")
+	}
+	if _, err := gitops.ResolveSession(dir, gitops.ResumeLatest); err == nil {
+		t.Error("expected error when there are no sessions")
+	}
+}
@@ -0,0 +1,95 @@
+package gitops
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Change is a path changed between two commits, relative to the root.
+type Change struct {
+	Status byte // 'A', 'M' or 'D'
+	Path   string
+}
+
+// Head returns the commit currently checked out.
+func (g *GitOps) Head() (string, error) {
+	return revParse(g.root, "HEAD")
+}
+
+// Rollback restores paths (or, with none, the whole workspace) to how they
+// were at commit, which must be one of the session's commits or the one it
+// started from. Pending work
+// is checkpointed first so the rollback itself can be undone, and the result
+// is committed. It returns the rollback commit (empty if nothing changed)
+// and the paths that changed on disk.
+func (g *GitOps) Rollback(commit string, paths []string) (string, []Change, error) {
+	target, err := g.sessionCommit(commit)
+	if err != nil {
+		return "", nil, err
+	}
+
+	specs := []string{"."}
+	if len(paths) > 0 {
+		specs = specs[:0]
+		for _, p := range paths {
+			clean, err := cleanPath(p)
+			if err != nil {
+				return "", nil, err
+			}
+			specs = append(specs, clean)
+		}
+	}
+
+	if err := g.Checkpoint("mob: checkpoint before rollback"); err != nil {
+		return "", nil, fmt.Errorf("checkpoint: %w", err)
+	}
+	before, err := g.Head()
+	if err != nil {
+		return "", nil, err
+	}
+
+	// restore also deletes files that didn't exist at the target
+	g.commitMu.Lock()
+	out, err := runGit(g.root, append([]string{"restore", "--source=" + target, "--staged", "--worktree", "--"}, specs...)...)
+	g.commitMu.Unlock()
+	if err != nil {
+		return "", nil, fmt.Errorf("git restore: %w: %s", err, strings.TrimSpace(out))
+	}
+
+	what := "workspace"
+	if len(paths) > 0 {
+		what = strings.Join(specs, ", ")
+	}
+	subject, _ := runGit(g.root, "log", "-1", "--format=%s", target)
+	msg := fmt.Sprintf("mob: roll back %s to %s (%s)", what, short(target), strings.TrimSpace(subject))
+	if err := g.commit(msg, nil); err != nil {
+		return "", nil, err
+	}
+
+	after, err := g.Head()
+	if err != nil || after == before {
+		return "", nil, err
+	}
+	changes, err := g.changesBetween(before, after)
+	if err != nil {
+		return after, nil, err
+	}
+	g.log.Info().Str("to", short(target)).Str("what", what).Int("files", len(changes)).Msg("Rolled back")
+	return after, changes, nil
+}
+
+func (g *GitOps) changesBetween(from, to string) ([]Change, error) {
+	out, err := runGit(g.root, "diff", "--name-status", "--no-renames", "--relative", "-z", from, to)
+	if err != nil {
+		return nil, fmt.Errorf("diff: %w", err)
+	}
+	fields := strings.Split(strings.TrimSuffix(out, "\x00"), "\x00")
+	var changes []Change
+	for i := 0; i+1 < len(fields); i += 2 {
+		if fields[i] == "" {
+			continue
+		}
+		changes = append(changes, Change{Status: fields[i][0], Path: fields[i+1]})
+	}
+	return changes, nil
+}
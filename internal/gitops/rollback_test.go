@@ -0,0 +1,71 @@
+package gitops_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/victorarias/blue-guy/internal/gitops"
+)
+
+func TestRollback_SinglePath(t *testing.T) {
+	dir := initRepo(t)
+	g := startSession(t, dir, "abc")
+	defer g.Stop()
+
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a1\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "b.txt"), []byte("b1\n"), 0644)
+	g.Checkpoint("first")
+	first, _ := g.Head()
+
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a2\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "b.txt"), []byte("b2\n"), 0644)
+
+	commit, changes, err := g.Rollback(first, []string{"/a.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "a.txt")); string(data) != "a1\n" {
+		t.Errorf("a.txt not rolled back: %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "b.txt")); string(data) != "b2\n" {
+		t.Errorf("b.txt should be untouched: %q", data)
+	}
+	if len(changes) != 1 || changes[0].Path != "a.txt" || changes[0].Status != 'M' {
+		t.Errorf("unexpected changes %+v", changes)
+	}
+
+	// The work that was rolled back is still in history
+	msgs := git(t, dir, "log", "--format=%s", "-3", commit)
+	if !strings.Contains(msgs, "mob: roll back a.txt to") || !strings.Contains(msgs, "mob: checkpoint before rollback") {
+		t.Errorf("unexpected history:\n%s", msgs)
+	}
+	if got := strings.TrimSpace(git(t, dir, "status", "--porcelain")); got != "" {
+		t.Errorf("expected a clean tree after rollback, got %q", got)
+	}
+}
+
+func TestRollback_RejectsCommitsOutsideTheSession(t *testing.T) {
+	dir := initRepo(t)
+	git(t, dir, "checkout", "-q", "-b", "elsewhere")
+	git(t, dir, "commit", "-q", "--allow-empty", "-m", "unrelated")
+	other := strings.TrimSpace(git(t, dir, "rev-parse", "HEAD"))
+	git(t, dir, "checkout", "-q", "main")
+	// An ancestor of the session, but from the host's history before it
+	git(t, dir, "commit", "-q", "--allow-empty", "-m", "last before the session")
+
+	g := startSession(t, dir, "abc")
+	defer g.Stop()
+
+	for _, rev := range []string{other, "HEAD~1", "nope", "--hard"} {
+		if _, _, err := g.Rollback(rev, nil); !errors.Is(err, gitops.ErrInvalidArgument) {
+			t.Errorf("Rollback(%q): expected ErrInvalidArgument, got %v", rev, err)
+		}
+	}
+	// The start itself is fine: that undoes the whole session
+	if _, _, err := g.Rollback("HEAD", nil); err != nil {
+		t.Errorf("expected rolling back to the start to work, got %v", err)
+	}
+}
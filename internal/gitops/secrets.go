@@ -0,0 +1,302 @@
+package gitops
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// SecretMode says what happens when staged changes look like they contain
+// credentials.
+type SecretMode string
+
+const (
+	SecretsBlock    SecretMode = "block"     // don't commit until they're gone
+	SecretsHoldPush SecretMode = "hold-push" // commit, but stop pushing
+	SecretsOff      SecretMode = "off"
+)
+
+// allowSecretMarker on a line exempts it from scanning, for test fixtures
+// and documentation that need key-shaped strings.
+const allowSecretMarker = "mob:allow-secret"
+
+// ParseSecretMode parses "block", "hold-push" or "off". Empty means block.
+func ParseSecretMode(s string) (SecretMode, error) {
+	switch SecretMode(s) {
+	case "":
+		return SecretsBlock, nil
+	case SecretsBlock, SecretsHoldPush, SecretsOff:
+		return SecretMode(s), nil
+	default:
+		return "", fmt.Errorf("unknown secrets mode %q (want %s, %s or %s)", s, SecretsBlock, SecretsHoldPush, SecretsOff)
+	}
+}
+
+// SecretOptions configures scanning. The zero value scans with the built-in
+// rules and blocks commits that trip them.
+type SecretOptions struct {
+	Mode SecretMode
+	// AllowPaths are globs (path.Match syntax, against the path from the
+	// root or the file name) for files that aren't scanned. A trailing
+	// slash matches a whole directory.
+	AllowPaths []string
+	// AllowValues are regular expressions; a match covering a finding's
+	// text lets it through.
+	AllowValues []string
+}
+
+// Finding is a possible secret in a staged line. The secret itself is only
+// kept redacted, since findings are logged and sent to clients.
+type Finding struct {
+	Path     string
+	Line     int
+	Rule     string
+	Redacted string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s:%d (%s %s)", f.Path, f.Line, f.Rule, f.Redacted)
+}
+
+// SecretReport is sent to OnSecrets listeners when a commit trips the scanner.
+type SecretReport struct {
+	Mode     SecretMode // what was done about it
+	Findings []Finding
+	Commit   string // the commit made anyway, under SecretsHoldPush
+}
+
+// SecretsError is returned by commits blocked under SecretsBlock.
+type SecretsError struct {
+	Findings []Finding
+}
+
+func (e *SecretsError) Error() string {
+	var b strings.Builder
+	b.WriteString("commit blocked, possible secrets in ")
+	for i, f := range e.Findings {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(f.String())
+	}
+	return b.String()
+}
+
+// findingPaths lists the files with findings, each once.
+func findingPaths(findings []Finding) string {
+	var paths []string
+	for _, f := range findings {
+		if !slices.Contains(paths, f.Path) {
+			paths = append(paths, f.Path)
+		}
+	}
+	return strings.Join(paths, ", ")
+}
+
+type secretRule struct {
+	name string
+	re   *regexp.Regexp
+	// group is the submatch holding the secret; 0 is the whole match
+	group int
+}
+
+var secretRules = []secretRule{
+	{name: "aws-access-key", re: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{name: "github-token", re: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})`)},
+	{name: "gitlab-token", re: regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}`)},
+	{name: "slack-token", re: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{name: "stripe-key", re: regexp.MustCompile(`\b[rs]k_live_[A-Za-z0-9]{20,}`)},
+	{name: "google-api-key", re: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}`)},
+	{name: "api-key", re: regexp.MustCompile(`\bsk-(?:ant-|proj-)?[A-Za-z0-9_-]{32,}`)},
+	{name: "private-key", re: regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY-----`)},
+	{name: "jwt", re: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+	{
+		name:  "password-assignment",
+		re:    regexp.MustCompile(`(?i)(?:password|passwd|secret|api_?key|access_?token|auth_?token)["']?\s*[:=]\s*["']([^"'\s]{8,})["']`),
+		group: 1,
+	},
+}
+
+// High-entropy quoted strings: long, mixed-case alphanumerics with enough
+// randomness that they're unlikely to be words, paths or hex hashes.
+var (
+	quotedToken     = regexp.MustCompile(`["'\x60]([A-Za-z0-9+/_=.-]{24,})["'\x60]`)
+	minTokenEntropy = 4.2 // bits per character
+)
+
+// Lock files are full of integrity hashes that look random by design.
+var lockFiles = map[string]bool{
+	"go.sum": true, "package-lock.json": true, "yarn.lock": true, "pnpm-lock.yaml": true,
+	"Cargo.lock": true, "poetry.lock": true, "Gemfile.lock": true, "composer.lock": true,
+}
+
+// secretScanner applies the rules and allowlists to added lines.
+type secretScanner struct {
+	allowPaths  []string
+	allowValues []*regexp.Regexp
+}
+
+func newSecretScanner(opts SecretOptions) (*secretScanner, error) {
+	s := &secretScanner{allowPaths: opts.AllowPaths}
+	for _, v := range opts.AllowValues {
+		re, err := regexp.Compile(v)
+		if err != nil {
+			return nil, fmt.Errorf("secret allowlist %q: %w", v, err)
+		}
+		s.allowValues = append(s.allowValues, re)
+	}
+	return s, nil
+}
+
+func (s *secretScanner) scan(lines []addedLine) []Finding {
+	var findings []Finding
+	for _, l := range lines {
+		if s.pathAllowed(l.path) || strings.Contains(l.text, allowSecretMarker) {
+			continue
+		}
+		findings = append(findings, s.scanLine(l)...)
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Path != findings[j].Path {
+			return findings[i].Path < findings[j].Path
+		}
+		return findings[i].Line < findings[j].Line
+	})
+	return findings
+}
+
+func (s *secretScanner) scanLine(l addedLine) []Finding {
+	var findings []Finding
+	found := func(rule, secret string) {
+		if s.valueAllowed(secret) {
+			return
+		}
+		findings = append(findings, Finding{Path: l.path, Line: l.line, Rule: rule, Redacted: redact(secret)})
+	}
+
+	for _, r := range secretRules {
+		for _, m := range r.re.FindAllStringSubmatch(l.text, -1) {
+			found(r.name, m[r.group])
+		}
+	}
+	if len(findings) > 0 || lockFiles[path.Base(l.path)] {
+		return findings
+	}
+	for _, m := range quotedToken.FindAllStringSubmatch(l.text, -1) {
+		if looksRandom(m[1]) {
+			found("high-entropy-string", m[1])
+		}
+	}
+	return findings
+}
+
+func (s *secretScanner) pathAllowed(p string) bool {
+	for _, pattern := range s.allowPaths {
+		if dir, ok := strings.CutSuffix(pattern, "/"); ok {
+			if strings.HasPrefix(p, dir+"/") {
+				return true
+			}
+			continue
+		}
+		if ok, _ := path.Match(pattern, p); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, path.Base(p)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *secretScanner) valueAllowed(secret string) bool {
+	for _, re := range s.allowValues {
+		if re.MatchString(secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// looksRandom reports whether a token has upper and lower case letters and
+// digits, and high Shannon entropy.
+func looksRandom(tok string) bool {
+	var upper, lower, digit bool
+	counts := make(map[rune]int)
+	for _, c := range tok {
+		counts[c]++
+		switch {
+		case c >= 'A' && c <= 'Z':
+			upper = true
+		case c >= 'a' && c <= 'z':
+			lower = true
+		case c >= '0' && c <= '9':
+			digit = true
+		}
+	}
+	if !upper || !lower || !digit {
+		return false
+	}
+	var entropy float64
+	n := float64(len(tok))
+	for _, c := range counts {
+		p := float64(c) / n
+		entropy -= p * math.Log2(p)
+	}
+	return entropy >= minTokenEntropy
+}
+
+// redact keeps just enough of a secret to recognise it.
+func redact(secret string) string {
+	if len(secret) <= 8 {
+		return "****"
+	}
+	return secret[:4] + "****"
+}
+
+// OnSecrets registers fn to be called when a commit is blocked, or pushing
+// held, because of possible secrets. The same findings are reported once.
+func (g *GitOps) OnSecrets(fn func(SecretReport)) {
+	g.secretsMu.Lock()
+	defer g.secretsMu.Unlock()
+	g.secretsListen = fn
+}
+
+func (g *GitOps) scanSecrets() ([]Finding, error) {
+	if g.secrets == nil {
+		return nil, nil
+	}
+	lines, err := g.repo.StagedLines()
+	if err != nil {
+		return nil, fmt.Errorf("scan for secrets: %w", err)
+	}
+	return g.secrets.scan(lines), nil
+}
+
+// reportSecrets tells the listener about findings it hasn't heard about yet.
+// An empty report just clears the record, so reappearing findings are
+// reported again.
+func (g *GitOps) reportSecrets(r SecretReport) {
+	key := fmt.Sprint(r.Mode, r.Findings)
+	g.secretsMu.Lock()
+	if len(r.Findings) == 0 || key == g.lastSecrets {
+		if len(r.Findings) == 0 {
+			g.lastSecrets = ""
+		}
+		g.secretsMu.Unlock()
+		return
+	}
+	g.lastSecrets = key
+	fn := g.secretsListen
+	g.secretsMu.Unlock()
+
+	for _, f := range r.Findings {
+		g.log.Warn().Str("path", f.Path).Int("line", f.Line).Str("rule", f.Rule).Msg("Possible secret")
+	}
+	if fn != nil {
+		fn(r)
+	}
+}
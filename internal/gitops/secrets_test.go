@@ -0,0 +1,170 @@
+package gitops_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/victorarias/blue-guy/internal/gitops"
+)
+
+// Assembled at runtime so this file doesn't trip secret scanners itself.
+var (
+	awsKey   = "AKIA" + "Z7QXW2RTYPLMK3VB"
+	randomID = "q8Zr2LwX9" + "vTb4NcY7mKp3HsJ6dFa"
+)
+
+type secretLog struct {
+	mu      sync.Mutex
+	reports []gitops.SecretReport
+}
+
+func (l *secretLog) record(r gitops.SecretReport) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.reports = append(l.reports, r)
+}
+
+func (l *secretLog) count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.reports)
+}
+
+func TestParseSecretMode(t *testing.T) {
+	for in, want := range map[string]gitops.SecretMode{
+		"": gitops.SecretsBlock, "block": gitops.SecretsBlock,
+		"hold-push": gitops.SecretsHoldPush, "off": gitops.SecretsOff,
+	} {
+		if got, err := gitops.ParseSecretMode(in); err != nil || got != want {
+			t.Errorf("ParseSecretMode(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := gitops.ParseSecretMode("warn"); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
+
+func TestSecrets_BlockCommitUntilRemoved(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, opts gitops.Options) {
+		dir := initRepo(t)
+		g := startSessionWith(t, dir, "abc", opts)
+		defer g.Stop()
+		var log secretLog
+		g.OnSecrets(log.record)
+		before, _ := g.Head()
+
+		os.WriteFile(filepath.Join(dir, "config.py"), []byte("region = 'eu'\naws_key = '"+awsKey+"'\n"), 0644)
+		err := g.Checkpoint("add config")
+		var se *gitops.SecretsError
+		if !errors.As(err, &se) {
+			t.Fatalf("expected a SecretsError, got %v", err)
+		}
+		f := se.Findings[0]
+		if len(se.Findings) != 1 || f.Path != "config.py" || f.Line != 2 || f.Rule != "aws-access-key" {
+			t.Errorf("unexpected findings %v", se.Findings)
+		}
+		if strings.Contains(err.Error(), awsKey) {
+			t.Error("the error shouldn't contain the secret")
+		}
+		if head, _ := g.Head(); head != before {
+			t.Error("expected nothing committed")
+		}
+		if out := git(t, dir, "diff", "--cached", "--name-only"); out != "" {
+			t.Errorf("expected the index to be reset, got %q", out)
+		}
+
+		// Retrying doesn't report the same thing twice
+		g.Checkpoint("add config")
+		if log.count() != 1 {
+			t.Errorf("expected one report, got %d", log.count())
+		}
+
+		os.WriteFile(filepath.Join(dir, "config.py"), []byte("region = 'eu'\naws_key = os.environ['AWS_KEY']\n"), 0644)
+		if err := g.Checkpoint("add config"); err != nil {
+			t.Fatal(err)
+		}
+		if head, _ := g.Head(); head == before {
+			t.Error("expected the clean change to be committed")
+		}
+	})
+}
+
+func TestSecrets_HoldPush(t *testing.T) {
+	dir := initRepo(t)
+	remote := withRemote(t, dir, "origin")
+	g := startSessionWith(t, dir, "abc", gitops.Options{Secrets: gitops.SecretOptions{Mode: gitops.SecretsHoldPush}})
+	var log secretLog
+	g.OnSecrets(log.record)
+	// The hold has to be in place before the commit exists for a push to
+	// pick up
+	before, _ := g.Head()
+	var heldAt string
+	g.OnPushState(func(st gitops.PushState) {
+		if st.Held != "" && heldAt == "" {
+			heldAt, _ = g.Head()
+		}
+	})
+
+	os.WriteFile(filepath.Join(dir, "token.txt"), []byte("id: \""+randomID+"\"\n"), 0644)
+	if err := g.Checkpoint("add token"); err != nil {
+		t.Fatal(err)
+	}
+	if st := g.PushState(); !strings.Contains(st.Held, "token.txt") {
+		t.Errorf("expected pushing to be held, got %+v", st)
+	}
+	if heldAt != before {
+		t.Error("expected pushing held before the commit was made")
+	}
+	if log.count() != 1 || log.reports[0].Commit == "" || log.reports[0].Findings[0].Rule != "high-entropy-string" {
+		t.Errorf("unexpected reports %+v", log.reports)
+	}
+
+	g.Stop()
+	if remoteHas(remote, "mob/session-abc") {
+		t.Error("expected nothing pushed while held")
+	}
+}
+
+func TestSecrets_Allowlists(t *testing.T) {
+	dir := initRepo(t)
+	g := startSessionWith(t, dir, "abc", gitops.Options{Secrets: gitops.SecretOptions{
+		AllowPaths:  []string{"testdata/", "*.example"},
+		AllowValues: []string{"^AKIA.*VB$"},
+	}})
+	defer g.Stop()
+
+	os.MkdirAll(filepath.Join(dir, "testdata"), 0755)
+	os.WriteFile(filepath.Join(dir, "testdata", "fixture.txt"), []byte("ghp_"+strings.Repeat("a1B2", 10)+"\n"), 0644)
+	os.WriteFile(filepath.Join(dir, ".env.example"), []byte("TOKEN=\""+randomID+"\"\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "aws.go"), []byte("const key = \""+awsKey+"\"\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "doc.md"), []byte("`"+randomID+"` mob:allow-secret\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "go.sum"), []byte("example.com/x v1.0.0 h1:\""+randomID+"\"\n"), 0644)
+
+	if err := g.Checkpoint("fixtures"); err != nil {
+		t.Errorf("expected allowlisted content to be committed, got %v", err)
+	}
+}
+
+func TestSecrets_Off(t *testing.T) {
+	dir := initRepo(t)
+	g := startSessionWith(t, dir, "abc", gitops.Options{Secrets: gitops.SecretOptions{Mode: gitops.SecretsOff}})
+	defer g.Stop()
+
+	os.WriteFile(filepath.Join(dir, "key.txt"), []byte(awsKey+"\n"), 0644)
+	if err := g.Checkpoint("key"); err != nil {
+		t.Errorf("expected no scanning, got %v", err)
+	}
+}
+
+func TestSecrets_BadAllowlistIsRejected(t *testing.T) {
+	dir := initRepo(t)
+	_, err := gitops.New(dir, "abc", gitops.Options{Secrets: gitops.SecretOptions{AllowValues: []string{"("}}}, zerolog.Nop())
+	if err == nil {
+		t.Error("expected an invalid allowlist pattern to be rejected")
+	}
+}
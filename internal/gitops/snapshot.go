@@ -0,0 +1,314 @@
+package gitops
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// SnapshotStart names the commit the session branched from.
+	SnapshotStart = "start"
+
+	maxSnapshotRead = 1 << 20 // bytes returned by one SnapshotRead
+)
+
+// ErrNotFound marks a snapshot or path that isn't in the session history.
+var ErrNotFound = errors.New("not found")
+
+var hashName = regexp.MustCompile(`^[0-9a-f]{4,40}$`)
+
+// Time formats accepted as snapshot names, tried in order. The clock-only
+// ones mean today, in the host's time zone.
+var snapshotTimeFormats = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+	"15:04:05",
+	"15:04",
+}
+
+// Snapshot is a commit whose files can be browsed read-only.
+type Snapshot struct {
+	Commit string
+	Time   time.Time // commit time
+}
+
+// TreeEntry is a file or directory as it was in a snapshot. Modes are
+// read-only; symlinks appear as files holding their target.
+type TreeEntry struct {
+	Name string
+	Mode os.FileMode
+	Size int64
+}
+
+// blobCache keeps the last blob read, since FUSE reads a file in many
+// small chunks.
+type blobCache struct {
+	mu   sync.Mutex
+	hash string
+	data []byte
+}
+
+// snapshotCache keeps what FUSE keeps asking about: which commit a name
+// means, and the last few trees listed.
+type snapshotCache struct {
+	mu    sync.Mutex
+	names map[string]Snapshot // hashes and "start"; times can move as commits land
+	trees map[string]*snapshotTree
+	order []string // commits in trees, oldest first
+}
+
+const (
+	maxCachedNames = 1024
+	maxCachedTrees = 4
+)
+
+// ResolveSnapshot maps a name to one of the session's commits, or the one
+// it started from. The name is a (possibly abbreviated) commit hash,
+// "start", or a time such as 2024-05-01T14:30 meaning the last commit made
+// at or before it.
+func (g *GitOps) ResolveSnapshot(name string) (Snapshot, error) {
+	c := &g.snapshots
+	c.mu.Lock()
+	s, ok := c.names[name]
+	c.mu.Unlock()
+	if ok {
+		return s, nil
+	}
+	s, err := g.resolveSnapshot(name)
+	if err != nil || (name != SnapshotStart && !hashName.MatchString(name)) {
+		return s, err
+	}
+	c.mu.Lock()
+	if c.names == nil || len(c.names) == maxCachedNames {
+		c.names = make(map[string]Snapshot)
+	}
+	c.names[name] = s
+	c.mu.Unlock()
+	return s, nil
+}
+
+func (g *GitOps) resolveSnapshot(name string) (Snapshot, error) {
+	if name == SnapshotStart {
+		base, err := g.sessionBase()
+		if err != nil {
+			return Snapshot{}, err
+		}
+		return g.snapshot(base)
+	}
+
+	if hashName.MatchString(name) {
+		// Only commits the session can see, not anything in the object store
+		commit, err := g.sessionCommit(name)
+		if err != nil {
+			return Snapshot{}, fmt.Errorf("%w: %s is not on %s", ErrNotFound, name, g.branch)
+		}
+		return g.snapshot(commit)
+	}
+
+	t, err := parseSnapshotTime(name, g.clock.Now())
+	if err != nil {
+		return Snapshot{}, err
+	}
+	out, err := runGit(g.root, "rev-list", "-1", "--before=@"+strconv.FormatInt(t.Unix(), 10), g.branch)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("git rev-list: %w: %s", err, strings.TrimSpace(out))
+	}
+	commit := strings.TrimSpace(out)
+	if commit == "" {
+		return Snapshot{}, fmt.Errorf("%w: no commit before %s", ErrNotFound, name)
+	}
+	if commit, err = g.sessionCommit(commit); err != nil {
+		return Snapshot{}, fmt.Errorf("%w: no session commit before %s", ErrNotFound, name)
+	}
+	return g.snapshot(commit)
+}
+
+func (g *GitOps) snapshot(rev string) (Snapshot, error) {
+	out, err := runGit(g.root, "log", "-1", "--format=%H %ct", rev, "--")
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("%w: commit %s", ErrNotFound, rev)
+	}
+	hash, ct, _ := strings.Cut(strings.TrimSpace(out), " ")
+	secs, _ := strconv.ParseInt(ct, 10, 64)
+	return Snapshot{Commit: hash, Time: time.Unix(secs, 0)}, nil
+}
+
+func parseSnapshotTime(name string, now time.Time) (time.Time, error) {
+	for _, layout := range snapshotTimeFormats {
+		t, err := time.ParseInLocation(layout, name, now.Location())
+		if err != nil {
+			continue
+		}
+		if !strings.Contains(layout, "2006") {
+			y, m, d := now.Date()
+			t = time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), 0, now.Location())
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%w: %q is neither a commit nor a time", ErrInvalidArgument, name)
+}
+
+// SnapshotStat describes file at commit. An empty file or "." is the root.
+func (g *GitOps) SnapshotStat(commit, file string) (TreeEntry, error) {
+	clean, err := cleanPath(file)
+	if err != nil {
+		return TreeEntry{}, err
+	}
+	if clean == "." {
+		return TreeEntry{Name: ".", Mode: os.ModeDir | 0555}, nil
+	}
+	tree, err := g.tree(commit)
+	if err != nil {
+		return TreeEntry{}, err
+	}
+	item, ok := tree.items[clean]
+	if !ok {
+		return TreeEntry{}, fmt.Errorf("%w: %s", ErrNotFound, clean)
+	}
+	return item.entry, nil
+}
+
+// SnapshotList lists the directory dir at commit.
+func (g *GitOps) SnapshotList(commit, dir string) ([]TreeEntry, error) {
+	st, err := g.SnapshotStat(commit, dir)
+	if err != nil {
+		return nil, err
+	}
+	if !st.Mode.IsDir() {
+		return nil, fmt.Errorf("%w: %s is not a directory", ErrInvalidArgument, dir)
+	}
+	tree, err := g.tree(commit)
+	if err != nil {
+		return nil, err
+	}
+	clean, _ := cleanPath(dir)
+	return tree.dirs[clean], nil
+}
+
+// SnapshotRead reads up to length bytes (at most 1MB) of file at commit,
+// starting at offset.
+func (g *GitOps) SnapshotRead(commit, file string, offset, length int64) ([]byte, error) {
+	clean, err := cleanPath(file)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := g.tree(commit)
+	if err != nil {
+		return nil, err
+	}
+	item, ok := tree.items[clean]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, clean)
+	}
+	if item.entry.Mode.IsDir() {
+		return nil, fmt.Errorf("%w: %s is a directory", ErrInvalidArgument, clean)
+	}
+
+	data, err := g.blob(item.hash)
+	if err != nil {
+		return nil, err
+	}
+	if length <= 0 || length > maxSnapshotRead {
+		length = maxSnapshotRead
+	}
+	if offset < 0 || offset >= int64(len(data)) {
+		return nil, nil
+	}
+	return data[offset:min(offset+length, int64(len(data)))], nil
+}
+
+func (g *GitOps) blob(hash string) ([]byte, error) {
+	g.blobs.mu.Lock()
+	defer g.blobs.mu.Unlock()
+	if g.blobs.hash == hash {
+		return g.blobs.data, nil
+	}
+	out, err := runGit(g.root, "cat-file", "blob", hash)
+	if err != nil {
+		return nil, fmt.Errorf("git cat-file: %w: %s", err, strings.TrimSpace(out))
+	}
+	g.blobs.hash, g.blobs.data = hash, []byte(out)
+	return g.blobs.data, nil
+}
+
+// snapshotTree is every file and directory of a snapshot, by path
+// relative to the root.
+type snapshotTree struct {
+	items map[string]treeItem
+	dirs  map[string][]TreeEntry // directory contents, "." for the root
+}
+
+type treeItem struct {
+	entry TreeEntry
+	hash  string
+}
+
+// tree returns commit's tree, listing it with a single `git ls-tree` the
+// first time it's asked for. FUSE stats the same snapshot paths over and
+// over, and commits don't change.
+func (g *GitOps) tree(commit string) (*snapshotTree, error) {
+	c := &g.snapshots
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if t, ok := c.trees[commit]; ok {
+		return t, nil
+	}
+	t, err := g.lsTree(commit)
+	if err != nil {
+		return nil, err
+	}
+	if c.trees == nil {
+		c.trees = make(map[string]*snapshotTree)
+	}
+	if len(c.order) == maxCachedTrees {
+		delete(c.trees, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.trees[commit] = t
+	c.order = append(c.order, commit)
+	return t, nil
+}
+
+// lsTree runs `git ls-tree -r` from the root, so paths are relative to it
+// and nothing outside it shows up.
+func (g *GitOps) lsTree(commit string) (*snapshotTree, error) {
+	if err := checkRev(commit); err != nil {
+		return nil, err
+	}
+	out, err := runGit(g.root, "ls-tree", "-r", "-t", "-l", "-z", commit)
+	if err != nil {
+		return nil, fmt.Errorf("git ls-tree: %w: %s", err, strings.TrimSpace(out))
+	}
+
+	// "<mode> <type> <hash> <size>\t<path>\0", size "-" for trees
+	t := &snapshotTree{items: make(map[string]treeItem), dirs: make(map[string][]TreeEntry)}
+	for _, rec := range strings.Split(strings.TrimSuffix(out, "\x00"), "\x00") {
+		meta, name, ok := strings.Cut(rec, "\t")
+		f := strings.Fields(meta)
+		// From a subdirectory -t lists the root's own tree too, as "./"
+		if !ok || len(f) != 4 || name == "./" {
+			continue
+		}
+		e := TreeEntry{Name: path.Base(name), Mode: 0444}
+		switch f[0] {
+		case "040000", "160000": // submodules show as empty directories
+			e.Mode = os.ModeDir | 0555
+		case "100755":
+			e.Mode = 0555
+		}
+		e.Size, _ = strconv.ParseInt(f[3], 10, 64)
+		t.items[name] = treeItem{entry: e, hash: f[2]}
+		dir := path.Dir(name)
+		t.dirs[dir] = append(t.dirs[dir], e)
+	}
+	return t, nil
+}
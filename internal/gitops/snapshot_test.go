@@ -0,0 +1,157 @@
+package gitops_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/victorarias/blue-guy/internal/gitops"
+)
+
+func TestSnapshot_ReadsFilesAsTheyWere(t *testing.T) {
+	dir := initRepo(t)
+	g := startSession(t, dir, "abc")
+	defer g.Stop()
+
+	os.MkdirAll(filepath.Join(dir, "src"), 0755)
+	os.WriteFile(filepath.Join(dir, "src", "main.go"), []byte("package main\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "run.sh"), []byte("#!/bin/sh\n"), 0755)
+	if err := g.Checkpoint("first"); err != nil {
+		t.Fatal(err)
+	}
+	first, _ := g.Head()
+	os.WriteFile(filepath.Join(dir, "src", "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644)
+	if err := g.Checkpoint("second"); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := g.ResolveSnapshot(first[:12])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Commit != first || s.Time.IsZero() {
+		t.Errorf("unexpected snapshot %+v", s)
+	}
+
+	entries, err := g.SnapshotList(s.Commit, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	modes := map[string]os.FileMode{}
+	for _, e := range entries {
+		modes[e.Name] = e.Mode
+	}
+	if len(modes) != 3 || !modes["src"].IsDir() || modes["run.sh"] != 0555 || modes["README.md"] != 0444 {
+		t.Errorf("unexpected root listing %v", modes)
+	}
+
+	st, err := g.SnapshotStat(s.Commit, "src/main.go")
+	if err != nil || st.Size != int64(len("package main\n")) {
+		t.Errorf("unexpected stat %+v, %v", st, err)
+	}
+	data, err := g.SnapshotRead(s.Commit, "/src/main.go", 8, 100)
+	if err != nil || string(data) != "main\n" {
+		t.Errorf("expected the first version's tail, got %q, %v", data, err)
+	}
+	if data, _ := g.SnapshotRead(s.Commit, "src/main.go", 100, 10); len(data) != 0 {
+		t.Errorf("expected nothing past the end, got %q", data)
+	}
+
+	start, err := g.ResolveSnapshot(gitops.SnapshotStart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.SnapshotStat(start.Commit, "src"); !errors.Is(err, gitops.ErrNotFound) {
+		t.Errorf("src shouldn't exist at the start, got %v", err)
+	}
+}
+
+func TestSnapshot_ResolvesTimes(t *testing.T) {
+	dir := initRepo(t)
+	g := startSession(t, dir, "abc")
+	defer g.Stop()
+
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a\n"), 0644)
+	if err := g.Checkpoint("add a"); err != nil {
+		t.Fatal(err)
+	}
+	head, _ := g.Head()
+
+	s, err := g.ResolveSnapshot("2999-01-01T00:00")
+	if err != nil || s.Commit != head {
+		t.Errorf("expected a future time to resolve to HEAD, got %+v, %v", s, err)
+	}
+	if _, err := g.ResolveSnapshot("2000-01-01"); !errors.Is(err, gitops.ErrNotFound) {
+		t.Errorf("expected no commit before 2000, got %v", err)
+	}
+	if _, err := g.ResolveSnapshot("yesterday"); !errors.Is(err, gitops.ErrInvalidArgument) {
+		t.Errorf("expected an unparseable name to be rejected, got %v", err)
+	}
+}
+
+func TestSnapshot_OnlySessionCommits(t *testing.T) {
+	dir := initRepo(t)
+	git(t, dir, "checkout", "-q", "-b", "elsewhere")
+	git(t, dir, "commit", "-q", "--allow-empty", "-m", "unrelated")
+	other := strings.TrimSpace(git(t, dir, "rev-parse", "HEAD"))
+	git(t, dir, "checkout", "-q", "main")
+	initial := strings.TrimSpace(git(t, dir, "rev-parse", "HEAD"))
+	git(t, dir, "commit", "-q", "--allow-empty", "-m", "last before the session")
+
+	g := startSession(t, dir, "abc")
+	defer g.Stop()
+
+	// The host's history before the session is off limits too
+	for _, name := range []string{other, initial, initial[:8], "deadbeef"} {
+		if _, err := g.ResolveSnapshot(name); !errors.Is(err, gitops.ErrNotFound) {
+			t.Errorf("ResolveSnapshot(%q): expected ErrNotFound, got %v", name, err)
+		}
+	}
+	head, _ := g.Head()
+	if _, err := g.SnapshotRead(head, ".git/config", 0, 0); !errors.Is(err, gitops.ErrInvalidArgument) {
+		t.Errorf("expected .git to be off limits, got %v", err)
+	}
+}
+
+func TestSnapshot_ExportedSubtree(t *testing.T) {
+	dir := initRepo(t)
+	os.MkdirAll(filepath.Join(dir, "app", "src"), 0755)
+	os.WriteFile(filepath.Join(dir, "app", "src", "main.go"), []byte("package main\n"), 0644)
+	git(t, dir, "add", "-A")
+	git(t, dir, "commit", "-q", "-m", "app")
+
+	g := startSessionWith(t, filepath.Join(dir, "app"), "abc", gitops.Options{})
+	defer g.Stop()
+	os.WriteFile(filepath.Join(dir, "app", "notes.txt"), []byte("notes\n"), 0644)
+	if err := g.Checkpoint("notes"); err != nil {
+		t.Fatal(err)
+	}
+	head, _ := g.Head()
+	s, err := g.ResolveSnapshot(head[:12])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Twice, the second time from the cache
+	for range 2 {
+		entries, err := g.SnapshotList(s.Commit, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name)
+		}
+		if strings.Join(names, ",") != "notes.txt,src" {
+			t.Errorf("expected only the exported directory, got %v", names)
+		}
+		if data, err := g.SnapshotRead(s.Commit, "src/main.go", 0, 0); err != nil || string(data) != "package main\n" {
+			t.Errorf("unexpected read %q, %v", data, err)
+		}
+		if _, err := g.SnapshotStat(s.Commit, "README.md"); !errors.Is(err, gitops.ErrNotFound) {
+			t.Errorf("expected files outside the export to be missing, got %v", err)
+		}
+	}
+}
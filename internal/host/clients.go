@@ -0,0 +1,300 @@
+package host
+
+import (
+	"context"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/victorarias/blue-guy/internal/clock"
+	"github.com/victorarias/blue-guy/internal/identity"
+	pb "github.com/victorarias/blue-guy/internal/proto/gen"
+	"github.com/victorarias/blue-guy/internal/workspace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
+)
+
+// kickGrace is how long a kicked client's connection stays open so it
+// can learn why before it's closed.
+const kickGrace = time.Second
+
+// ClientTracker keeps a list of connected clients for the control socket.
+// It is a gRPC stats handler: connections are added when they open and
+// dropped when they close, and requests record who is on them. Its
+// interceptors keep kicked clients out.
+type ClientTracker struct {
+	clock  clock.Clock
+	mu     sync.Mutex
+	nextID uint64
+	conns  map[uint64]*trackedConn
+	raw    map[string]net.Conn // accepted connections by remote address
+	banned map[string]string   // identity or remote host to kick reason
+	// changed is closed and replaced whenever a client connects or
+	// disconnects
+	changed chan struct{}
+}
+
+type trackedConn struct {
+	id        identity.Identity
+	addr      string
+	workspace string
+	connected time.Time
+	lastSeen  time.Time
+	raw       net.Conn
+	cancel    context.CancelFunc // ends every request on the connection
+}
+
+type connKey struct{}
+
+func NewClientTracker(clk clock.Clock) *ClientTracker {
+	return &ClientTracker{
+		clock:   clock.Or(clk),
+		conns:   make(map[uint64]*trackedConn),
+		raw:     make(map[string]net.Conn),
+		banned:  make(map[string]string),
+		changed: make(chan struct{}),
+	}
+}
+
+// Changed returns a channel that's closed the next time a client connects
+// or disconnects.
+func (t *ClientTracker) Changed() <-chan struct{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.changed
+}
+
+func (t *ClientTracker) notifyLocked() {
+	close(t.changed)
+	t.changed = make(chan struct{})
+}
+
+// Listener wraps the host's listener so the tracker can close connections
+// it kicks.
+func (t *ClientTracker) Listener(lis net.Listener) net.Listener {
+	return &trackedListener{Listener: lis, t: t}
+}
+
+type trackedListener struct {
+	net.Listener
+	t *ClientTracker
+}
+
+func (l *trackedListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	l.t.mu.Lock()
+	l.t.raw[conn.RemoteAddr().String()] = conn
+	l.t.mu.Unlock()
+	return &trackedNetConn{Conn: conn, t: l.t}, nil
+}
+
+type trackedNetConn struct {
+	net.Conn
+	t *ClientTracker
+}
+
+func (c *trackedNetConn) Close() error {
+	c.t.mu.Lock()
+	delete(c.t.raw, c.RemoteAddr().String())
+	c.t.mu.Unlock()
+	return c.Conn.Close()
+}
+
+func (t *ClientTracker) TagConn(ctx context.Context, info *stats.ConnTagInfo) context.Context {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.nextID++
+	c := &trackedConn{}
+	if info.RemoteAddr != nil {
+		c.addr = info.RemoteAddr.String()
+		c.raw = t.raw[c.addr]
+	}
+	// Requests on the connection derive from this context, so cancelling
+	// it ends them all
+	ctx, c.cancel = context.WithCancel(ctx)
+	t.conns[t.nextID] = c
+	return context.WithValue(ctx, connKey{}, t.nextID)
+}
+
+func (t *ClientTracker) HandleConn(ctx context.Context, s stats.ConnStats) {
+	id, ok := ctx.Value(connKey{}).(uint64)
+	if !ok {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch s.(type) {
+	case *stats.ConnBegin:
+		if c := t.conns[id]; c != nil {
+			c.connected = t.clock.Now()
+			c.lastSeen = c.connected
+			t.notifyLocked()
+		}
+	case *stats.ConnEnd:
+		if c := t.conns[id]; c != nil {
+			c.cancel()
+			delete(t.conns, id)
+			t.notifyLocked()
+		}
+	}
+}
+
+func (t *ClientTracker) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (t *ClientTracker) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	in, ok := s.(*stats.InHeader)
+	if !ok {
+		return
+	}
+	connID, ok := ctx.Value(connKey{}).(uint64)
+	if !ok {
+		return
+	}
+	md := metadata.NewIncomingContext(ctx, in.Header)
+	id, _ := identity.FromIncomingContext(md)
+	ws := workspace.FromIncomingContext(md)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if c := t.conns[connID]; c != nil {
+		c.lastSeen = t.clock.Now()
+		if !id.IsZero() {
+			c.id = id
+		}
+		if ws != "" {
+			c.workspace = ws
+		}
+	}
+}
+
+// Len is how many clients are connected.
+func (t *ClientTracker) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.conns)
+}
+
+// List describes the connected clients, oldest connection first.
+func (t *ClientTracker) List() []*pb.ClientInfo {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var out []*pb.ClientInfo
+	for _, c := range t.conns {
+		out = append(out, c.info())
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ConnectedUnix < out[j].ConnectedUnix })
+	return out
+}
+
+func (c *trackedConn) info() *pb.ClientInfo {
+	return &pb.ClientInfo{
+		Name:          c.id.Name,
+		Email:         c.id.Email,
+		Addr:          c.addr,
+		ConnectedUnix: c.connected.Unix(),
+		LastSeenUnix:  c.lastSeen.Unix(),
+		Workspace:     c.workspace,
+	}
+}
+
+// Kick disconnects every client whose name, email or remote address is
+// who, and refuses them for the rest of the session. Their open requests
+// fail with the reason, and their connections close shortly after.
+func (t *ClientTracker) Kick(who, reason string) []*pb.ClientInfo {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var kicked []*pb.ClientInfo
+	for _, c := range t.conns {
+		if who == "" || (who != c.id.Name && who != c.id.Email && who != c.addr) {
+			continue
+		}
+		kicked = append(kicked, c.info())
+		t.banned[banKey(c.id, c.addr)] = reason
+		c.cancel()
+		if raw := c.raw; raw != nil {
+			t.clock.AfterFunc(kickGrace, func() { raw.Close() })
+		}
+	}
+	sort.Slice(kicked, func(i, j int) bool { return kicked[i].ConnectedUnix < kicked[j].ConnectedUnix })
+	return kicked
+}
+
+// banKey identifies a kicked client: by identity, or by remote host for
+// clients that don't send one.
+func banKey(id identity.Identity, addr string) string {
+	if !id.IsZero() {
+		return "id:" + id.String()
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	return "host:" + host
+}
+
+// refused returns the error for a request from a kicked client, or nil.
+func (t *ClientTracker) refused(ctx context.Context) error {
+	id, _ := identity.FromIncomingContext(ctx)
+	addr := ""
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		addr = p.Addr.String()
+	}
+	t.mu.Lock()
+	reason, ok := t.banned[banKey(id, addr)]
+	t.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return kickedError(reason)
+}
+
+func kickedError(reason string) error {
+	msg := "removed from the session by the host"
+	if reason != "" {
+		msg += ": " + reason
+	}
+	return status.Error(codes.PermissionDenied, msg)
+}
+
+// UnaryServerInterceptor refuses requests from kicked clients.
+func (t *ClientTracker) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := t.refused(ctx); err != nil {
+			return nil, err
+		}
+		resp, err := handler(ctx, req)
+		if ctx.Err() != nil {
+			if kerr := t.refused(ctx); kerr != nil {
+				return nil, kerr
+			}
+		}
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor refuses streams from kicked clients, and ends
+// a stream that's open when its client is kicked with the reason.
+func (t *ClientTracker) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := t.refused(ss.Context()); err != nil {
+			return err
+		}
+		err := handler(srv, ss)
+		if ss.Context().Err() != nil {
+			if kerr := t.refused(ss.Context()); kerr != nil {
+				return kerr
+			}
+		}
+		return err
+	}
+}
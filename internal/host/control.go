@@ -0,0 +1,131 @@
+package host
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/victorarias/blue-guy/internal/control"
+	pb "github.com/victorarias/blue-guy/internal/proto/gen"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ControlServer implements the ControlService for a running host.
+type ControlServer struct {
+	pb.UnimplementedControlServiceServer
+	h *Host
+}
+
+func (s *ControlServer) Status(ctx context.Context, _ *pb.ControlStatusRequest) (*pb.ControlStatus, error) {
+	st := &pb.ControlStatus{
+		Role:        control.RoleHost,
+		Pid:         int32(os.Getpid()),
+		Addr:        s.h.addr,
+		StartedUnix: s.h.started.Unix(),
+		Clients:     int32(s.h.clients.Len()),
+	}
+	for _, w := range s.h.workspaces {
+		st.Workspaces = append(st.Workspaces, w.info(ctx, true))
+	}
+	// A host sharing one workspace reports it at the top level too, as
+	// it always has
+	if len(st.Workspaces) == 1 {
+		st.Workspace = st.Workspaces[0].Root
+		st.Session = st.Workspaces[0].Session
+	}
+	return st, nil
+}
+
+func (s *ControlServer) ListClients(_ context.Context, _ *pb.ListClientsRequest) (*pb.ListClientsResponse, error) {
+	return &pb.ListClientsResponse{Clients: s.h.clients.List()}, nil
+}
+
+// Checkpoint commits on behalf of the host's owner, so unlike
+// GitServer.Checkpoint it isn't subject to the driver rotation. Hosts
+// sharing several workspaces need req.Workspace to say which.
+func (s *ControlServer) Checkpoint(_ context.Context, req *pb.CheckpointRequest) (*pb.CheckpointResponse, error) {
+	w, err := s.h.workspaceAt(req.Workspace)
+	if err != nil {
+		return nil, err
+	}
+	if w.git == nil {
+		return nil, status.Error(codes.FailedPrecondition, "git integration is off")
+	}
+	before, _ := w.git.Head()
+	if err := w.git.Checkpoint(req.Message); err != nil {
+		return nil, gitError(err)
+	}
+	after, err := w.git.Head()
+	if err != nil {
+		return nil, gitError(err)
+	}
+	resp := &pb.CheckpointResponse{}
+	if after != before {
+		resp.Commit = after
+	}
+	return resp, nil
+}
+
+func (s *ControlServer) Stop(_ context.Context, req *pb.StopRequest) (*pb.StopResponse, error) {
+	s.h.log.Info().Str("reason", req.Reason).Msg("Stop requested over the control socket")
+	if req.Reason != "" {
+		s.h.cancel(errors.New(req.Reason))
+	} else {
+		s.h.cancel(nil)
+	}
+	return &pb.StopResponse{}, nil
+}
+
+func (s *ControlServer) ReloadConfig(_ context.Context, _ *pb.ReloadConfigRequest) (*pb.ReloadConfigResponse, error) {
+	if s.h.reload == nil {
+		return nil, status.Error(codes.Unimplemented, "this host can't reload its settings")
+	}
+	resp, err := s.h.reload()
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	s.h.log.Info().Strs("applied", resp.Applied).Strs("restart", resp.Restart).Msg("Reloaded settings")
+	return resp, nil
+}
+
+func (s *ControlServer) SetLogLevel(_ context.Context, req *pb.SetLogLevelRequest) (*pb.SetLogLevelResponse, error) {
+	return control.SetLogLevel(req)
+}
+
+func (s *ControlServer) KickClient(_ context.Context, req *pb.KickClientRequest) (*pb.KickClientResponse, error) {
+	if req.Client == "" {
+		return nil, status.Error(codes.InvalidArgument, "say which client: a name, email or address")
+	}
+	kicked := s.h.clients.Kick(req.Client, req.Reason)
+	if len(kicked) == 0 {
+		return nil, status.Errorf(codes.NotFound, "no connected client matches %q", req.Client)
+	}
+	s.h.log.Info().Str("client", req.Client).Str("reason", req.Reason).Int("connections", len(kicked)).Msg("Kicked client")
+	return &pb.KickClientResponse{Kicked: kicked}, nil
+}
+
+// serveControl starts the control socket. A host without one still works,
+// it just can't be managed from the CLI, so failure is only a warning.
+func (h *Host) serveControl() {
+	lis, path, err := control.Listen(control.RoleHost)
+	if err != nil {
+		h.log.Warn().Err(err).Msg("Control socket unavailable")
+		return
+	}
+	h.controlServer = grpc.NewServer()
+	h.controlPath = path
+	pb.RegisterControlServiceServer(h.controlServer, &ControlServer{h: h})
+	go h.controlServer.Serve(lis)
+	h.log.Debug().Str("socket", path).Msg("Control socket ready")
+}
+
+func (h *Host) stopControl() {
+	if h.controlServer == nil {
+		return
+	}
+	// Graceful, so the reply to a Stop request gets out
+	h.controlServer.GracefulStop()
+	os.Remove(h.controlPath)
+}
@@ -0,0 +1,299 @@
+package host_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/victorarias/blue-guy/internal/control"
+	"github.com/victorarias/blue-guy/internal/gitops"
+	"github.com/victorarias/blue-guy/internal/host"
+	"github.com/victorarias/blue-guy/internal/identity"
+	pb "github.com/victorarias/blue-guy/internal/proto/gen"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// startHost runs a host on a free port and returns its control client and
+// a channel that receives Start's result. setup runs before Start.
+func startHost(t *testing.T, setup ...func(*host.Host)) (string, pb.ControlServiceClient, <-chan error) {
+	t.Helper()
+	t.Setenv("BLUEGUY_CONTROL_DIR", filepath.Join(t.TempDir(), "ctl"))
+	dir := initGitRepo(t)
+	h := host.New(0, "abc", host.Options{
+		Bind: "127.0.0.1",
+		Git:  gitops.Options{Push: gitops.PushOptions{Policy: gitops.PushNever}},
+	})
+	if err := h.AddWorkspace("", dir); err != nil {
+		t.Fatal(err)
+	}
+	for _, fn := range setup {
+		fn(h)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- h.Start(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	select {
+	case <-h.Ready():
+	case err := <-done:
+		t.Fatalf("host didn't start: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("host never became ready")
+	}
+	if len(control.Find(control.RoleHost)) == 0 {
+		t.Fatal("no control socket")
+	}
+	ctl, closeConn, err := control.Dial(control.Find(control.RoleHost)[0].Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { closeConn() })
+	return dir, ctl, done
+}
+
+// dialAs connects to the host as a client with the given identity.
+func dialAs(t *testing.T, addr string, id identity.Identity) *grpc.ClientConn {
+	t.Helper()
+	conn, err := grpc.NewClient(addr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(identity.UnaryClientInterceptor(id)),
+		grpc.WithStreamInterceptor(identity.StreamClientInterceptor(id)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestControl_StatusAndClients(t *testing.T) {
+	var h *host.Host
+	dir, ctl, _ := startHost(t, func(started *host.Host) { h = started })
+	ctx := context.Background()
+
+	st, err := ctl.Status(ctx, &pb.ControlStatusRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Role != control.RoleHost || st.Workspace != dir || st.Session.GetSessionId() != "abc" || st.Pid != int32(os.Getpid()) {
+		t.Errorf("unexpected status %v", st)
+	}
+
+	conn := dialAs(t, st.Addr, identity.Identity{Name: "Alice", Email: "alice@example.com"})
+	if _, err := pb.NewSessionServiceClient(conn).GetStatus(ctx, &pb.GetStatusRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := ctl.ListClients(ctx, &pb.ListClientsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Clients) != 1 || resp.Clients[0].Email != "alice@example.com" || resp.Clients[0].Addr == "" {
+		t.Errorf("expected alice connected, got %v", resp.Clients)
+	}
+
+	conn.Close()
+	for {
+		changed := h.Clients().Changed()
+		if h.Clients().Len() == 0 {
+			break
+		}
+		select {
+		case <-changed:
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected alice to be dropped after disconnecting")
+		}
+	}
+	if resp, err := ctl.ListClients(ctx, &pb.ListClientsRequest{}); err != nil || len(resp.Clients) != 0 {
+		t.Errorf("expected no clients listed, got %v %v", resp.GetClients(), err)
+	}
+}
+
+func TestControl_CheckpointAndStop(t *testing.T) {
+	dir, ctl, done := startHost(t)
+	ctx := context.Background()
+
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a\n"), 0644)
+	resp, err := ctl.Checkpoint(ctx, &pb.CheckpointRequest{Message: "by hand"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Commit == "" {
+		t.Error("expected a commit")
+	}
+
+	if _, err := ctl.Stop(ctx, &pb.StopRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expected a clean shutdown, got %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("host didn't stop")
+	}
+	if eps := control.Find(control.RoleHost); len(eps) != 0 {
+		t.Errorf("expected the socket removed, found %v", eps)
+	}
+}
+
+func TestControl_KickClient(t *testing.T) {
+	_, ctl, _ := startHost(t)
+	ctx := context.Background()
+	st, err := ctl.Status(ctx, &pb.ControlStatusRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	alice := identity.Identity{Name: "Alice", Email: "alice@example.com"}
+	stream, err := pb.NewSessionServiceClient(dialAs(t, st.Addr, alice)).WatchSession(ctx, &pb.WatchSessionRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob := pb.NewSessionServiceClient(dialAs(t, st.Addr, identity.Identity{Name: "Bob"}))
+	if _, err := bob.GetStatus(ctx, &pb.GetStatusRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ctl.KickClient(ctx, &pb.KickClientRequest{Client: "nobody"})
+	assertGRPCCode(t, err, codes.NotFound)
+
+	resp, err := ctl.KickClient(ctx, &pb.KickClientRequest{Client: "alice@example.com", Reason: "too loud"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Kicked) != 1 || resp.Kicked[0].Name != "Alice" {
+		t.Errorf("expected alice kicked, got %v", resp.Kicked)
+	}
+
+	// Her open stream ends with the reason, and she can't come back
+	_, err = stream.Recv()
+	assertGRPCCode(t, err, codes.PermissionDenied)
+	if !strings.Contains(err.Error(), "too loud") {
+		t.Errorf("expected the reason in %q", err)
+	}
+	_, err = pb.NewSessionServiceClient(dialAs(t, st.Addr, alice)).GetStatus(ctx, &pb.GetStatusRequest{})
+	assertGRPCCode(t, err, codes.PermissionDenied)
+
+	if _, err := bob.GetStatus(ctx, &pb.GetStatusRequest{}); err != nil {
+		t.Errorf("expected bob unaffected, got %v", err)
+	}
+}
+
+func TestControl_ReloadAndLogLevel(t *testing.T) {
+	dir, ctl, _ := startHost(t, func(h *host.Host) {
+		h.SetReloader(func() (*pb.ReloadConfigResponse, error) {
+			h.Reconfigure(host.Options{MaxReadSize: 4})
+			return &pb.ReloadConfigResponse{Applied: []string{"cache.max_read_size"}}, nil
+		})
+	})
+	ctx := context.Background()
+
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("hello world"), 0644)
+	resp, err := ctl.ReloadConfig(ctx, &pb.ReloadConfigRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Applied) != 1 {
+		t.Errorf("unexpected response %v", resp)
+	}
+
+	st, _ := ctl.Status(ctx, &pb.ControlStatusRequest{})
+	fc := pb.NewFileServiceClient(dialAs(t, st.Addr, identity.Identity{}))
+	read, err := fc.ReadFile(ctx, &pb.ReadFileRequest{Path: "/a.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(read.Data) != "hell" {
+		t.Errorf("expected the new read cap to apply, got %q", read.Data)
+	}
+
+	defer zerolog.SetGlobalLevel(zerolog.GlobalLevel())
+	level, err := ctl.SetLogLevel(ctx, &pb.SetLogLevelRequest{Level: "WARN"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if level.Level != "warn" || zerolog.GlobalLevel() != zerolog.WarnLevel {
+		t.Errorf("expected warn, got %v", level)
+	}
+	_, err = ctl.SetLogLevel(ctx, &pb.SetLogLevelRequest{Level: "loud"})
+	assertGRPCCode(t, err, codes.InvalidArgument)
+}
+
+func TestControl_StopWaitsForClientFlush(t *testing.T) {
+	dir, ctl, done := startHost(t)
+	ctx := context.Background()
+	st, err := ctl.Status(ctx, &pb.ControlStatusRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := dialAs(t, st.Addr, identity.Identity{Name: "Alice", Email: "alice@example.com"})
+	stream, err := pb.NewSessionServiceClient(conn).WatchSession(ctx, &pb.WatchSessionRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Headers come once the host has subscribed the stream
+	if _, err := stream.Header(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ctl.Stop(ctx, &pb.StopRequest{Reason: "lunch"}); err != nil {
+		t.Fatal(err)
+	}
+	ev, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ending := ev.GetEnding()
+	if ending == nil || ending.Reason != "lunch" {
+		t.Fatalf("expected the session ending with the reason, got %v", ev)
+	}
+
+	// A write that lands before the client says it's done makes the final
+	// commit
+	fc := pb.NewFileServiceClient(conn)
+	if _, err := fc.Create(ctx, &pb.CreateRequest{Path: "/late.txt", Mode: 0644}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fc.WriteFile(ctx, &pb.WriteFileRequest{Path: "/late.txt", Data: []byte("made it\n")}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+		t.Fatal("host stopped before the client flushed")
+	default:
+	}
+	if _, err := pb.NewSessionServiceClient(conn).Flushed(ctx, &pb.FlushedRequest{FlushId: ending.FlushId}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expected a clean shutdown, got %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("host didn't stop")
+	}
+	cmd := exec.Command("git", "show", "--name-only", "--format=", "mob/session-abc")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil || !strings.Contains(string(out), "late.txt") {
+		t.Errorf("expected late.txt in the final commit, got %s %v", out, err)
+	}
+}
@@ -7,23 +7,125 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 
+	"github.com/victorarias/blue-guy/internal/identity"
 	pb "github.com/victorarias/blue-guy/internal/proto/gen"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
-const maxReadSize = 1 << 20 // 1MB
+const defaultMaxReadSize = 1 << 20 // 1MB
+
+// ContributionRecorder is told which client changed which workspace path.
+type ContributionRecorder interface {
+	RecordContribution(id identity.Identity, path string)
+}
+
+// WritePolicy decides whether a client may modify the workspace, going by
+// the identity the client claims; nothing verifies it.
+type WritePolicy interface {
+	AllowWrite(id identity.Identity) error
+}
 
 // FileServer implements the gRPC FileService by serving files from a real directory.
 type FileServer struct {
 	pb.UnimplementedFileServiceServer
-	root    string
-	watcher *Watcher
+	root     string
+	watcher  *Watcher
+	recorder ContributionRecorder
+	policy   WritePolicy
+	maxRead  atomic.Int64
+	writes   writeGate
 }
 
 func NewFileServer(root string, watcher *Watcher) *FileServer {
-	return &FileServer{root: root, watcher: watcher}
+	s := &FileServer{root: root, watcher: watcher}
+	s.maxRead.Store(defaultMaxReadSize)
+	return s
+}
+
+// SetMaxReadSize caps the bytes returned by one ReadFile. It can change
+// while the server runs.
+func (s *FileServer) SetMaxReadSize(n int64) {
+	s.maxRead.Store(n)
+}
+
+// SetRecorder makes the server report every successful mutation, attributed
+// to the calling client's identity.
+func (s *FileServer) SetRecorder(r ContributionRecorder) {
+	s.recorder = r
+}
+
+// SetWritePolicy restricts which clients may modify the workspace.
+func (s *FileServer) SetWritePolicy(p WritePolicy) {
+	s.policy = p
+}
+
+// StopWrites refuses every mutation from now on, once those under way have
+// finished, so nothing lands after the final commit.
+func (s *FileServer) StopWrites() {
+	s.writes.close()
+}
+
+// checkWrite rejects mutations the write policy doesn't allow, or that come
+// too late. The policy sees the client's claimed identity, so it only keeps
+// well-behaved clients in line. When it returns nil the caller must call
+// endWrite once done.
+func (s *FileServer) checkWrite(ctx context.Context) error {
+	if s.policy != nil {
+		id, _ := identity.FromIncomingContext(ctx)
+		if err := s.policy.AllowWrite(id); err != nil {
+			return status.Error(codes.PermissionDenied, err.Error())
+		}
+	}
+	return s.writes.enter()
+}
+
+func (s *FileServer) endWrite() {
+	s.writes.leave()
+}
+
+// writeGate lets mutations through until it's closed. Each holds the lock
+// for reading while it runs, so closing waits for them.
+type writeGate struct {
+	mu     sync.RWMutex
+	closed bool
+}
+
+func (g *writeGate) enter() error {
+	g.mu.RLock()
+	if g.closed {
+		g.mu.RUnlock()
+		return status.Error(codes.Unavailable, "the host is shutting down")
+	}
+	return nil
+}
+
+func (g *writeGate) leave() {
+	g.mu.RUnlock()
+}
+
+func (g *writeGate) close() {
+	g.mu.Lock()
+	g.closed = true
+	g.mu.Unlock()
+}
+
+// record attributes changes to the given workspace-relative paths to the
+// client making the request. Requests without an identity are ignored.
+func (s *FileServer) record(ctx context.Context, paths ...string) {
+	if s.recorder == nil {
+		return
+	}
+	id, ok := identity.FromIncomingContext(ctx)
+	if !ok {
+		return
+	}
+	for _, p := range paths {
+		s.recorder.RecordContribution(id, strings.TrimPrefix(filepath.Clean("/"+p), "/"))
+	}
 }
 
 // resolve turns a workspace-relative path into an absolute path,
@@ -108,9 +210,9 @@ func (s *FileServer) ReadFile(_ context.Context, req *pb.ReadFileRequest) (*pb.R
 	}
 	defer f.Close()
 
-	length := req.Length
-	if length <= 0 || length > maxReadSize {
-		length = maxReadSize
+	length, maxRead := req.Length, s.maxRead.Load()
+	if length <= 0 || length > maxRead {
+		length = maxRead
 	}
 
 	buf := make([]byte, length)
@@ -127,11 +229,15 @@ func (s *FileServer) ReadFile(_ context.Context, req *pb.ReadFileRequest) (*pb.R
 	return &pb.ReadFileResponse{Data: buf[:n]}, nil
 }
 
-func (s *FileServer) WriteFile(_ context.Context, req *pb.WriteFileRequest) (*pb.WriteFileResponse, error) {
+func (s *FileServer) WriteFile(ctx context.Context, req *pb.WriteFileRequest) (*pb.WriteFileResponse, error) {
 	abs, err := s.resolve(req.Path)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.checkWrite(ctx); err != nil {
+		return nil, err
+	}
+	defer s.endWrite()
 
 	flags := os.O_WRONLY
 	if req.Truncate {
@@ -154,6 +260,7 @@ func (s *FileServer) WriteFile(_ context.Context, req *pb.WriteFileRequest) (*pb
 		return nil, status.Errorf(codes.Internal, "write: %v", err)
 	}
 
+	s.record(ctx, req.Path)
 	return &pb.WriteFileResponse{}, nil
 }
 
@@ -180,11 +287,15 @@ func (s *FileServer) ReadDir(_ context.Context, req *pb.ReadDirRequest) (*pb.Rea
 	return &pb.ReadDirResponse{Entries: infos}, nil
 }
 
-func (s *FileServer) Create(_ context.Context, req *pb.CreateRequest) (*pb.CreateResponse, error) {
+func (s *FileServer) Create(ctx context.Context, req *pb.CreateRequest) (*pb.CreateResponse, error) {
 	abs, err := s.resolve(req.Path)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.checkWrite(ctx); err != nil {
+		return nil, err
+	}
+	defer s.endWrite()
 
 	mode := os.FileMode(req.Mode)
 	if mode == 0 {
@@ -196,14 +307,19 @@ func (s *FileServer) Create(_ context.Context, req *pb.CreateRequest) (*pb.Creat
 		return nil, osErrToStatus(err)
 	}
 	f.Close()
+	s.record(ctx, req.Path)
 	return &pb.CreateResponse{}, nil
 }
 
-func (s *FileServer) Mkdir(_ context.Context, req *pb.MkdirRequest) (*pb.MkdirResponse, error) {
+func (s *FileServer) Mkdir(ctx context.Context, req *pb.MkdirRequest) (*pb.MkdirResponse, error) {
 	abs, err := s.resolve(req.Path)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.checkWrite(ctx); err != nil {
+		return nil, err
+	}
+	defer s.endWrite()
 
 	mode := os.FileMode(req.Mode)
 	if mode == 0 {
@@ -213,22 +329,28 @@ func (s *FileServer) Mkdir(_ context.Context, req *pb.MkdirRequest) (*pb.MkdirRe
 	if err := os.Mkdir(abs, mode); err != nil {
 		return nil, osErrToStatus(err)
 	}
+	s.record(ctx, req.Path)
 	return &pb.MkdirResponse{}, nil
 }
 
-func (s *FileServer) Remove(_ context.Context, req *pb.RemoveRequest) (*pb.RemoveResponse, error) {
+func (s *FileServer) Remove(ctx context.Context, req *pb.RemoveRequest) (*pb.RemoveResponse, error) {
 	abs, err := s.resolve(req.Path)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.checkWrite(ctx); err != nil {
+		return nil, err
+	}
+	defer s.endWrite()
 
 	if err := os.Remove(abs); err != nil {
 		return nil, osErrToStatus(err)
 	}
+	s.record(ctx, req.Path)
 	return &pb.RemoveResponse{}, nil
 }
 
-func (s *FileServer) Rename(_ context.Context, req *pb.RenameRequest) (*pb.RenameResponse, error) {
+func (s *FileServer) Rename(ctx context.Context, req *pb.RenameRequest) (*pb.RenameResponse, error) {
 	oldAbs, err := s.resolve(req.OldPath)
 	if err != nil {
 		return nil, err
@@ -237,34 +359,49 @@ func (s *FileServer) Rename(_ context.Context, req *pb.RenameRequest) (*pb.Renam
 	if err != nil {
 		return nil, err
 	}
+	if err := s.checkWrite(ctx); err != nil {
+		return nil, err
+	}
+	defer s.endWrite()
 
 	if err := os.Rename(oldAbs, newAbs); err != nil {
 		return nil, osErrToStatus(err)
 	}
+	s.record(ctx, req.OldPath, req.NewPath)
 	return &pb.RenameResponse{}, nil
 }
 
-func (s *FileServer) Chmod(_ context.Context, req *pb.ChmodRequest) (*pb.ChmodResponse, error) {
+func (s *FileServer) Chmod(ctx context.Context, req *pb.ChmodRequest) (*pb.ChmodResponse, error) {
 	abs, err := s.resolve(req.Path)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.checkWrite(ctx); err != nil {
+		return nil, err
+	}
+	defer s.endWrite()
 
 	if err := os.Chmod(abs, os.FileMode(req.Mode)); err != nil {
 		return nil, osErrToStatus(err)
 	}
+	s.record(ctx, req.Path)
 	return &pb.ChmodResponse{}, nil
 }
 
-func (s *FileServer) Truncate(_ context.Context, req *pb.TruncateRequest) (*pb.TruncateResponse, error) {
+func (s *FileServer) Truncate(ctx context.Context, req *pb.TruncateRequest) (*pb.TruncateResponse, error) {
 	abs, err := s.resolve(req.Path)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.checkWrite(ctx); err != nil {
+		return nil, err
+	}
+	defer s.endWrite()
 
 	if err := os.Truncate(abs, req.Size); err != nil {
 		return nil, osErrToStatus(err)
 	}
+	s.record(ctx, req.Path)
 	return &pb.TruncateResponse{}, nil
 }
 
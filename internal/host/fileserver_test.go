@@ -7,8 +7,10 @@ import (
 	"testing"
 
 	"github.com/victorarias/blue-guy/internal/host"
+	"github.com/victorarias/blue-guy/internal/identity"
 	pb "github.com/victorarias/blue-guy/internal/proto/gen"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
@@ -124,6 +126,20 @@ func TestReadFile_EmptyFile(t *testing.T) {
 	}
 }
 
+func TestReadFile_MaxReadSize(t *testing.T) {
+	s, dir := setupServer(t)
+	s.SetMaxReadSize(4)
+	os.WriteFile(filepath.Join(dir, "long.txt"), []byte("0123456789"), 0644)
+
+	resp, err := s.ReadFile(context.Background(), &pb.ReadFileRequest{Path: "long.txt", Length: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Data) != "0123" {
+		t.Errorf("expected the read capped at 4 bytes, got %q", resp.Data)
+	}
+}
+
 func TestCreateAndRemove(t *testing.T) {
 	s, dir := setupServer(t)
 
@@ -188,6 +204,31 @@ func TestMkdirAndRename(t *testing.T) {
 	}
 }
 
+func TestStopWrites_RefusesLateWrites(t *testing.T) {
+	s, dir := setupServer(t)
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("before"), 0644)
+	ctx := context.Background()
+
+	// Shutdown has started: the final commit is being made
+	s.StopWrites()
+
+	_, err := s.WriteFile(ctx, &pb.WriteFileRequest{Path: "a.txt", Data: []byte("late"), Truncate: true})
+	assertGRPCCode(t, err, codes.Unavailable)
+	_, err = s.Create(ctx, &pb.CreateRequest{Path: "b.txt", Mode: 0644})
+	assertGRPCCode(t, err, codes.Unavailable)
+	_, err = s.Remove(ctx, &pb.RemoveRequest{Path: "a.txt"})
+	assertGRPCCode(t, err, codes.Unavailable)
+	if data, _ := os.ReadFile(filepath.Join(dir, "a.txt")); string(data) != "before" {
+		t.Errorf("expected the file untouched, got %q", data)
+	}
+
+	// Reading is still fine
+	resp, err := s.ReadFile(ctx, &pb.ReadFileRequest{Path: "a.txt", Length: 100})
+	if err != nil || string(resp.Data) != "before" {
+		t.Errorf("expected reads to keep working, got %v", err)
+	}
+}
+
 func TestPathTraversal_DotDot(t *testing.T) {
 	s, _ := setupServer(t)
 
@@ -222,3 +263,53 @@ func TestPathTraversal_SiblingPrefix(t *testing.T) {
 	}
 	assertGRPCCode(t, err, codes.NotFound)
 }
+
+type recordedChange struct {
+	who  identity.Identity
+	path string
+}
+
+type fakeRecorder struct {
+	changes []recordedChange
+}
+
+func (r *fakeRecorder) RecordContribution(id identity.Identity, path string) {
+	r.changes = append(r.changes, recordedChange{id, path})
+}
+
+func TestMutationsAreAttributedToCaller(t *testing.T) {
+	s, dir := setupServer(t)
+	rec := &fakeRecorder{}
+	s.SetRecorder(rec)
+	os.WriteFile(filepath.Join(dir, "a.txt"), nil, 0644)
+
+	alice := identity.Identity{Name: "Alice", Email: "alice@example.com"}
+	ctx := incomingContext(alice)
+
+	if _, err := s.WriteFile(ctx, &pb.WriteFileRequest{Path: "/a.txt", Data: []byte("x")}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Rename(ctx, &pb.RenameRequest{OldPath: "a.txt", NewPath: "b.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	// Failed operations and anonymous callers are not recorded
+	s.Remove(ctx, &pb.RemoveRequest{Path: "missing.txt"})
+	s.Create(context.Background(), &pb.CreateRequest{Path: "anon.txt"})
+
+	want := []recordedChange{{alice, "a.txt"}, {alice, "a.txt"}, {alice, "b.txt"}}
+	if len(rec.changes) != len(want) {
+		t.Fatalf("got %v, want %v", rec.changes, want)
+	}
+	for i := range want {
+		if rec.changes[i] != want[i] {
+			t.Errorf("change %d: got %v, want %v", i, rec.changes[i], want[i])
+		}
+	}
+}
+
+// incomingContext simulates a request that arrived with id's metadata.
+func incomingContext(id identity.Identity) context.Context {
+	out := identity.NewOutgoingContext(context.Background(), id)
+	md, _ := metadata.FromOutgoingContext(out)
+	return metadata.NewIncomingContext(context.Background(), md)
+}
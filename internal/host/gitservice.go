@@ -0,0 +1,328 @@
+package host
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"time"
+
+	"github.com/victorarias/blue-guy/internal/gitops"
+	"github.com/victorarias/blue-guy/internal/identity"
+	pb "github.com/victorarias/blue-guy/internal/proto/gen"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// historyListLimit caps how many commits the history root lists.
+const historyListLimit = 1000
+
+// ChangeNotifier tells clients about changes the host made on disk.
+// Implemented by Watcher.
+type ChangeNotifier interface {
+	Notify(path string, changeType pb.ChangeType)
+}
+
+// GitServer implements the gRPC GitService on top of the session's GitOps.
+type GitServer struct {
+	pb.UnimplementedGitServiceServer
+	git      *gitops.GitOps // nil when git integration is off
+	notifier ChangeNotifier // may be nil
+	policy   WritePolicy    // nil allows everyone
+	writes   writeGate
+}
+
+func NewGitServer(git *gitops.GitOps, notifier ChangeNotifier) *GitServer {
+	return &GitServer{git: git, notifier: notifier}
+}
+
+// SetWritePolicy restricts who may checkpoint and roll back.
+func (s *GitServer) SetWritePolicy(p WritePolicy) {
+	s.policy = p
+}
+
+func (s *GitServer) Status(_ context.Context, _ *pb.GitStatusRequest) (*pb.GitStatusResponse, error) {
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+	files, err := s.git.Status()
+	if err != nil {
+		return nil, gitError(err)
+	}
+	resp := &pb.GitStatusResponse{Branch: s.git.Branch()}
+	for _, f := range files {
+		resp.Files = append(resp.Files, &pb.FileStatus{
+			Path:     f.Path,
+			OrigPath: f.OrigPath,
+			Staging:  string(f.Staging),
+			Worktree: string(f.Worktree),
+		})
+	}
+	return resp, nil
+}
+
+func (s *GitServer) Diff(_ context.Context, req *pb.DiffRequest) (*pb.DiffResponse, error) {
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+	patch, truncated, err := s.git.Diff(req.Commit, req.SinceStart, req.Paths)
+	if err != nil {
+		return nil, gitError(err)
+	}
+	return &pb.DiffResponse{Patch: patch, Truncated: truncated}, nil
+}
+
+func (s *GitServer) Log(_ context.Context, req *pb.LogRequest) (*pb.LogResponse, error) {
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+	commits, err := s.git.Log(int(req.Limit), req.Path)
+	if err != nil {
+		return nil, gitError(err)
+	}
+	resp := &pb.LogResponse{}
+	for _, c := range commits {
+		resp.Commits = append(resp.Commits, &pb.CommitInfo{
+			Hash:        c.Hash,
+			AuthorName:  c.AuthorName,
+			AuthorEmail: c.AuthorEmail,
+			TimeUnix:    c.Time.Unix(),
+			Subject:     c.Subject,
+			Body:        c.Body,
+			CoAuthors:   c.CoAuthors,
+		})
+	}
+	return resp, nil
+}
+
+func (s *GitServer) Blame(_ context.Context, req *pb.BlameRequest) (*pb.BlameResponse, error) {
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+	lines, err := s.git.Blame(req.Path, req.Commit)
+	if err != nil {
+		return nil, gitError(err)
+	}
+	resp := &pb.BlameResponse{}
+	for _, l := range lines {
+		resp.Lines = append(resp.Lines, &pb.BlameLine{
+			Line:        int32(l.Line),
+			Hash:        l.Hash,
+			AuthorName:  l.AuthorName,
+			AuthorEmail: l.AuthorEmail,
+			TimeUnix:    l.Time.Unix(),
+			Summary:     l.Summary,
+			Text:        l.Text,
+		})
+	}
+	return resp, nil
+}
+
+func (s *GitServer) Checkpoint(ctx context.Context, req *pb.CheckpointRequest) (*pb.CheckpointResponse, error) {
+	if err := s.checkWrite(ctx); err != nil {
+		return nil, err
+	}
+	defer s.endWrite()
+	before, _ := s.git.Head()
+	if err := s.git.Checkpoint(req.Message); err != nil {
+		return nil, gitError(err)
+	}
+	after, err := s.git.Head()
+	if err != nil {
+		return nil, gitError(err)
+	}
+	resp := &pb.CheckpointResponse{}
+	if after != before {
+		resp.Commit = after
+	}
+	return resp, nil
+}
+
+func (s *GitServer) Rollback(ctx context.Context, req *pb.RollbackRequest) (*pb.RollbackResponse, error) {
+	if err := s.checkWrite(ctx); err != nil {
+		return nil, err
+	}
+	defer s.endWrite()
+	if req.Commit == "" {
+		return nil, status.Error(codes.InvalidArgument, "commit is required")
+	}
+	commit, changes, err := s.git.Rollback(req.Commit, req.Paths)
+	if err != nil {
+		return nil, gitError(err)
+	}
+
+	resp := &pb.RollbackResponse{Commit: commit}
+	for _, c := range changes {
+		resp.ChangedPaths = append(resp.ChangedPaths, c.Path)
+		if s.notifier == nil {
+			continue
+		}
+		// The watcher sees these too, but clients shouldn't have to wait
+		// for it (or for a poll) to drop stale caches
+		t := pb.ChangeType_CHANGE_TYPE_MODIFIED
+		switch c.Status {
+		case 'A':
+			t = pb.ChangeType_CHANGE_TYPE_CREATED
+		case 'D':
+			t = pb.ChangeType_CHANGE_TYPE_DELETED
+		}
+		s.notifier.Notify(c.Path, t)
+	}
+	return resp, nil
+}
+
+func (s *GitServer) HistoryStat(_ context.Context, req *pb.HistoryRequest) (*pb.StatResponse, error) {
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+	if req.Snapshot == "" {
+		if req.Path != "" && req.Path != "/" {
+			return nil, status.Error(codes.NotFound, "not a snapshot")
+		}
+		info := &pb.FileInfo{Name: "history", Mode: goModeToUnix(0o555 | fs.ModeDir), IsDir: true}
+		if head, err := s.git.Head(); err == nil {
+			if snap, err := s.git.ResolveSnapshot(head); err == nil {
+				info.ModTimeUnix = snap.Time.Unix()
+			}
+		}
+		return &pb.StatResponse{Info: info}, nil
+	}
+
+	snap, err := s.git.ResolveSnapshot(req.Snapshot)
+	if err != nil {
+		return nil, gitError(err)
+	}
+	e, err := s.git.SnapshotStat(snap.Commit, req.Path)
+	if err != nil {
+		return nil, gitError(err)
+	}
+	info := treeEntryToProto(e, snap.Time)
+	if e.Name == "." {
+		info.Name = req.Snapshot
+	}
+	return &pb.StatResponse{Info: info}, nil
+}
+
+func (s *GitServer) HistoryReadDir(_ context.Context, req *pb.HistoryRequest) (*pb.ReadDirResponse, error) {
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+	if req.Snapshot == "" {
+		return s.listSnapshots()
+	}
+
+	snap, err := s.git.ResolveSnapshot(req.Snapshot)
+	if err != nil {
+		return nil, gitError(err)
+	}
+	entries, err := s.git.SnapshotList(snap.Commit, req.Path)
+	if err != nil {
+		return nil, gitError(err)
+	}
+	resp := &pb.ReadDirResponse{}
+	for _, e := range entries {
+		resp.Entries = append(resp.Entries, treeEntryToProto(e, snap.Time))
+	}
+	return resp, nil
+}
+
+func (s *GitServer) HistoryReadFile(_ context.Context, req *pb.HistoryReadRequest) (*pb.ReadFileResponse, error) {
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+	if req.Snapshot == "" {
+		return nil, status.Error(codes.InvalidArgument, "snapshot is required")
+	}
+	snap, err := s.git.ResolveSnapshot(req.Snapshot)
+	if err != nil {
+		return nil, gitError(err)
+	}
+	data, err := s.git.SnapshotRead(snap.Commit, req.Path, req.Offset, req.Length)
+	if err != nil {
+		return nil, gitError(err)
+	}
+	return &pb.ReadFileResponse{Data: data}, nil
+}
+
+// listSnapshots lists the session's commits by short hash, plus "start"
+// for where the session branched from. Times can be looked up too but
+// aren't listed.
+func (s *GitServer) listSnapshots() (*pb.ReadDirResponse, error) {
+	commits, err := s.git.Log(historyListLimit, "")
+	if err != nil {
+		return nil, gitError(err)
+	}
+	dir := goModeToUnix(0o555 | fs.ModeDir)
+	resp := &pb.ReadDirResponse{}
+	for _, c := range commits {
+		resp.Entries = append(resp.Entries, &pb.FileInfo{
+			Name:        c.Hash[:min(len(c.Hash), 12)],
+			Mode:        dir,
+			ModTimeUnix: c.Time.Unix(),
+			IsDir:       true,
+		})
+	}
+	if start, err := s.git.ResolveSnapshot(gitops.SnapshotStart); err == nil {
+		resp.Entries = append(resp.Entries, &pb.FileInfo{
+			Name:        gitops.SnapshotStart,
+			Mode:        dir,
+			ModTimeUnix: start.Time.Unix(),
+			IsDir:       true,
+		})
+	}
+	return resp, nil
+}
+
+func treeEntryToProto(e gitops.TreeEntry, mod time.Time) *pb.FileInfo {
+	return &pb.FileInfo{
+		Name:        e.Name,
+		Size:        e.Size,
+		Mode:        goModeToUnix(e.Mode),
+		ModTimeUnix: mod.Unix(),
+		IsDir:       e.Mode.IsDir(),
+	}
+}
+
+// StopWrites refuses checkpoints and rollbacks from now on, once those
+// under way have finished.
+func (s *GitServer) StopWrites() {
+	s.writes.close()
+}
+
+// checkWrite admits a checkpoint or rollback. When it returns nil the
+// caller must call endWrite once done.
+func (s *GitServer) checkWrite(ctx context.Context) error {
+	if err := s.check(); err != nil {
+		return err
+	}
+	if s.policy != nil {
+		id, _ := identity.FromIncomingContext(ctx)
+		if err := s.policy.AllowWrite(id); err != nil {
+			return status.Error(codes.PermissionDenied, err.Error())
+		}
+	}
+	return s.writes.enter()
+}
+
+func (s *GitServer) endWrite() {
+	s.writes.leave()
+}
+
+func (s *GitServer) check() error {
+	if s.git == nil {
+		return status.Error(codes.FailedPrecondition, "git integration is off on this host")
+	}
+	return nil
+}
+
+func gitError(err error) error {
+	var secrets *gitops.SecretsError
+	switch {
+	case errors.As(err, &secrets):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, gitops.ErrInvalidArgument):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, gitops.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
@@ -0,0 +1,181 @@
+package host_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/victorarias/blue-guy/internal/gitops"
+	"github.com/victorarias/blue-guy/internal/host"
+	"github.com/victorarias/blue-guy/internal/identity"
+	pb "github.com/victorarias/blue-guy/internal/proto/gen"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type fakeNotifier struct {
+	events []*pb.FileChangeEvent
+}
+
+func (f *fakeNotifier) Notify(path string, t pb.ChangeType) {
+	f.events = append(f.events, &pb.FileChangeEvent{Path: path, Type: t})
+}
+
+type denyAll struct{}
+
+func (denyAll) AllowWrite(identity.Identity) error { return errors.New("read-only") }
+
+// gitSession starts a session in a fresh repository with one commit.
+func gitSession(t *testing.T) (string, *gitops.GitOps) {
+	t.Helper()
+	dir := initGitRepo(t)
+	g, err := gitops.New(dir, "abc", gitops.Options{}, zerolog.Nop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(g.Stop)
+	return dir, g
+}
+
+// initGitRepo creates a repository with one commit.
+func initGitRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"config", "user.name", "Host"},
+		{"config", "user.email", "host@example.com"},
+		{"config", "commit.gpgsign", "false"},
+		{"commit", "-q", "--allow-empty", "-m", "initial"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+	return dir
+}
+
+func TestGitServer_WithoutGit(t *testing.T) {
+	s := host.NewGitServer(nil, nil)
+	_, err := s.Log(context.Background(), &pb.LogRequest{})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("expected FailedPrecondition, got %v", err)
+	}
+}
+
+func TestGitServer_CheckpointAndRollback(t *testing.T) {
+	dir, g := gitSession(t)
+	notifier := &fakeNotifier{}
+	s := host.NewGitServer(g, notifier)
+	ctx := context.Background()
+
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("v1\n"), 0644)
+	cp, err := s.Checkpoint(ctx, &pb.CheckpointRequest{Message: "v1"})
+	if err != nil || cp.Commit == "" {
+		t.Fatalf("expected a checkpoint commit, got %v, %v", cp, err)
+	}
+
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("v2\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "b.txt"), []byte("new\n"), 0644)
+
+	rb, err := s.Rollback(ctx, &pb.RollbackRequest{Commit: cp.Commit})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "a.txt")); string(data) != "v1\n" {
+		t.Errorf("expected a.txt rolled back, got %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "b.txt")); !os.IsNotExist(err) {
+		t.Error("expected b.txt, created after the checkpoint, to be gone")
+	}
+	if rb.Commit == "" || len(rb.ChangedPaths) != 2 {
+		t.Errorf("unexpected response %v", rb)
+	}
+
+	got := map[string]pb.ChangeType{}
+	for _, e := range notifier.events {
+		got[e.Path] = e.Type
+	}
+	if got["a.txt"] != pb.ChangeType_CHANGE_TYPE_MODIFIED || got["b.txt"] != pb.ChangeType_CHANGE_TYPE_DELETED {
+		t.Errorf("unexpected change notifications %v", got)
+	}
+}
+
+func TestGitServer_RollbackHonoursWritePolicy(t *testing.T) {
+	_, g := gitSession(t)
+	s := host.NewGitServer(g, nil)
+	s.SetWritePolicy(denyAll{})
+
+	_, err := s.Rollback(context.Background(), &pb.RollbackRequest{Commit: "HEAD"})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected PermissionDenied, got %v", err)
+	}
+}
+
+func TestGitServer_History(t *testing.T) {
+	dir, g := gitSession(t)
+	s := host.NewGitServer(g, nil)
+	ctx := context.Background()
+
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("v1\n"), 0644)
+	cp, err := s.Checkpoint(ctx, &pb.CheckpointRequest{Message: "v1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("v2\n"), 0644)
+	if _, err := s.Checkpoint(ctx, &pb.CheckpointRequest{Message: "v2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	root, err := s.HistoryReadDir(ctx, &pb.HistoryRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range root.Entries {
+		names = append(names, e.Name)
+	}
+	if len(names) != 3 || names[1] != cp.Commit[:12] || names[2] != "start" {
+		t.Errorf("expected two commits and start, got %v", names)
+	}
+
+	st, err := s.HistoryStat(ctx, &pb.HistoryRequest{Snapshot: names[1]})
+	if err != nil || !st.Info.IsDir || st.Info.Name != names[1] {
+		t.Errorf("expected the snapshot to be a directory, got %v, %v", st, err)
+	}
+	read, err := s.HistoryReadFile(ctx, &pb.HistoryReadRequest{Snapshot: names[1], Path: "/a.txt"})
+	if err != nil || string(read.Data) != "v1\n" {
+		t.Errorf("expected the first version, got %v, %v", read, err)
+	}
+
+	_, err = s.HistoryStat(ctx, &pb.HistoryRequest{Snapshot: "start", Path: "a.txt"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound before the session, got %v", err)
+	}
+	_, err = s.HistoryReadDir(ctx, &pb.HistoryRequest{Snapshot: "whenever"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestGitServer_StopWrites(t *testing.T) {
+	_, g := gitSession(t)
+	s := host.NewGitServer(g, nil)
+	s.StopWrites()
+
+	_, err := s.Checkpoint(context.Background(), &pb.CheckpointRequest{Message: "late"})
+	assertGRPCCode(t, err, codes.Unavailable)
+	head, _ := g.Head()
+	_, err = s.Rollback(context.Background(), &pb.RollbackRequest{Commit: head})
+	assertGRPCCode(t, err, codes.Unavailable)
+}
@@ -2,115 +2,245 @@ package host
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"os"
-	"path/filepath"
+	"strconv"
+	"time"
 
 	"github.com/rs/zerolog"
+	"github.com/victorarias/blue-guy/internal/clock"
 	"github.com/victorarias/blue-guy/internal/gitops"
 	pb "github.com/victorarias/blue-guy/internal/proto/gen"
 	"google.golang.org/grpc"
 )
 
+// Options configures optional host behaviour. The zero value is usable.
+type Options struct {
+	// Bind is the address to listen on. Empty means all interfaces.
+	Bind string
+	// WatcherBackend selects the filesystem event source (see BackendFsnotify
+	// and BackendFanotify).
+	WatcherBackend string
+	// PollInterval is the rescan interval for directories the OS watcher
+	// can't cover.
+	PollInterval time.Duration
+	// SubscriberBuffer is how many change events a slow subscriber can
+	// fall behind by before events are dropped for it. 0 means 64.
+	SubscriberBuffer int
+	// MaxReadSize caps the bytes returned by one read. 0 means 1MB.
+	MaxReadSize int64
+	// Git configures the auto-commit integration.
+	Git gitops.Options
+	// Rotation enables mob driver rotation when Turn is non-zero.
+	Rotation RotationOptions
+	// ShutdownGrace is how long the host waits, when the session ends, for
+	// clients to finish their writes before the final commit. 0 means 5s.
+	ShutdownGrace time.Duration
+	// Clock is the time source for the watcher, auto-commits and rotation
+	// when their own options don't set one. nil means the system clock.
+	Clock clock.Clock
+}
+
+const defaultShutdownGrace = 5 * time.Second
+
 type Host struct {
-	root       string
 	port       int
 	sessionID  string
+	opts       Options
+	workspaces []*Workspace
 	grpcServer *grpc.Server
-	fileServer *FileServer
-	watcher    *Watcher
-	git        *gitops.GitOps
+	clients    *ClientTracker
 	log        zerolog.Logger
+
+	addr          string
+	started       time.Time
+	cancel        context.CancelCauseFunc // ends the session, e.g. on a Stop request; the cause is the reason
+	controlServer *grpc.Server
+	controlPath   string
+	reload        Reloader
+	ready         chan struct{}
 }
 
-func New(root string, port int, sessionID string) (*Host, error) {
-	absRoot, err := filepath.Abs(root)
-	if err != nil {
-		return nil, fmt.Errorf("resolve root: %w", err)
-	}
-	info, err := os.Stat(absRoot)
-	if err != nil || !info.IsDir() {
-		return nil, fmt.Errorf("root %q is not a directory", absRoot)
-	}
+// Reloader re-reads the host's settings for ReloadConfig, applying what it
+// can with Reconfigure and reporting the rest.
+type Reloader func() (*pb.ReloadConfigResponse, error)
 
+// New creates a host with nothing shared yet; add at least one workspace
+// with AddWorkspace before calling Start.
+func New(port int, sessionID string, opts Options) *Host {
 	log := zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr}).
 		With().Timestamp().Str("role", "host").Logger()
 
 	return &Host{
-		root:      absRoot,
 		port:      port,
 		sessionID: sessionID,
+		opts:      opts,
 		log:       log,
-	}, nil
+		ready:     make(chan struct{}),
+	}
 }
 
-func (h *Host) Start(ctx context.Context) error {
-	// Start file watcher
-	watcher, err := NewWatcher(h.root, h.log)
-	if err != nil {
-		return fmt.Errorf("start watcher: %w", err)
-	}
-	h.watcher = watcher
-	go h.watcher.Run()
+// Ready is closed once Start is listening for clients and on the control
+// socket. It stays open if Start fails before then.
+func (h *Host) Ready() <-chan struct{} { return h.ready }
 
-	// Start git integration
-	g, err := gitops.New(h.root, h.sessionID, h.log)
-	if err != nil {
-		h.log.Warn().Err(err).Msg("Git integration disabled (not a git repo?)")
-	} else {
-		h.git = g
-		if err := h.git.Start(ctx); err != nil {
-			h.log.Warn().Err(err).Msg("Failed to start git integration")
-			h.git = nil
-		}
-	}
+// Clients tracks who is connected. It's nil until Ready.
+func (h *Host) Clients() *ClientTracker { return h.clients }
 
-	// Wire watcher events to git auto-commit
-	if h.git != nil {
-		changeCh := h.watcher.Subscribe()
-		go func() {
-			for range changeCh {
-				h.git.NotifyChange()
+func (h *Host) Start(ctx context.Context) error {
+	if len(h.workspaces) == 0 {
+		return errors.New("no workspaces to share")
+	}
+	ctx, h.cancel = context.WithCancelCause(ctx)
+	defer h.cancel(nil)
+	h.started = clock.Or(h.opts.Clock).Now()
+
+	for i, w := range h.workspaces {
+		if err := w.start(ctx, h.sessionID, h.opts); err != nil {
+			for _, started := range h.workspaces[:i] {
+				started.stop()
+			}
+			if len(h.workspaces) > 1 {
+				return fmt.Errorf("workspace %s: %w", w.name, err)
 			}
-		}()
+			return err
+		}
 	}
 
-	h.fileServer = NewFileServer(h.root, h.watcher)
-	h.grpcServer = grpc.NewServer()
-	pb.RegisterFileServiceServer(h.grpcServer, h.fileServer)
+	h.clients = NewClientTracker(h.opts.Clock)
+	h.grpcServer = grpc.NewServer(
+		grpc.StatsHandler(h.clients),
+		grpc.ChainUnaryInterceptor(h.clients.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(h.clients.StreamServerInterceptor()),
+	)
+	h.grpcServer.RegisterService(routed(pb.FileService_ServiceDesc, func(ctx context.Context) (any, error) {
+		w, err := h.workspaceFor(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return w.fileServer, nil
+	}), nil)
+	h.grpcServer.RegisterService(routed(pb.SessionService_ServiceDesc, func(ctx context.Context) (any, error) {
+		w, err := h.workspaceFor(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return w.session, nil
+	}), nil)
+	h.grpcServer.RegisterService(routed(pb.GitService_ServiceDesc, func(ctx context.Context) (any, error) {
+		w, err := h.workspaceFor(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return w.gitServer, nil
+	}), nil)
+	pb.RegisterWorkspaceServiceServer(h.grpcServer, &WorkspaceServer{h: h})
 
-	addr := fmt.Sprintf("0.0.0.0:%d", h.port)
+	bind := h.opts.Bind
+	if bind == "" {
+		bind = "0.0.0.0"
+	}
+	addr := net.JoinHostPort(bind, strconv.Itoa(h.port))
 	lis, err := net.Listen("tcp", addr)
 	if err != nil {
+		for _, w := range h.workspaces {
+			w.stop()
+		}
 		return fmt.Errorf("listen on %s: %w", addr, err)
 	}
-
-	dirName := filepath.Base(h.root)
-	h.log.Info().
-		Str("path", h.root).
-		Str("session", h.sessionID).
-		Str("branch", "mob/session-"+h.sessionID).
-		Msgf("Starting mob session on %s", h.root)
+	h.addr = lis.Addr().String()
+	lis = h.clients.Listener(lis)
+	h.serveControl()
+	close(h.ready)
+
+	for _, w := range h.workspaces {
+		w.log.Info().
+			Str("path", w.root).
+			Str("session", h.sessionID).
+			Str("branch", "mob/session-"+h.sessionID).
+			Msgf("Starting mob session on %s", w.root)
+	}
 
 	fmt.Printf("Session: %s | Branch: mob/session-%s\n", h.sessionID, h.sessionID)
 	fmt.Printf("Listening on %s\n", addr)
-	fmt.Printf("Join with: blue-guy --connect <YOUR_IP>:%d\n", h.port)
-	fmt.Printf("Workspace: %s\n", dirName)
+	if len(h.workspaces) == 1 {
+		fmt.Printf("Join with: blue-guy join <YOUR_IP>:%d\n", h.port)
+	} else {
+		fmt.Printf("Join with: blue-guy join --workspace <name> <YOUR_IP>:%d (or --all)\n", h.port)
+	}
+	for _, w := range h.workspaces {
+		if len(h.workspaces) == 1 {
+			fmt.Printf("Workspace: %s\n", w.name)
+		} else {
+			fmt.Printf("Workspace: %s (%s)\n", w.name, w.root)
+		}
+	}
+
+	for _, w := range h.workspaces {
+		if w.rotation != nil {
+			w.rotation.Start()
+			fmt.Printf("Rotation: %s drives first in %s, %s turns\n", w.rotation.Driver(), w.name, h.opts.Rotation.Turn)
+		}
+	}
 
 	// Shut down when context is cancelled
 	go func() {
 		<-ctx.Done()
-		h.log.Info().Msg("Shutting down")
-		if h.git != nil {
-			h.git.Stop()
+		reason := ""
+		if cause := context.Cause(ctx); !errors.Is(cause, context.Canceled) {
+			reason = cause.Error()
+		}
+		h.log.Info().Str("reason", reason).Msg("Shutting down")
+		h.endSessions(reason)
+		for _, w := range h.workspaces {
+			w.stop()
 		}
-		h.watcher.Close()
 		h.grpcServer.GracefulStop()
 	}()
 
-	return h.grpcServer.Serve(lis)
+	err = h.grpcServer.Serve(lis)
+	h.stopControl()
+	return err
+}
+
+// endSessions tells every client the session is over and waits, up to the
+// shutdown grace, for them to finish writing so the final commits have
+// everything.
+func (h *Host) endSessions(reason string) {
+	grace := h.opts.ShutdownGrace
+	if grace <= 0 {
+		grace = defaultShutdownGrace
+	}
+	deadline := clock.Or(h.opts.Clock).After(grace)
+	var flushed []<-chan struct{}
+	for _, w := range h.workspaces {
+		flushed = append(flushed, w.session.End(reason, grace))
+	}
+	for _, done := range flushed {
+		select {
+		case <-done:
+		case <-deadline:
+			h.log.Warn().Dur("grace", grace).Msg("Not every client finished writing in time")
+			return
+		}
+	}
+}
+
+// SetReloader sets how the control socket's ReloadConfig re-reads
+// settings. Without one, reloading isn't supported.
+func (h *Host) SetReloader(fn Reloader) {
+	h.reload = fn
+}
+
+// Reconfigure applies the settings in opts that can change mid-session:
+// auto-commit delays, file size limits and the read size cap. Everything
+// else needs a restart.
+func (h *Host) Reconfigure(opts Options) {
+	for _, w := range h.workspaces {
+		w.reconfigure(opts)
+	}
 }
 
-func (h *Host) Root() string      { return h.root }
 func (h *Host) SessionID() string { return h.sessionID }
@@ -0,0 +1,154 @@
+package host
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/victorarias/blue-guy/internal/clock"
+	pb "github.com/victorarias/blue-guy/internal/proto/gen"
+)
+
+const defaultPollInterval = 2 * time.Second
+
+// fileState is the part of a file's metadata the poller compares between scans.
+type fileState struct {
+	size    int64
+	modTime time.Time
+	isDir   bool
+}
+
+// Poller detects changes in directory trees by periodically snapshotting
+// size and mtime and diffing against the previous scan. It is the fallback
+// for subtrees the OS watcher can't cover (e.g. inotify watch exhaustion).
+type Poller struct {
+	interval time.Duration
+	clock    clock.Clock
+	emit     func(path string, changeType pb.ChangeType)
+
+	mu       sync.Mutex
+	roots    map[string]struct{}
+	snapshot map[string]fileState
+
+	stop chan struct{}
+	once sync.Once
+}
+
+func NewPoller(interval time.Duration, emit func(path string, changeType pb.ChangeType)) *Poller {
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	return &Poller{
+		interval: interval,
+		clock:    clock.Real,
+		emit:     emit,
+		roots:    make(map[string]struct{}),
+		snapshot: make(map[string]fileState),
+		stop:     make(chan struct{}),
+	}
+}
+
+// AddRoot starts polling the tree under dir. The initial state is recorded
+// without emitting events, so only subsequent changes are reported.
+func (p *Poller) AddRoot(dir string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.roots[dir]; ok {
+		return
+	}
+	p.roots[dir] = struct{}{}
+	for path, st := range snapshotTree(dir) {
+		p.snapshot[path] = st
+	}
+}
+
+// Roots returns the directories currently being polled.
+func (p *Poller) Roots() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	roots := make([]string, 0, len(p.roots))
+	for r := range p.roots {
+		roots = append(roots, r)
+	}
+	return roots
+}
+
+// Run scans on every interval until Close is called.
+func (p *Poller) Run() {
+	ticker := p.clock.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C():
+			p.Scan()
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+// Scan takes a fresh snapshot of all roots and emits an event for every
+// path that appeared, changed or disappeared since the previous scan.
+func (p *Poller) Scan() {
+	p.mu.Lock()
+	current := make(map[string]fileState, len(p.snapshot))
+	for root := range p.roots {
+		for path, st := range snapshotTree(root) {
+			current[path] = st
+		}
+	}
+	prev := p.snapshot
+	p.snapshot = current
+	p.mu.Unlock()
+
+	for path, st := range current {
+		old, existed := prev[path]
+		switch {
+		case !existed:
+			p.emit(path, pb.ChangeType_CHANGE_TYPE_CREATED)
+		case st.isDir != old.isDir:
+			p.emit(path, pb.ChangeType_CHANGE_TYPE_DELETED)
+			p.emit(path, pb.ChangeType_CHANGE_TYPE_CREATED)
+		case !st.isDir && (st.size != old.size || !st.modTime.Equal(old.modTime)):
+			p.emit(path, pb.ChangeType_CHANGE_TYPE_MODIFIED)
+		}
+	}
+	for path := range prev {
+		if _, ok := current[path]; !ok {
+			p.emit(path, pb.ChangeType_CHANGE_TYPE_DELETED)
+		}
+	}
+}
+
+func (p *Poller) Close() {
+	p.once.Do(func() { close(p.stop) })
+}
+
+// snapshotTree records the state of every entry under dir, skipping hidden
+// directories the same way the watcher does. The root itself is not included.
+func snapshotTree(dir string) map[string]fileState {
+	snap := make(map[string]fileState)
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil // skip inaccessible paths
+		}
+		if path == dir {
+			return nil
+		}
+		if info.IsDir() && strings.HasPrefix(info.Name(), ".") {
+			return filepath.SkipDir
+		}
+		snap[path] = fileState{
+			size:    info.Size(),
+			modTime: info.ModTime(),
+			isDir:   info.IsDir(),
+		}
+		return nil
+	})
+	return snap
+}
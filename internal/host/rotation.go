@@ -0,0 +1,184 @@
+package host
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/victorarias/blue-guy/internal/clock"
+	"github.com/victorarias/blue-guy/internal/identity"
+	pb "github.com/victorarias/blue-guy/internal/proto/gen"
+)
+
+// RotationOptions configures the mob driver rotation.
+type RotationOptions struct {
+	// Roster lists drivers in turn order. Names are matched against a
+	// client's identity name or email, case-insensitively.
+	Roster []string
+	// Turn is how long each driver has the keyboard.
+	Turn time.Duration
+	// DriversOnly makes everyone but the current driver read-only. It's a
+	// courtesy, not access control: identities are whatever clients say.
+	DriversOnly bool
+	// Clock times the turns. nil means the system clock.
+	Clock clock.Clock
+}
+
+// Checkpointer commits the workspace immediately. Implemented by GitOps.
+type Checkpointer interface {
+	Checkpoint(message string, trailers ...string) error
+}
+
+// Rotation hands the keyboard from one roster member to the next every turn,
+// checkpointing the work at each handoff and announcing the new driver.
+type Rotation struct {
+	opts     RotationOptions
+	session  *SessionServer
+	git      Checkpointer // nil when git integration is off
+	log      zerolog.Logger
+	handoff  sync.Mutex // one handoff at a time, checkpoint and all
+	mu       sync.Mutex
+	index    int
+	turnEnds time.Time
+	timer    clock.Timer
+	stopped  bool
+}
+
+func NewRotation(opts RotationOptions, session *SessionServer, git Checkpointer, log zerolog.Logger) (*Rotation, error) {
+	if len(opts.Roster) == 0 {
+		return nil, fmt.Errorf("rotation needs at least one name in the roster")
+	}
+	if opts.Turn <= 0 {
+		return nil, fmt.Errorf("rotation turn length must be positive")
+	}
+	opts.Clock = clock.Or(opts.Clock)
+	return &Rotation{
+		opts:    opts,
+		session: session,
+		git:     git,
+		log:     log.With().Str("component", "rotation").Logger(),
+	}, nil
+}
+
+// Start begins the first turn.
+func (r *Rotation) Start() {
+	r.mu.Lock()
+	r.index = 0
+	r.startTurnLocked()
+	status := r.statusLocked()
+	r.mu.Unlock()
+
+	r.log.Info().Str("driver", status.Driver).Dur("turn", r.opts.Turn).Msg("Rotation started")
+	r.announce("", status)
+}
+
+// Handoff ends the current turn early (or on the timer) and passes the
+// keyboard to the next driver, committing the outgoing driver's work first.
+func (r *Rotation) Handoff() {
+	r.handoff.Lock()
+	defer r.handoff.Unlock()
+
+	r.mu.Lock()
+	if r.stopped {
+		r.mu.Unlock()
+		return
+	}
+	next := (r.index + 1) % len(r.opts.Roster)
+	outgoing, incoming := r.opts.Roster[r.index], r.opts.Roster[next]
+	r.mu.Unlock()
+
+	// While the outgoing driver still holds the keyboard, so the commit
+	// credited to them has none of the incoming driver's writes
+	if r.git != nil {
+		msg := fmt.Sprintf("mob: handoff from %s to %s", outgoing, incoming)
+		if err := r.git.Checkpoint(msg, "Mob-Driver: "+outgoing); err != nil {
+			r.log.Warn().Err(err).Msg("Handoff checkpoint failed")
+		}
+	}
+
+	r.mu.Lock()
+	if r.stopped {
+		r.mu.Unlock()
+		return
+	}
+	r.index = next
+	r.startTurnLocked()
+	status := r.statusLocked()
+	r.mu.Unlock()
+
+	r.log.Info().Str("from", outgoing).Str("to", incoming).Msg("Driver handoff")
+	r.announce(outgoing, status)
+}
+
+// Stop ends the rotation, including a handoff under way.
+func (r *Rotation) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stopped = true
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+}
+
+func (r *Rotation) startTurnLocked() {
+	if r.stopped {
+		return
+	}
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	r.turnEnds = r.opts.Clock.Now().Add(r.opts.Turn)
+	r.timer = r.opts.Clock.AfterFunc(r.opts.Turn, r.Handoff)
+}
+
+func (r *Rotation) announce(previous string, status *pb.Rotation) {
+	if r.session == nil {
+		return
+	}
+	r.session.Broadcast(&pb.SessionEvent{Event: &pb.SessionEvent_DriverChange{
+		DriverChange: &pb.DriverChange{PreviousDriver: previous, Rotation: status},
+	}})
+}
+
+// Driver returns the current driver.
+func (r *Rotation) Driver() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.opts.Roster[r.index]
+}
+
+// Status describes the current turn.
+func (r *Rotation) Status() *pb.Rotation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.statusLocked()
+}
+
+func (r *Rotation) statusLocked() *pb.Rotation {
+	return &pb.Rotation{
+		Roster:       append([]string(nil), r.opts.Roster...),
+		Driver:       r.opts.Roster[r.index],
+		NextDriver:   r.opts.Roster[(r.index+1)%len(r.opts.Roster)],
+		TurnEndsUnix: r.turnEnds.Unix(),
+		TurnSeconds:  int64(r.opts.Turn / time.Second),
+		DriversOnly:  r.opts.DriversOnly,
+	}
+}
+
+// AllowWrite reports whether a client may modify the workspace right now.
+// Without DriversOnly everyone may; otherwise only the current driver. The
+// identity is the one the client sends, unverified, so this keeps honest
+// clients from typing out of turn rather than stopping anyone determined.
+func (r *Rotation) AllowWrite(id identity.Identity) error {
+	if !r.opts.DriversOnly {
+		return nil
+	}
+	driver := r.Driver()
+	if strings.EqualFold(id.Name, driver) || strings.EqualFold(id.Email, driver) {
+		return nil
+	}
+	return fmt.Errorf("read-only: %s is driving", driver)
+}
@@ -0,0 +1,201 @@
+package host_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/victorarias/blue-guy/internal/clock"
+	"github.com/victorarias/blue-guy/internal/host"
+	"github.com/victorarias/blue-guy/internal/identity"
+	pb "github.com/victorarias/blue-guy/internal/proto/gen"
+	"google.golang.org/grpc/codes"
+)
+
+type checkpoint struct {
+	message  string
+	trailers []string
+}
+
+type fakeCheckpointer struct {
+	calls  []checkpoint
+	during func() // runs inside each checkpoint, if set
+}
+
+func (f *fakeCheckpointer) Checkpoint(message string, trailers ...string) error {
+	f.calls = append(f.calls, checkpoint{message, trailers})
+	if f.during != nil {
+		f.during()
+	}
+	return nil
+}
+
+var epoch = time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+
+// newRotation returns an hour-long rotation on a fake clock, so turns only
+// end when the test advances it or hands off by hand.
+func newRotation(t *testing.T, driversOnly bool) (*host.Rotation, *host.SessionServer, *fakeCheckpointer, *clock.Fake) {
+	t.Helper()
+	session := host.NewSessionServer("abc", "mob/session-abc", zerolog.Nop())
+	cp := &fakeCheckpointer{}
+	c := clock.NewFake(epoch)
+	r, err := host.NewRotation(host.RotationOptions{
+		Roster:      []string{"alice", "bob"},
+		Turn:        time.Hour,
+		DriversOnly: driversOnly,
+		Clock:       c,
+	}, session, cp, zerolog.Nop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(r.Stop)
+	return r, session, cp, c
+}
+
+func TestRotation_HandoffCheckpointsAndAnnounces(t *testing.T) {
+	r, session, cp, _ := newRotation(t, false)
+	events := session.Subscribe()
+	r.Start()
+	<-events // initial announcement
+
+	r.Handoff()
+
+	if got := r.Driver(); got != "bob" {
+		t.Errorf("expected bob to drive, got %s", got)
+	}
+	if len(cp.calls) != 1 {
+		t.Fatalf("expected 1 checkpoint, got %d", len(cp.calls))
+	}
+	if cp.calls[0].message != "mob: handoff from alice to bob" ||
+		len(cp.calls[0].trailers) != 1 || cp.calls[0].trailers[0] != "Mob-Driver: alice" {
+		t.Errorf("unexpected checkpoint %+v", cp.calls[0])
+	}
+
+	ev := (<-events).GetDriverChange()
+	if ev == nil || ev.PreviousDriver != "alice" || ev.Rotation.Driver != "bob" || ev.Rotation.NextDriver != "alice" {
+		t.Errorf("unexpected announcement %v", ev)
+	}
+
+	r.Handoff()
+	if got := r.Driver(); got != "alice" {
+		t.Errorf("expected rotation to wrap around to alice, got %s", got)
+	}
+}
+
+func TestRotation_TurnEndsOnTime(t *testing.T) {
+	r, _, cp, c := newRotation(t, false)
+	r.Start()
+
+	c.Advance(59 * time.Minute)
+	if got := r.Driver(); got != "alice" {
+		t.Fatalf("expected alice to still drive, got %s", got)
+	}
+	c.Advance(time.Minute)
+	if got := r.Driver(); got != "bob" || len(cp.calls) != 1 {
+		t.Errorf("expected a handoff to bob after an hour, got %s with %d checkpoints", got, len(cp.calls))
+	}
+	if ends := r.Status().TurnEndsUnix; ends != epoch.Add(2*time.Hour).Unix() {
+		t.Errorf("expected the next turn to end at 11:00, got %s", time.Unix(ends, 0).UTC())
+	}
+
+	// A handoff by hand restarts the turn
+	c.Advance(30 * time.Minute)
+	r.Handoff()
+	c.Advance(59 * time.Minute)
+	if got := r.Driver(); got != "alice" || len(cp.calls) != 2 {
+		t.Errorf("expected alice's turn to run a full hour, got %s with %d checkpoints", got, len(cp.calls))
+	}
+
+	r.Stop()
+	if c.Pending() != 0 {
+		t.Error("expected Stop to cancel the turn timer")
+	}
+}
+
+func TestRotation_CheckpointsBeforeTheNextDriver(t *testing.T) {
+	r, _, cp, _ := newRotation(t, true)
+	r.Start()
+
+	// Until the outgoing driver's work is committed, the incoming one can't
+	// write anything that would end up in it
+	bob := identity.Identity{Name: "bob"}
+	var driving string
+	var bobAllowed error
+	cp.during = func() {
+		driving = r.Driver()
+		bobAllowed = r.AllowWrite(bob)
+	}
+	r.Handoff()
+
+	if driving != "alice" || bobAllowed == nil {
+		t.Errorf("expected alice still driving during the checkpoint, got %s (bob allowed: %v)", driving, bobAllowed == nil)
+	}
+	if r.Driver() != "bob" {
+		t.Errorf("expected bob to drive after, got %s", r.Driver())
+	}
+}
+
+func TestRotation_StopDuringHandoff(t *testing.T) {
+	r, _, cp, c := newRotation(t, false)
+	r.Start()
+
+	// Shutdown arrives while the handoff is committing
+	cp.during = r.Stop
+	r.Handoff()
+
+	if c.Pending() != 0 {
+		t.Error("expected no new turn after Stop")
+	}
+	c.Advance(3 * time.Hour)
+	if len(cp.calls) != 1 || r.Driver() != "alice" {
+		t.Errorf("expected the rotation to stay stopped, got %d checkpoints and %s driving", len(cp.calls), r.Driver())
+	}
+}
+
+func TestSessionServer_GetStatus(t *testing.T) {
+	r, session, _, _ := newRotation(t, true)
+	session.SetRotation(r)
+	r.Start()
+
+	st, err := session.GetStatus(context.Background(), &pb.GetStatusRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.SessionId != "abc" || st.Rotation == nil || st.Rotation.Driver != "alice" || !st.Rotation.DriversOnly {
+		t.Errorf("unexpected status %v", st)
+	}
+	if ends := st.Rotation.TurnEndsUnix; ends != epoch.Add(time.Hour).Unix() {
+		t.Errorf("expected the turn to end in an hour, got %s", time.Unix(ends, 0).UTC())
+	}
+}
+
+func TestDriversOnly_NonDriversAreReadOnly(t *testing.T) {
+	r, _, _, _ := newRotation(t, true)
+	r.Start()
+
+	s, dir := setupServer(t)
+	s.SetWritePolicy(r)
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("x"), 0644)
+
+	bob := incomingContext(identity.Identity{Name: "Bob", Email: "bob@example.com"})
+	_, err := s.WriteFile(bob, &pb.WriteFileRequest{Path: "a.txt", Data: []byte("y")})
+	assertGRPCCode(t, err, codes.PermissionDenied)
+
+	// Reads are always allowed
+	if _, err := s.ReadFile(bob, &pb.ReadFileRequest{Path: "a.txt"}); err != nil {
+		t.Errorf("non-drivers should still read: %v", err)
+	}
+
+	alice := incomingContext(identity.Identity{Name: "Alice", Email: "alice@example.com"})
+	if _, err := s.WriteFile(alice, &pb.WriteFileRequest{Path: "a.txt", Data: []byte("y")}); err != nil {
+		t.Errorf("the driver should be able to write: %v", err)
+	}
+
+	r.Handoff()
+	if _, err := s.WriteFile(bob, &pb.WriteFileRequest{Path: "a.txt", Data: []byte("z")}); err != nil {
+		t.Errorf("bob drives after handoff: %v", err)
+	}
+}
@@ -0,0 +1,37 @@
+package host
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// routed copies a generated service description so every call goes to the
+// implementation pick returns for it, rather than to one registered
+// server. That lets one gRPC server front a FileService (and friends) per
+// workspace. Register the result with a nil implementation.
+func routed(desc grpc.ServiceDesc, pick func(context.Context) (any, error)) *grpc.ServiceDesc {
+	desc.Methods = append([]grpc.MethodDesc(nil), desc.Methods...)
+	for i := range desc.Methods {
+		handler := desc.Methods[i].Handler
+		desc.Methods[i].Handler = func(_ any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+			srv, err := pick(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return handler(srv, ctx, dec, interceptor)
+		}
+	}
+	desc.Streams = append([]grpc.StreamDesc(nil), desc.Streams...)
+	for i := range desc.Streams {
+		handler := desc.Streams[i].Handler
+		desc.Streams[i].Handler = func(_ any, stream grpc.ServerStream) error {
+			srv, err := pick(stream.Context())
+			if err != nil {
+				return err
+			}
+			return handler(srv, stream)
+		}
+	}
+	return &desc
+}
@@ -0,0 +1,255 @@
+package host
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/victorarias/blue-guy/internal/gitops"
+	pb "github.com/victorarias/blue-guy/internal/proto/gen"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// SessionServer implements the gRPC SessionService: session status and
+// session-wide announcements to every connected client.
+type SessionServer struct {
+	pb.UnimplementedSessionServiceServer
+	sessionID string
+	branch    string
+	rotation  *Rotation    // nil when no rotation is configured
+	push      PushReporter // nil when git integration is off
+	log       zerolog.Logger
+
+	mu          sync.RWMutex
+	subscribers map[chan *pb.SessionEvent]struct{}
+	nextFlush   int
+	flushing    map[chan *pb.SessionEvent]string // subscriber to flush ID, while ending
+	flushed     chan struct{}                    // closed once flushing empties
+}
+
+func NewSessionServer(sessionID, branch string, log zerolog.Logger) *SessionServer {
+	return &SessionServer{
+		sessionID:   sessionID,
+		branch:      branch,
+		log:         log.With().Str("component", "session").Logger(),
+		subscribers: make(map[chan *pb.SessionEvent]struct{}),
+	}
+}
+
+// SetRotation attaches the driver rotation reported by GetStatus.
+func (s *SessionServer) SetRotation(r *Rotation) {
+	s.rotation = r
+}
+
+// PushReporter reports how pushing session commits is going. Implemented by
+// GitOps.
+type PushReporter interface {
+	PushState() gitops.PushState
+}
+
+// SetPushReporter attaches the push state reported by GetStatus.
+func (s *SessionServer) SetPushReporter(p PushReporter) {
+	s.push = p
+}
+
+// BroadcastPush announces a push state change to every client.
+func (s *SessionServer) BroadcastPush(st gitops.PushState) {
+	s.Broadcast(&pb.SessionEvent{Event: &pb.SessionEvent_Push{Push: pushStateProto(st)}})
+}
+
+// BroadcastSecrets warns every client about possible secrets in the
+// workspace, without the secrets themselves.
+func (s *SessionServer) BroadcastSecrets(r gitops.SecretReport) {
+	alert := &pb.SecretAlert{Mode: string(r.Mode), Commit: r.Commit}
+	for _, f := range r.Findings {
+		alert.Findings = append(alert.Findings, &pb.SecretFinding{
+			Path:     f.Path,
+			Line:     int32(f.Line),
+			Rule:     f.Rule,
+			Redacted: f.Redacted,
+		})
+	}
+	s.Broadcast(&pb.SessionEvent{Event: &pb.SessionEvent_Secrets{Secrets: alert}})
+}
+
+// BroadcastLargeFiles tells every client which files were too large to
+// auto-commit.
+func (s *SessionServer) BroadcastLargeFiles(files []gitops.LargeFile) {
+	lf := &pb.LargeFiles{}
+	for _, f := range files {
+		lf.Files = append(lf.Files, &pb.LargeFile{Path: f.Path, Size: f.Size, Binary: f.Binary, Lfs: f.LFS})
+	}
+	s.Broadcast(&pb.SessionEvent{Event: &pb.SessionEvent_LargeFiles{LargeFiles: lf}})
+}
+
+func pushStateProto(st gitops.PushState) *pb.PushState {
+	p := &pb.PushState{
+		Policy:    string(st.Policy),
+		Remote:    st.Remote,
+		Pending:   st.Pending,
+		LastError: st.LastError,
+		Failures:  int32(st.Failures),
+		Held:      st.Held,
+	}
+	if !st.LastPush.IsZero() {
+		p.LastPushUnix = st.LastPush.Unix()
+	}
+	if !st.NextRetry.IsZero() {
+		p.NextRetryUnix = st.NextRetry.Unix()
+	}
+	return p
+}
+
+func (s *SessionServer) GetStatus(_ context.Context, _ *pb.GetStatusRequest) (*pb.SessionStatus, error) {
+	st := &pb.SessionStatus{
+		SessionId: s.sessionID,
+		Branch:    s.branch,
+	}
+	if s.rotation != nil {
+		st.Rotation = s.rotation.Status()
+	}
+	if s.push != nil {
+		st.Push = pushStateProto(s.push.PushState())
+	}
+	return st, nil
+}
+
+func (s *SessionServer) WatchSession(_ *pb.WatchSessionRequest, stream pb.SessionService_WatchSessionServer) error {
+	ch := s.Subscribe()
+	defer s.Unsubscribe(ch)
+	// Headers go out once subscribed, so a client that waits for them
+	// knows it won't miss an event from then on
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	// Bring late joiners up to date with who is driving
+	if s.rotation != nil {
+		if err := stream.Send(&pb.SessionEvent{Event: &pb.SessionEvent_DriverChange{
+			DriverChange: &pb.DriverChange{Rotation: s.rotation.Status()},
+		}}); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case event, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// Broadcast sends an event to every subscribed client.
+func (s *SessionServer) Broadcast(event *pb.SessionEvent) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for ch := range s.subscribers {
+		select {
+		case ch <- event:
+		default:
+			s.log.Debug().Msg("dropped session event for slow subscriber")
+		}
+	}
+}
+
+func (s *SessionServer) Subscribe() chan *pb.SessionEvent {
+	ch := make(chan *pb.SessionEvent, 16)
+	s.mu.Lock()
+	s.subscribers[ch] = struct{}{}
+	s.mu.Unlock()
+	return ch
+}
+
+func (s *SessionServer) Unsubscribe(ch chan *pb.SessionEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.subscribers[ch]; ok {
+		delete(s.subscribers, ch)
+		close(ch)
+	}
+	// A client that leaves has nothing more to flush
+	if _, ok := s.flushing[ch]; ok {
+		delete(s.flushing, ch)
+		s.checkFlushed()
+	}
+}
+
+// End tells every client the session is ending, for reason, and that the
+// host will wait up to grace for them. The returned channel is closed once
+// each of them has flushed its writes or disconnected; waiting for it, and
+// for how long, is up to the caller.
+func (s *SessionServer) End(reason string, grace time.Duration) <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flushing = make(map[chan *pb.SessionEvent]string)
+	s.flushed = make(chan struct{})
+	for ch := range s.subscribers {
+		s.nextFlush++
+		id := strconv.Itoa(s.nextFlush)
+		event := &pb.SessionEvent{Event: &pb.SessionEvent_Ending{Ending: &pb.SessionEnding{
+			Reason:  reason,
+			GraceMs: grace.Milliseconds(),
+			FlushId: id,
+		}}}
+		select {
+		case ch <- event:
+			s.flushing[ch] = id
+		default:
+			s.log.Debug().Msg("dropped session ending for slow subscriber")
+		}
+	}
+	s.log.Info().Int("clients", len(s.flushing)).Str("reason", reason).Msg("Session ending; waiting for clients to flush")
+	s.checkFlushed()
+	return s.flushed
+}
+
+// Flushed records a client's answer to the session ending.
+func (s *SessionServer) Flushed(_ context.Context, req *pb.FlushedRequest) (*pb.FlushedResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch, id := range s.flushing {
+		if id == req.FlushId {
+			delete(s.flushing, ch)
+			s.checkFlushed()
+			return &pb.FlushedResponse{}, nil
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "no flush %q pending", req.FlushId)
+}
+
+// checkFlushed closes flushed once nobody is left to flush. Callers hold mu.
+func (s *SessionServer) checkFlushed() {
+	if s.flushed == nil || len(s.flushing) > 0 {
+		return
+	}
+	select {
+	case <-s.flushed:
+	default:
+		close(s.flushed)
+	}
+}
+
+// Close ends all WatchSession streams.
+func (s *SessionServer) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.subscribers {
+		delete(s.subscribers, ch)
+		close(ch)
+	}
+	clear(s.flushing)
+	s.checkFlushed()
+}
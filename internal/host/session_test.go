@@ -0,0 +1,142 @@
+package host_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/victorarias/blue-guy/internal/gitops"
+	"github.com/victorarias/blue-guy/internal/host"
+	pb "github.com/victorarias/blue-guy/internal/proto/gen"
+	"google.golang.org/grpc/codes"
+)
+
+type fakePushReporter struct {
+	state gitops.PushState
+}
+
+func (f *fakePushReporter) PushState() gitops.PushState { return f.state }
+
+func TestSessionServer_ReportsPushState(t *testing.T) {
+	session := host.NewSessionServer("abc", "mob/session-abc", zerolog.Nop())
+	retry := time.Now().Add(time.Minute)
+	session.SetPushReporter(&fakePushReporter{gitops.PushState{
+		Policy:    gitops.PushOnCommit,
+		Remote:    "origin",
+		Pending:   true,
+		LastError: "connection refused",
+		Failures:  2,
+		NextRetry: retry,
+	}})
+
+	st, err := session.GetStatus(context.Background(), &pb.GetStatusRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := st.Push
+	if p == nil || p.Policy != "commit" || !p.Pending || p.Failures != 2 || p.NextRetryUnix != retry.Unix() {
+		t.Errorf("unexpected push state %v", p)
+	}
+	if p.LastPushUnix != 0 {
+		t.Errorf("never pushed should be 0, got %d", p.LastPushUnix)
+	}
+}
+
+func TestSessionServer_BroadcastPush(t *testing.T) {
+	session := host.NewSessionServer("abc", "mob/session-abc", zerolog.Nop())
+	ch := session.Subscribe()
+	defer session.Unsubscribe(ch)
+
+	session.BroadcastPush(gitops.PushState{Remote: "origin", LastPush: time.Now()})
+
+	select {
+	case ev := <-ch:
+		if p := ev.GetPush(); p == nil || p.Remote != "origin" || p.LastPushUnix == 0 {
+			t.Errorf("unexpected event %v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event")
+	}
+}
+
+func TestSessionServer_BroadcastSecrets(t *testing.T) {
+	session := host.NewSessionServer("abc", "mob/session-abc", zerolog.Nop())
+	ch := session.Subscribe()
+	defer session.Unsubscribe(ch)
+
+	session.BroadcastSecrets(gitops.SecretReport{
+		Mode:     gitops.SecretsBlock,
+		Findings: []gitops.Finding{{Path: "config.py", Line: 2, Rule: "aws-access-key", Redacted: "AKIA****"}},
+	})
+
+	select {
+	case ev := <-ch:
+		s := ev.GetSecrets()
+		if s == nil || s.Mode != "block" || len(s.Findings) != 1 || s.Findings[0].Line != 2 {
+			t.Errorf("unexpected event %v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event")
+	}
+}
+
+func TestSessionServer_BroadcastLargeFiles(t *testing.T) {
+	session := host.NewSessionServer("abc", "mob/session-abc", zerolog.Nop())
+	ch := session.Subscribe()
+	defer session.Unsubscribe(ch)
+
+	session.BroadcastLargeFiles([]gitops.LargeFile{{Path: "data.csv", Size: 500 << 20}})
+
+	select {
+	case ev := <-ch:
+		lf := ev.GetLargeFiles()
+		if lf == nil || len(lf.Files) != 1 || lf.Files[0].Path != "data.csv" || lf.Files[0].Lfs {
+			t.Errorf("unexpected event %v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event")
+	}
+}
+
+func TestSessionServer_EndWaitsForFlushes(t *testing.T) {
+	session := host.NewSessionServer("abc", "mob/session-abc", zerolog.Nop())
+	alice, bob := session.Subscribe(), session.Subscribe()
+
+	done := session.End("lunch", time.Minute)
+	ending := func(ch chan *pb.SessionEvent) *pb.SessionEnding {
+		t.Helper()
+		select {
+		case ev := <-ch:
+			e := ev.GetEnding()
+			if e == nil || e.Reason != "lunch" || e.GraceMs != time.Minute.Milliseconds() || e.FlushId == "" {
+				t.Fatalf("unexpected event %v", ev)
+			}
+			return e
+		case <-time.After(time.Second):
+			t.Fatal("no event")
+			return nil
+		}
+	}
+	a, _ := ending(alice), ending(bob)
+
+	if _, err := session.Flushed(context.Background(), &pb.FlushedRequest{FlushId: a.FlushId}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := session.Flushed(context.Background(), &pb.FlushedRequest{FlushId: a.FlushId})
+	assertGRPCCode(t, err, codes.NotFound)
+	select {
+	case <-done:
+		t.Fatal("expected to wait for bob")
+	default:
+	}
+
+	// Leaving counts as done
+	session.Unsubscribe(bob)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected every client accounted for")
+	}
+	session.Unsubscribe(alice)
+}
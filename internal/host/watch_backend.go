@@ -0,0 +1,120 @@
+package host
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog"
+)
+
+// Watcher backend names accepted by WatcherOptions.Backend.
+const (
+	BackendFsnotify = "fsnotify"
+	BackendFanotify = "fanotify"
+)
+
+var errBackendUnsupported = errors.New("watcher backend not supported on this platform")
+
+// rawOp is the backend-independent kind of a filesystem event.
+type rawOp int
+
+const (
+	opCreate rawOp = iota + 1
+	opWrite
+	opRemove
+	opRename // the old name of a moved entry; the new name arrives as opCreate
+)
+
+// rawEvent is what backends emit: an absolute path and what happened to it.
+// The Watcher turns these into workspace-relative FileChangeEvents.
+type rawEvent struct {
+	path string
+	op   rawOp
+}
+
+// watchBackend is an OS-level source of filesystem events.
+type watchBackend interface {
+	// Add starts watching a single directory. Recursive backends ignore it.
+	Add(dir string) error
+	// Recursive reports whether the backend already covers the whole tree,
+	// including directories created later.
+	Recursive() bool
+	Events() <-chan rawEvent
+	Errors() <-chan error
+	Close() error
+}
+
+// newBackend picks the requested backend, falling back to fsnotify when
+// fanotify isn't available or permitted.
+func newBackend(root, name string, log zerolog.Logger) (watchBackend, error) {
+	switch name {
+	case "", BackendFsnotify:
+		return newFsnotifyBackend()
+	case BackendFanotify:
+		b, err := newFanotifyBackend(root)
+		if err == nil {
+			return b, nil
+		}
+		log.Warn().Err(err).Msg("fanotify unavailable, falling back to fsnotify")
+		return newFsnotifyBackend()
+	default:
+		return nil, fmt.Errorf("unknown watcher backend %q", name)
+	}
+}
+
+// fsnotifyBackend wraps fsnotify (inotify on Linux, kqueue on macOS), which
+// needs one watch per directory.
+type fsnotifyBackend struct {
+	fw     *fsnotify.Watcher
+	events chan rawEvent
+	done   chan struct{}
+}
+
+func newFsnotifyBackend() (*fsnotifyBackend, error) {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	b := &fsnotifyBackend{
+		fw:     fw,
+		events: make(chan rawEvent),
+		done:   make(chan struct{}),
+	}
+	go b.translate()
+	return b, nil
+}
+
+func (b *fsnotifyBackend) translate() {
+	defer close(b.events)
+	for event := range b.fw.Events {
+		var op rawOp
+		switch {
+		case event.Op&fsnotify.Create != 0:
+			op = opCreate
+		case event.Op&fsnotify.Write != 0:
+			op = opWrite
+		case event.Op&fsnotify.Remove != 0:
+			op = opRemove
+		case event.Op&fsnotify.Rename != 0:
+			op = opRename
+		default:
+			continue
+		}
+		select {
+		case b.events <- rawEvent{path: event.Name, op: op}:
+		case <-b.done:
+			return
+		}
+	}
+}
+
+func (b *fsnotifyBackend) Add(dir string) error    { return b.fw.Add(dir) }
+func (b *fsnotifyBackend) Recursive() bool         { return false }
+func (b *fsnotifyBackend) Events() <-chan rawEvent { return b.events }
+func (b *fsnotifyBackend) Errors() <-chan error    { return b.fw.Errors }
+
+func (b *fsnotifyBackend) Close() error {
+	close(b.done)
+	return b.fw.Close()
+}
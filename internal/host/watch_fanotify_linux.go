@@ -0,0 +1,222 @@
+//go:build linux
+
+package host
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const fanotifyMask = unix.FAN_CREATE | unix.FAN_DELETE | unix.FAN_MODIFY |
+	unix.FAN_MOVED_FROM | unix.FAN_MOVED_TO | unix.FAN_ONDIR
+
+// fanotifyBackend watches the whole filesystem containing root with a single
+// fanotify filesystem mark, so there is no per-directory setup and no race
+// with directories that are created and filled quickly. It needs
+// CAP_SYS_ADMIN (and CAP_DAC_READ_SEARCH to resolve file handles) and a
+// 5.1+ kernel.
+type fanotifyBackend struct {
+	root     string
+	realRoot string   // root with symlinks resolved, as the kernel reports it
+	file     *os.File // fanotify group fd
+	mountFD  int      // any fd on the marked filesystem, for open_by_handle_at
+	events   chan rawEvent
+	errors   chan error
+	done     chan struct{} // closed by Close, so read stops sending
+	stopped  chan struct{} // closed once read has returned
+	closing  sync.Once
+}
+
+func newFanotifyBackend(root string) (*fanotifyBackend, error) {
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, err
+	}
+
+	fd, err := unix.FanotifyInit(
+		unix.FAN_CLASS_NOTIF|unix.FAN_CLOEXEC|unix.FAN_NONBLOCK|unix.FAN_REPORT_DFID_NAME,
+		unix.O_RDONLY|unix.O_LARGEFILE,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("fanotify_init: %w", err)
+	}
+
+	if err := unix.FanotifyMark(fd, unix.FAN_MARK_ADD|unix.FAN_MARK_FILESYSTEM, fanotifyMask, unix.AT_FDCWD, root); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("fanotify_mark %s: %w", root, err)
+	}
+
+	mountFD, err := unix.Open(root, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("open %s: %w", root, err)
+	}
+
+	b := &fanotifyBackend{
+		root:     root,
+		realRoot: realRoot,
+		file:     os.NewFile(uintptr(fd), "fanotify"), // non-blocking fd: reads go through the runtime poller
+		mountFD:  mountFD,
+		events:   make(chan rawEvent, 256),
+		errors:   make(chan error, 1),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	go b.read()
+	return b, nil
+}
+
+func (b *fanotifyBackend) Add(string) error        { return nil }
+func (b *fanotifyBackend) Recursive() bool         { return true }
+func (b *fanotifyBackend) Events() <-chan rawEvent { return b.events }
+func (b *fanotifyBackend) Errors() <-chan error    { return b.errors }
+
+func (b *fanotifyBackend) Close() error {
+	var err error
+	b.closing.Do(func() {
+		close(b.done)
+		err = b.file.Close() // unblocks read
+		// read may still be resolving handles against mountFD
+		<-b.stopped
+		unix.Close(b.mountFD)
+	})
+	return err
+}
+
+func (b *fanotifyBackend) read() {
+	defer close(b.stopped)
+	defer close(b.events)
+	defer close(b.errors)
+
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := b.file.Read(buf)
+		if err != nil {
+			return // closed
+		}
+		if !b.parse(buf[:n]) {
+			return
+		}
+	}
+}
+
+// parse sends the events in buf, reporting false if the backend was closed
+// before they could all go.
+func (b *fanotifyBackend) parse(buf []byte) bool {
+	metaSize := int(unsafe.Sizeof(unix.FanotifyEventMetadata{}))
+	for len(buf) >= metaSize {
+		meta := (*unix.FanotifyEventMetadata)(unsafe.Pointer(&buf[0]))
+		if meta.Event_len < uint32(metaSize) || int(meta.Event_len) > len(buf) {
+			return true
+		}
+		event := buf[:meta.Event_len]
+		buf = buf[meta.Event_len:]
+
+		if meta.Vers != unix.FANOTIFY_METADATA_VERSION {
+			b.sendErr(fmt.Errorf("fanotify: unexpected metadata version %d", meta.Vers))
+			continue
+		}
+		if meta.Mask&unix.FAN_Q_OVERFLOW != 0 {
+			b.sendErr(fmt.Errorf("fanotify: event queue overflowed"))
+			continue
+		}
+
+		path, ok := b.resolve(event[meta.Metadata_len:])
+		if !ok || (path != b.realRoot && !strings.HasPrefix(path, b.realRoot+string(filepath.Separator))) {
+			continue // outside the workspace (the mark covers the whole filesystem)
+		}
+		path = b.root + strings.TrimPrefix(path, b.realRoot)
+
+		var op rawOp
+		switch {
+		case meta.Mask&(unix.FAN_CREATE|unix.FAN_MOVED_TO) != 0:
+			op = opCreate
+		case meta.Mask&unix.FAN_MODIFY != 0:
+			op = opWrite
+		case meta.Mask&unix.FAN_DELETE != 0:
+			op = opRemove
+		case meta.Mask&unix.FAN_MOVED_FROM != 0:
+			op = opRename
+		default:
+			continue
+		}
+		select {
+		case b.events <- rawEvent{path: path, op: op}:
+		case <-b.done:
+			return false
+		}
+	}
+	return true
+}
+
+// resolve decodes a DFID_NAME info record (parent directory file handle plus
+// entry name) into an absolute path.
+func (b *fanotifyBackend) resolve(info []byte) (string, bool) {
+	// struct fanotify_event_info_header { u8 type; u8 pad; u16 len; }
+	const headerLen = 4
+	// __kernel_fsid_t precedes the file handle
+	const fsidLen = 8
+	// struct file_handle { u32 handle_bytes; s32 handle_type; u8 f_handle[]; }
+	const handleHeaderLen = 8
+
+	for len(info) >= headerLen {
+		infoType := info[0]
+		infoLen := int(binary.NativeEndian.Uint16(info[2:4]))
+		if infoLen < headerLen || infoLen > len(info) {
+			return "", false
+		}
+		record := info[:infoLen]
+		info = info[infoLen:]
+
+		if infoType != unix.FAN_EVENT_INFO_TYPE_DFID_NAME {
+			continue
+		}
+		fh := record[headerLen+fsidLen:]
+		if len(fh) < handleHeaderLen {
+			return "", false
+		}
+		handleBytes := int(binary.NativeEndian.Uint32(fh[0:4]))
+		handleType := int32(binary.NativeEndian.Uint32(fh[4:8]))
+		if len(fh) < handleHeaderLen+handleBytes {
+			return "", false
+		}
+		name := fh[handleHeaderLen+handleBytes:]
+		if i := bytes.IndexByte(name, 0); i >= 0 {
+			name = name[:i]
+		}
+
+		dir, err := b.handlePath(unix.NewFileHandle(handleType, fh[handleHeaderLen:handleHeaderLen+handleBytes]))
+		if err != nil {
+			return "", false // parent already gone
+		}
+		if len(name) == 0 || string(name) == "." {
+			return dir, true
+		}
+		return filepath.Join(dir, string(name)), true
+	}
+	return "", false
+}
+
+func (b *fanotifyBackend) handlePath(h unix.FileHandle) (string, error) {
+	fd, err := unix.OpenByHandleAt(b.mountFD, h, unix.O_PATH|unix.O_CLOEXEC)
+	if err != nil {
+		return "", err
+	}
+	defer unix.Close(fd)
+	return os.Readlink(fmt.Sprintf("/proc/self/fd/%d", fd))
+}
+
+func (b *fanotifyBackend) sendErr(err error) {
+	select {
+	case b.errors <- err:
+	default:
+	}
+}
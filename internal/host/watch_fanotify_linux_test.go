@@ -0,0 +1,71 @@
+//go:build linux
+
+package host
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFanotifyBackend_ReportsNestedCreates(t *testing.T) {
+	root := t.TempDir()
+	b, err := newFanotifyBackend(root)
+	if err != nil {
+		t.Skipf("fanotify not available here: %v", err)
+	}
+	defer b.Close()
+
+	// No per-directory setup: a tree created in one go is fully reported
+	nested := filepath.Join(root, "a", "b")
+	os.MkdirAll(nested, 0755)
+	target := filepath.Join(nested, "c.txt")
+	os.WriteFile(target, []byte("hi"), 0644)
+
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case ev := <-b.Events():
+			if ev.path == target && ev.op == opCreate {
+				return
+			}
+		case <-deadline:
+			t.Fatalf("no create event for %s", target)
+		}
+	}
+}
+
+func TestFanotifyBackend_CloseWithoutAReader(t *testing.T) {
+	root := t.TempDir()
+	b, err := newFanotifyBackend(root)
+	if err != nil {
+		t.Skipf("fanotify not available here: %v", err)
+	}
+
+	// More events than the channel holds, with nobody reading them
+	for i := range 300 {
+		os.WriteFile(filepath.Join(root, fmt.Sprintf("f%d.txt", i)), []byte("hi"), 0644)
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		b.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close hung on a reader nobody was draining")
+	}
+	// The reader has stopped, so what's buffered is all there is
+	for n := 0; ; n++ {
+		if _, ok := <-b.Events(); !ok {
+			break
+		}
+		if n > cap(b.events) {
+			t.Fatal("events still coming after Close")
+		}
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !linux
+
+package host
+
+func newFanotifyBackend(string) (watchBackend, error) {
+	return nil, errBackendUnsupported
+}
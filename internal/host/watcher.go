@@ -1,50 +1,90 @@
 package host
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"syscall"
+	"time"
 
-	"github.com/fsnotify/fsnotify"
 	"github.com/rs/zerolog"
+	"github.com/victorarias/blue-guy/internal/clock"
 	pb "github.com/victorarias/blue-guy/internal/proto/gen"
 )
 
+// WatcherOptions tunes the watcher.
+type WatcherOptions struct {
+	// Backend selects the OS event source: BackendFsnotify (default) or
+	// BackendFanotify (Linux only, falls back to fsnotify if not permitted).
+	Backend string
+	// PollInterval is how often subtrees that couldn't be watched natively
+	// are rescanned. Defaults to 2s.
+	PollInterval time.Duration
+	// SubscriberBuffer is how many events a subscriber can fall behind by
+	// before events are dropped for it. Defaults to 64.
+	SubscriberBuffer int
+	// Clock times the rescans. nil means the system clock.
+	Clock clock.Clock
+}
+
+const defaultSubscriberBuffer = 64
+
 // Watcher monitors filesystem changes and broadcasts them to subscribers.
 type Watcher struct {
 	root    string
-	watcher *fsnotify.Watcher
+	backend watchBackend
 	log     zerolog.Logger
 
+	buffer int
+
+	// poller covers subtrees that overflowed the OS watch limit.
+	poller        *Poller
+	exhaustedOnce sync.Once
+
 	mu          sync.RWMutex
 	subscribers map[chan *pb.FileChangeEvent]struct{}
 }
 
-func NewWatcher(root string, log zerolog.Logger) (*Watcher, error) {
-	fw, err := fsnotify.NewWatcher()
+func NewWatcher(root string, opts WatcherOptions, log zerolog.Logger) (*Watcher, error) {
+	backend, err := newBackend(root, opts.Backend, log)
 	if err != nil {
 		return nil, err
 	}
 
-	w := &Watcher{
-		root:        root,
-		watcher:     fw,
-		log:         log.With().Str("component", "watcher").Logger(),
-		subscribers: make(map[chan *pb.FileChangeEvent]struct{}),
-	}
-
-	// Add the root directory. fsnotify watches directories non-recursively,
-	// so we walk and add each subdirectory.
+	w := newWatcher(root, backend, opts, log)
 	if err := w.addRecursive(root); err != nil {
-		fw.Close()
+		backend.Close()
 		return nil, err
 	}
 
 	return w, nil
 }
 
+func newWatcher(root string, backend watchBackend, opts WatcherOptions, log zerolog.Logger) *Watcher {
+	if opts.SubscriberBuffer <= 0 {
+		opts.SubscriberBuffer = defaultSubscriberBuffer
+	}
+	w := &Watcher{
+		root:        root,
+		backend:     backend,
+		log:         log.With().Str("component", "watcher").Logger(),
+		buffer:      opts.SubscriberBuffer,
+		subscribers: make(map[chan *pb.FileChangeEvent]struct{}),
+	}
+	w.poller = NewPoller(opts.PollInterval, w.emit)
+	w.poller.clock = clock.Or(opts.Clock)
+	return w
+}
+
+// addRecursive registers dir and every non-hidden directory below it with
+// the backend. fsnotify watches directories non-recursively, so we walk and
+// add each subdirectory; recursive backends already cover the tree.
 func (w *Watcher) addRecursive(dir string) error {
+	if w.backend.Recursive() {
+		return nil
+	}
 	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return nil // skip inaccessible paths
@@ -55,62 +95,155 @@ func (w *Watcher) addRecursive(dir string) error {
 			if name != "." && strings.HasPrefix(name, ".") {
 				return filepath.SkipDir
 			}
-			return w.watcher.Add(path)
+			if err := w.backend.Add(path); err != nil {
+				if !isWatchLimitErr(err) {
+					return err
+				}
+				// Everything below this directory is polled instead.
+				w.fallBackToPolling(path, err)
+				return filepath.SkipDir
+			}
 		}
 		return nil
 	})
 }
 
+// isWatchLimitErr reports whether err means the OS ran out of watch
+// descriptors (inotify max_user_watches on Linux, open files with kqueue).
+func isWatchLimitErr(err error) bool {
+	return errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.EMFILE)
+}
+
+func (w *Watcher) fallBackToPolling(dir string, err error) {
+	w.exhaustedOnce.Do(func() {
+		w.log.Warn().Err(err).
+			Dur("interval", w.poller.interval).
+			Msg("OS watch limit reached; polling remaining directories instead (raise fs.inotify.max_user_watches to avoid this)")
+	})
+	w.log.Debug().Str("dir", dir).Msg("polling subtree")
+	w.poller.AddRoot(dir)
+}
+
 // Run starts the event loop. Blocks until the watcher is closed.
 func (w *Watcher) Run() {
+	go w.poller.Run()
+
+	events, errs := w.backend.Events(), w.backend.Errors()
 	for {
 		select {
-		case event, ok := <-w.watcher.Events:
+		case event, ok := <-events:
 			if !ok {
 				return
 			}
 			w.handleEvent(event)
-		case err, ok := <-w.watcher.Errors:
+		case err, ok := <-errs:
 			if !ok {
 				return
 			}
-			w.log.Warn().Err(err).Msg("fsnotify error")
+			w.log.Warn().Err(err).Msg("watcher backend error")
 		}
 	}
 }
 
-func (w *Watcher) handleEvent(event fsnotify.Event) {
-	rel, err := filepath.Rel(w.root, event.Name)
-	if err != nil {
+// handleEvent normalises a backend event into a FileChangeEvent.
+func (w *Watcher) handleEvent(event rawEvent) {
+	rel, err := filepath.Rel(w.root, event.path)
+	if err != nil || inHiddenDir(rel) {
 		return
 	}
-	rel = "/" + rel
 
 	var changeType pb.ChangeType
-	switch {
-	case event.Op&fsnotify.Create != 0:
+	switch event.op {
+	case opCreate:
 		changeType = pb.ChangeType_CHANGE_TYPE_CREATED
-		// If a new non-hidden directory was created, watch it too
-		name := filepath.Base(event.Name)
-		if !strings.HasPrefix(name, ".") {
-			w.watcher.Add(event.Name) // no-op if it's a file
-		}
-	case event.Op&fsnotify.Write != 0:
+	case opWrite:
 		changeType = pb.ChangeType_CHANGE_TYPE_MODIFIED
-	case event.Op&fsnotify.Remove != 0:
+	case opRemove:
 		changeType = pb.ChangeType_CHANGE_TYPE_DELETED
-	case event.Op&fsnotify.Rename != 0:
+	case opRename:
 		changeType = pb.ChangeType_CHANGE_TYPE_RENAMED
 	default:
 		return
 	}
 
-	change := &pb.FileChangeEvent{
-		Path: rel,
-		Type: changeType,
+	w.emit(event.path, changeType)
+
+	if event.op == opCreate && !w.backend.Recursive() {
+		w.watchNewDir(event.path)
+	}
+}
+
+// watchNewDir starts watching a freshly created directory. Anything created
+// inside it before the watch was registered would otherwise be missed, so
+// the new tree is rescanned and its contents reported as created. Clients
+// may see a duplicate CREATED for files that raced with the watch, which is
+// harmless.
+func (w *Watcher) watchNewDir(dir string) {
+	if strings.HasPrefix(filepath.Base(dir), ".") {
+		return
+	}
+	info, err := os.Stat(dir)
+	if err != nil || !info.IsDir() {
+		return
+	}
+
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if path != dir {
+			w.emit(path, pb.ChangeType_CHANGE_TYPE_CREATED)
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if path != dir && strings.HasPrefix(info.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if err := w.backend.Add(path); err != nil {
+			if isWatchLimitErr(err) {
+				w.fallBackToPolling(path, err)
+			}
+			return filepath.SkipDir
+		}
+		return nil
+	})
+}
+
+// inHiddenDir reports whether a workspace-relative path lies inside a hidden
+// directory such as .git. Hidden files directly in a visible directory are
+// still reported.
+func inHiddenDir(rel string) bool {
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for _, p := range parts[:len(parts)-1] {
+		if strings.HasPrefix(p, ".") && p != "." && p != ".." {
+			return true
+		}
 	}
+	return false
+}
 
-	w.broadcast(change)
+// emit converts an absolute path into a workspace-relative change event and
+// broadcasts it.
+func (w *Watcher) emit(path string, changeType pb.ChangeType) {
+	rel, err := filepath.Rel(w.root, path)
+	if err != nil {
+		return
+	}
+
+	w.broadcast(&pb.FileChangeEvent{
+		Path: "/" + rel,
+		Type: changeType,
+	})
+}
+
+// Notify broadcasts a change the host made itself (a rollback, say) without
+// waiting for the OS to report it. path is workspace-relative.
+func (w *Watcher) Notify(path string, changeType pb.ChangeType) {
+	w.broadcast(&pb.FileChangeEvent{
+		Path: "/" + strings.TrimPrefix(path, "/"),
+		Type: changeType,
+	})
 }
 
 func (w *Watcher) broadcast(event *pb.FileChangeEvent) {
@@ -130,7 +263,7 @@ func (w *Watcher) broadcast(event *pb.FileChangeEvent) {
 // Subscribe returns a channel that receives change events.
 // Call Unsubscribe to stop receiving and clean up.
 func (w *Watcher) Subscribe() chan *pb.FileChangeEvent {
-	ch := make(chan *pb.FileChangeEvent, 64)
+	ch := make(chan *pb.FileChangeEvent, w.buffer)
 	w.mu.Lock()
 	w.subscribers[ch] = struct{}{}
 	w.mu.Unlock()
@@ -139,13 +272,17 @@ func (w *Watcher) Subscribe() chan *pb.FileChangeEvent {
 
 func (w *Watcher) Unsubscribe(ch chan *pb.FileChangeEvent) {
 	w.mu.Lock()
-	delete(w.subscribers, ch)
-	w.mu.Unlock()
-	close(ch)
+	defer w.mu.Unlock()
+	// Close may already have closed it
+	if _, ok := w.subscribers[ch]; ok {
+		delete(w.subscribers, ch)
+		close(ch)
+	}
 }
 
 func (w *Watcher) Close() error {
-	err := w.watcher.Close()
+	w.poller.Close()
+	err := w.backend.Close()
 	// Close all subscriber channels so blocked readers unblock
 	w.mu.Lock()
 	for ch := range w.subscribers {
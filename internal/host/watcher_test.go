@@ -0,0 +1,245 @@
+package host
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/rs/zerolog"
+	pb "github.com/victorarias/blue-guy/internal/proto/gen"
+)
+
+// fakeBackend records Add calls and lets tests inject events directly.
+type fakeBackend struct {
+	mu        sync.Mutex
+	added     []string
+	add       func(dir string) error
+	recursive bool
+	events    chan rawEvent
+	errors    chan error
+}
+
+func newFakeBackend(add func(string) error) *fakeBackend {
+	return &fakeBackend{
+		add:    add,
+		events: make(chan rawEvent),
+		errors: make(chan error),
+	}
+}
+
+func (b *fakeBackend) Add(dir string) error {
+	b.mu.Lock()
+	b.added = append(b.added, dir)
+	b.mu.Unlock()
+	if b.add != nil {
+		return b.add(dir)
+	}
+	return nil
+}
+
+func (b *fakeBackend) Recursive() bool         { return b.recursive }
+func (b *fakeBackend) Events() <-chan rawEvent { return b.events }
+func (b *fakeBackend) Errors() <-chan error    { return b.errors }
+func (b *fakeBackend) Close() error            { return nil }
+
+func (b *fakeBackend) addedDirs() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.added...)
+}
+
+// newTestWatcher builds a Watcher whose OS watch registration fails with
+// ENOSPC for any directory under one of the exhausted paths.
+func newTestWatcher(t *testing.T, root string, exhausted ...string) (*Watcher, error) {
+	t.Helper()
+	backend := newFakeBackend(func(path string) error {
+		for _, e := range exhausted {
+			if path == e || strings.HasPrefix(path, e+string(filepath.Separator)) {
+				return fmt.Errorf("add %s: %w", path, syscall.ENOSPC)
+			}
+		}
+		return nil
+	})
+
+	w := newWatcher(root, backend, WatcherOptions{}, zerolog.Nop())
+	return w, w.addRecursive(root)
+}
+
+func collect(t *testing.T, w *Watcher) func() map[string]pb.ChangeType {
+	t.Helper()
+	ch := w.Subscribe()
+	return func() map[string]pb.ChangeType {
+		got := make(map[string]pb.ChangeType)
+		for {
+			select {
+			case ev := <-ch:
+				got[ev.Path] = ev.Type
+			default:
+				return got
+			}
+		}
+	}
+}
+
+func TestWatcher_FallsBackToPollingOnWatchLimit(t *testing.T) {
+	root := t.TempDir()
+	big := filepath.Join(root, "big")
+	os.MkdirAll(filepath.Join(big, "nested"), 0755)
+	os.Mkdir(filepath.Join(root, "small"), 0755)
+
+	w, err := newTestWatcher(t, root, big)
+	if err != nil {
+		t.Fatalf("watch exhaustion should not abort the watcher: %v", err)
+	}
+
+	roots := w.poller.Roots()
+	if len(roots) != 1 || roots[0] != big {
+		t.Errorf("expected only %s to be polled, got %v", big, roots)
+	}
+}
+
+func TestWatcher_OtherAddErrorsStillFail(t *testing.T) {
+	root := t.TempDir()
+	boom := errors.New("boom")
+	w := newWatcher(root, newFakeBackend(func(string) error { return boom }), WatcherOptions{}, zerolog.Nop())
+	if err := w.addRecursive(root); !errors.Is(err, boom) {
+		t.Errorf("expected boom, got %v", err)
+	}
+}
+
+func TestWatcher_PolledSubtreeReportsChanges(t *testing.T) {
+	root := t.TempDir()
+	big := filepath.Join(root, "big")
+	os.MkdirAll(filepath.Join(big, "nested"), 0755)
+	os.WriteFile(filepath.Join(big, "edit.txt"), []byte("a"), 0644)
+	os.WriteFile(filepath.Join(big, "nested", "gone.txt"), []byte("x"), 0644)
+
+	w, err := newTestWatcher(t, root, big)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := collect(t, w)
+
+	os.WriteFile(filepath.Join(big, "new.txt"), []byte("hi"), 0644)
+	os.WriteFile(filepath.Join(big, "edit.txt"), []byte("abc"), 0644)
+	os.Remove(filepath.Join(big, "nested", "gone.txt"))
+	w.poller.Scan()
+
+	want := map[string]pb.ChangeType{
+		"/big/new.txt":         pb.ChangeType_CHANGE_TYPE_CREATED,
+		"/big/edit.txt":        pb.ChangeType_CHANGE_TYPE_MODIFIED,
+		"/big/nested/gone.txt": pb.ChangeType_CHANGE_TYPE_DELETED,
+	}
+	got := events()
+	for path, typ := range want {
+		if got[path] != typ {
+			t.Errorf("%s: got %v, want %v", path, got[path], typ)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("unexpected extra events: %v", got)
+	}
+
+	// A second scan with no changes is silent
+	w.poller.Scan()
+	if got := events(); len(got) != 0 {
+		t.Errorf("expected no events on idle scan, got %v", got)
+	}
+}
+
+func TestPoller_SkipsHiddenDirectories(t *testing.T) {
+	root := t.TempDir()
+	os.Mkdir(filepath.Join(root, ".git"), 0755)
+
+	var got []string
+	p := NewPoller(0, func(path string, _ pb.ChangeType) { got = append(got, path) })
+	p.AddRoot(root)
+
+	os.WriteFile(filepath.Join(root, ".git", "index"), []byte("x"), 0644)
+	p.Scan()
+
+	if len(got) != 0 {
+		t.Errorf("expected hidden directory to be ignored, got %v", got)
+	}
+}
+
+func TestWatcher_RescansNewDirectories(t *testing.T) {
+	root := t.TempDir()
+	backend := newFakeBackend(nil)
+	w := newWatcher(root, backend, WatcherOptions{}, zerolog.Nop())
+	events := collect(t, w)
+
+	// Simulate a tree that was filled before the watch on "gen" existed
+	gen := filepath.Join(root, "gen")
+	os.MkdirAll(filepath.Join(gen, "pkg"), 0755)
+	os.MkdirAll(filepath.Join(gen, ".cache"), 0755)
+	os.WriteFile(filepath.Join(gen, "pkg", "a.go"), []byte("package pkg"), 0644)
+	os.WriteFile(filepath.Join(gen, ".cache", "blob"), []byte("x"), 0644)
+
+	w.handleEvent(rawEvent{path: gen, op: opCreate})
+
+	got := events()
+	for _, path := range []string{"/gen", "/gen/pkg", "/gen/pkg/a.go", "/gen/.cache"} {
+		if got[path] != pb.ChangeType_CHANGE_TYPE_CREATED {
+			t.Errorf("expected CREATED for %s, got %v", path, got[path])
+		}
+	}
+	if _, ok := got["/gen/.cache/blob"]; ok {
+		t.Error("contents of hidden directories should not be reported")
+	}
+
+	added := backend.addedDirs()
+	if len(added) != 2 || added[0] != gen || added[1] != filepath.Join(gen, "pkg") {
+		t.Errorf("expected watches on gen and gen/pkg, got %v", added)
+	}
+}
+
+func TestWatcher_RecursiveBackendSkipsPerDirectoryWork(t *testing.T) {
+	root := t.TempDir()
+	os.Mkdir(filepath.Join(root, "sub"), 0755)
+	backend := newFakeBackend(nil)
+	backend.recursive = true
+
+	w := newWatcher(root, backend, WatcherOptions{}, zerolog.Nop())
+	if err := w.addRecursive(root); err != nil {
+		t.Fatal(err)
+	}
+	events := collect(t, w)
+
+	w.handleEvent(rawEvent{path: filepath.Join(root, "sub"), op: opCreate})
+	w.handleEvent(rawEvent{path: filepath.Join(root, ".git", "index"), op: opWrite})
+	w.handleEvent(rawEvent{path: filepath.Join(root, "sub", ".env"), op: opWrite})
+
+	if added := backend.addedDirs(); len(added) != 0 {
+		t.Errorf("recursive backend should not get per-directory watches, got %v", added)
+	}
+	got := events()
+	if got["/sub"] != pb.ChangeType_CHANGE_TYPE_CREATED {
+		t.Errorf("expected CREATED for /sub, got %v", got["/sub"])
+	}
+	if got["/sub/.env"] != pb.ChangeType_CHANGE_TYPE_MODIFIED {
+		t.Errorf("hidden files in visible directories should be reported, got %v", got["/sub/.env"])
+	}
+	if _, ok := got["/.git/index"]; ok {
+		t.Error("events inside .git should be filtered")
+	}
+}
+
+func TestWatcher_UnsubscribeAfterClose(t *testing.T) {
+	w, err := NewWatcher(t.TempDir(), WatcherOptions{}, zerolog.Nop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch := w.Subscribe()
+	w.Close()
+	if _, ok := <-ch; ok {
+		t.Fatal("expected Close to close the channel")
+	}
+	// A stream ending after shutdown unsubscribes late; that mustn't panic
+	w.Unsubscribe(ch)
+}
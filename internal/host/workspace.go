@@ -0,0 +1,267 @@
+package host
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/victorarias/blue-guy/internal/gitops"
+	pb "github.com/victorarias/blue-guy/internal/proto/gen"
+	"github.com/victorarias/blue-guy/internal/workspace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Workspace is one directory a host shares, with its own watcher, file
+// server and git session.
+type Workspace struct {
+	name       string
+	root       string
+	repo       string // top of the git working tree, empty outside one
+	watcher    *Watcher
+	git        *gitops.GitOps
+	fileServer *FileServer
+	gitServer  *GitServer
+	session    *SessionServer
+	rotation   *Rotation
+	log        zerolog.Logger
+}
+
+func (w *Workspace) Name() string     { return w.name }
+func (w *Workspace) Root() string     { return w.root }
+func (w *Workspace) GitEnabled() bool { return w.git != nil }
+
+// start brings up the workspace's watcher, git session and servers.
+func (w *Workspace) start(ctx context.Context, sessionID string, opts Options) error {
+	watcher, err := NewWatcher(w.root, WatcherOptions{
+		Backend:          opts.WatcherBackend,
+		PollInterval:     opts.PollInterval,
+		SubscriberBuffer: opts.SubscriberBuffer,
+		Clock:            opts.Clock,
+	}, w.log)
+	if err != nil {
+		return fmt.Errorf("start watcher: %w", err)
+	}
+	w.watcher = watcher
+	go w.watcher.Run()
+
+	// Start git integration
+	if opts.Git.Clock == nil {
+		opts.Git.Clock = opts.Clock
+	}
+	g, err := gitops.New(w.root, sessionID, opts.Git, w.log)
+	if err != nil {
+		w.log.Warn().Err(err).Msg("Git integration disabled (not a git repo?)")
+	} else {
+		w.git = g
+		if err := w.git.Start(ctx); err != nil {
+			// A repository that can't take the session is the user's call to
+			// resolve, not a reason to silently run without history
+			w.watcher.Close()
+			return err
+		}
+	}
+
+	// Wire watcher events to git auto-commit
+	if w.git != nil {
+		changeCh := w.watcher.Subscribe()
+		go func() {
+			for ev := range changeCh {
+				w.git.NotifyChangeOf(changeKind(ev.GetType()))
+			}
+		}()
+	}
+
+	w.fileServer = NewFileServer(w.root, w.watcher)
+	if opts.MaxReadSize > 0 {
+		w.fileServer.SetMaxReadSize(opts.MaxReadSize)
+	}
+	if w.git != nil {
+		w.fileServer.SetRecorder(w.git)
+	}
+	w.gitServer = NewGitServer(w.git, w.watcher)
+	w.session = NewSessionServer(sessionID, "mob/session-"+sessionID, w.log)
+	if w.git != nil {
+		w.session.SetPushReporter(w.git)
+		w.git.OnPushState(w.session.BroadcastPush)
+		w.git.OnSecrets(w.session.BroadcastSecrets)
+		w.git.OnLargeFiles(w.session.BroadcastLargeFiles)
+	}
+	if opts.Rotation.Turn > 0 {
+		var cp Checkpointer
+		if w.git != nil {
+			cp = w.git
+		}
+		if opts.Rotation.Clock == nil {
+			opts.Rotation.Clock = opts.Clock
+		}
+		rot, err := NewRotation(opts.Rotation, w.session, cp, w.log)
+		if err != nil {
+			w.stop()
+			return fmt.Errorf("rotation: %w", err)
+		}
+		w.rotation = rot
+		w.session.SetRotation(rot)
+		w.fileServer.SetWritePolicy(rot)
+		w.gitServer.SetWritePolicy(rot)
+	}
+	return nil
+}
+
+// stop ends the workspace's session: no more client writes, rotation, a
+// final commit, and the watcher and session event streams.
+func (w *Workspace) stop() {
+	// Late writes would miss the final commit and land on whatever branch
+	// is checked out after it
+	if w.fileServer != nil {
+		w.fileServer.StopWrites()
+	}
+	if w.gitServer != nil {
+		w.gitServer.StopWrites()
+	}
+	if w.rotation != nil {
+		w.rotation.Stop()
+	}
+	if w.git != nil {
+		w.git.Stop()
+	}
+	w.watcher.Close()
+	if w.session != nil {
+		w.session.Close()
+	}
+}
+
+// reconfigure applies the settings that can change mid-session.
+func (w *Workspace) reconfigure(opts Options) {
+	if w.git != nil {
+		w.git.Reconfigure(opts.Git)
+	}
+	if w.fileServer != nil {
+		maxRead := opts.MaxReadSize
+		if maxRead <= 0 {
+			maxRead = defaultMaxReadSize
+		}
+		w.fileServer.SetMaxReadSize(maxRead)
+	}
+}
+
+// changeKind is the auto-commit's view of a watcher event.
+func changeKind(t pb.ChangeType) gitops.ChangeKind {
+	switch t {
+	case pb.ChangeType_CHANGE_TYPE_CREATED, pb.ChangeType_CHANGE_TYPE_DELETED, pb.ChangeType_CHANGE_TYPE_RENAMED:
+		return gitops.ChangeStructure
+	}
+	return gitops.ChangeEdit
+}
+
+// info describes the workspace, including its root only if withRoot.
+func (w *Workspace) info(ctx context.Context, withRoot bool) *pb.WorkspaceInfo {
+	wi := &pb.WorkspaceInfo{Name: w.name}
+	if withRoot {
+		wi.Root = w.root
+	}
+	wi.Session, _ = w.session.GetStatus(ctx, &pb.GetStatusRequest{})
+	return wi
+}
+
+// AddWorkspace shares root under name, which defaults to the directory's
+// own name. Call it before Start.
+func (h *Host) AddWorkspace(name, root string) error {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return fmt.Errorf("resolve root: %w", err)
+	}
+	info, err := os.Stat(absRoot)
+	if err != nil || !info.IsDir() {
+		return fmt.Errorf("root %q is not a directory", absRoot)
+	}
+	if name == "" {
+		name = filepath.Base(absRoot)
+	}
+	if err := workspace.Check(name); err != nil {
+		return err
+	}
+	// Each workspace checks out its own session branch, so two in one
+	// repository would fight over HEAD and one would stop committing
+	repo := gitops.RepoRoot(absRoot)
+	for _, w := range h.workspaces {
+		if w.name == name {
+			return fmt.Errorf("two workspaces named %q", name)
+		}
+		if w.root == absRoot {
+			return fmt.Errorf("%s is shared twice", absRoot)
+		}
+		if repo != "" && w.repo == repo {
+			return fmt.Errorf("%s and %s are in the same repository (%s); share it as one workspace", w.root, absRoot, repo)
+		}
+	}
+	h.workspaces = append(h.workspaces, &Workspace{
+		name: name,
+		root: absRoot,
+		repo: repo,
+		log:  h.log.With().Str("workspace", name).Logger(),
+	})
+	return nil
+}
+
+// Workspaces lists the shared workspaces in the order they were added.
+func (h *Host) Workspaces() []*Workspace {
+	return h.workspaces
+}
+
+// workspaceFor picks the workspace a client call is for. Calls that don't
+// say get the only workspace, if there is just one.
+func (h *Host) workspaceFor(ctx context.Context) (*Workspace, error) {
+	name := workspace.FromIncomingContext(ctx)
+	if name == "" {
+		if len(h.workspaces) == 1 {
+			return h.workspaces[0], nil
+		}
+		return nil, status.Errorf(codes.InvalidArgument, "this host shares several workspaces (%s); say which", strings.Join(h.workspaceNames(), ", "))
+	}
+	for _, w := range h.workspaces {
+		if w.name == name {
+			return w, nil
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "no workspace %q (this host shares %s)", name, strings.Join(h.workspaceNames(), ", "))
+}
+
+// workspaceAt finds a workspace by name or by a path inside its root, or
+// the only one when which is empty or matches nothing.
+func (h *Host) workspaceAt(which string) (*Workspace, error) {
+	for _, w := range h.workspaces {
+		if which == w.name || which == w.root || strings.HasPrefix(which, w.root+string(filepath.Separator)) {
+			return w, nil
+		}
+	}
+	if len(h.workspaces) == 1 {
+		return h.workspaces[0], nil
+	}
+	return nil, status.Errorf(codes.InvalidArgument, "this host shares several workspaces (%s); say which", strings.Join(h.workspaceNames(), ", "))
+}
+
+func (h *Host) workspaceNames() []string {
+	names := make([]string, len(h.workspaces))
+	for i, w := range h.workspaces {
+		names[i] = w.name
+	}
+	return names
+}
+
+// WorkspaceServer implements the gRPC WorkspaceService.
+type WorkspaceServer struct {
+	pb.UnimplementedWorkspaceServiceServer
+	h *Host
+}
+
+func (s *WorkspaceServer) ListWorkspaces(ctx context.Context, _ *pb.ListWorkspacesRequest) (*pb.ListWorkspacesResponse, error) {
+	resp := &pb.ListWorkspacesResponse{}
+	for _, w := range s.h.workspaces {
+		resp.Workspaces = append(resp.Workspaces, w.info(ctx, false))
+	}
+	return resp, nil
+}
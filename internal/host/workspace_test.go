@@ -0,0 +1,116 @@
+package host_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/victorarias/blue-guy/internal/host"
+	"github.com/victorarias/blue-guy/internal/identity"
+	pb "github.com/victorarias/blue-guy/internal/proto/gen"
+	"github.com/victorarias/blue-guy/internal/workspace"
+	"google.golang.org/grpc/codes"
+)
+
+func TestAddWorkspace_Validates(t *testing.T) {
+	h := host.New(0, "abc", host.Options{})
+	dir := t.TempDir()
+	if err := h.AddWorkspace("a/b", dir); err == nil {
+		t.Error("expected a name with a slash refused")
+	}
+	if err := h.AddWorkspace("", filepath.Join(dir, "missing")); err == nil {
+		t.Error("expected a missing root refused")
+	}
+	if err := h.AddWorkspace("", dir); err != nil {
+		t.Fatal(err)
+	}
+	if got := h.Workspaces()[0].Name(); got != filepath.Base(dir) {
+		t.Errorf("expected the name to default to the directory's, got %q", got)
+	}
+	if err := h.AddWorkspace("", dir); err == nil {
+		t.Error("expected the same workspace refused twice")
+	}
+}
+
+func TestAddWorkspace_OneWorkspacePerRepository(t *testing.T) {
+	repo := initGitRepo(t)
+	for _, sub := range []string{"app", "lib"} {
+		if err := os.Mkdir(filepath.Join(repo, sub), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := host.New(0, "abc", host.Options{})
+	if err := h.AddWorkspace("", filepath.Join(repo, "app")); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.AddWorkspace("", filepath.Join(repo, "lib")); err == nil {
+		t.Error("expected a second workspace in the same repository refused")
+	}
+	if err := h.AddWorkspace("", t.TempDir()); err != nil {
+		t.Errorf("expected a directory outside any repository accepted, got %v", err)
+	}
+	if err := h.AddWorkspace("", t.TempDir()); err != nil {
+		t.Errorf("expected a second directory outside any repository accepted, got %v", err)
+	}
+}
+
+func TestWorkspaces_RouteByName(t *testing.T) {
+	other := initGitRepo(t)
+	dir, ctl, _ := startHost(t, func(h *host.Host) {
+		if err := h.AddWorkspace("other", other); err != nil {
+			t.Fatal(err)
+		}
+	})
+	ctx := context.Background()
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("first"), 0644)
+	os.WriteFile(filepath.Join(other, "a.txt"), []byte("second"), 0644)
+
+	st, err := ctl.Status(ctx, &pb.ControlStatusRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(st.Workspaces) != 2 || st.Workspace != "" {
+		t.Fatalf("expected two workspaces and no top-level one, got %v", st)
+	}
+
+	conn := dialAs(t, st.Addr, identity.Identity{Name: "Alice"})
+	list, err := pb.NewWorkspaceServiceClient(conn).ListWorkspaces(ctx, &pb.ListWorkspacesRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Workspaces) != 2 || list.Workspaces[1].Name != "other" || list.Workspaces[1].Root != "" {
+		t.Errorf("expected both workspaces listed without roots, got %v", list.Workspaces)
+	}
+
+	fc := pb.NewFileServiceClient(conn)
+	for name, want := range map[string]string{filepath.Base(dir): "first", "other": "second"} {
+		read, err := fc.ReadFile(workspace.NewOutgoingContext(ctx, name), &pb.ReadFileRequest{Path: "/a.txt"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(read.Data) != want {
+			t.Errorf("workspace %s: expected %q, got %q", name, want, read.Data)
+		}
+	}
+
+	_, err = fc.ReadFile(ctx, &pb.ReadFileRequest{Path: "/a.txt"})
+	assertGRPCCode(t, err, codes.InvalidArgument)
+	_, err = fc.ReadFile(workspace.NewOutgoingContext(ctx, "nope"), &pb.ReadFileRequest{Path: "/a.txt"})
+	assertGRPCCode(t, err, codes.NotFound)
+
+	_, err = ctl.Checkpoint(ctx, &pb.CheckpointRequest{})
+	assertGRPCCode(t, err, codes.InvalidArgument)
+	resp, err := ctl.Checkpoint(ctx, &pb.CheckpointRequest{Workspace: "other"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Commit == "" {
+		t.Error("expected a commit in the other workspace")
+	}
+
+	clients, _ := ctl.ListClients(ctx, &pb.ListClientsRequest{})
+	if len(clients.GetClients()) != 1 || clients.Clients[0].Workspace == "" {
+		t.Errorf("expected the client's workspace recorded, got %v", clients.GetClients())
+	}
+}
@@ -0,0 +1,90 @@
+// Package identity carries who a client is across gRPC calls, so the host can
+// attribute changes to the people who made them.
+package identity
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Metadata keys used on every client request. The -bin suffix makes gRPC
+// base64 the values, so non-ASCII names survive the trip.
+const (
+	mdName  = "blueguy-name-bin"
+	mdEmail = "blueguy-email-bin"
+)
+
+// Identity is a client's git-style author identity.
+type Identity struct {
+	Name  string
+	Email string
+}
+
+func (id Identity) IsZero() bool {
+	return id.Name == "" && id.Email == ""
+}
+
+// String formats the identity the way git does: "Name <email>".
+func (id Identity) String() string {
+	return id.Name + " <" + id.Email + ">"
+}
+
+// FromGitConfig reads user.name and user.email from the local git config.
+// Missing values are left empty.
+func FromGitConfig() Identity {
+	return Identity{
+		Name:  gitConfig("user.name"),
+		Email: gitConfig("user.email"),
+	}
+}
+
+func gitConfig(key string) string {
+	out, err := exec.Command("git", "config", "--get", key).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// NewOutgoingContext attaches id to ctx for an outgoing gRPC call.
+func NewOutgoingContext(ctx context.Context, id Identity) context.Context {
+	if id.IsZero() {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, mdName, id.Name, mdEmail, id.Email)
+}
+
+// FromIncomingContext extracts the caller's identity on the server side.
+func FromIncomingContext(ctx context.Context) (Identity, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return Identity{}, false
+	}
+	id := Identity{Name: first(md.Get(mdName)), Email: first(md.Get(mdEmail))}
+	return id, !id.IsZero()
+}
+
+func first(vals []string) string {
+	if len(vals) == 0 {
+		return ""
+	}
+	return vals[0]
+}
+
+// UnaryClientInterceptor stamps id on every unary call.
+func UnaryClientInterceptor(id Identity) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(NewOutgoingContext(ctx, id), method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor stamps id on every streaming call.
+func StreamClientInterceptor(id Identity) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(NewOutgoingContext(ctx, id), desc, cc, method, opts...)
+	}
+}
@@ -1152,6 +1152,3012 @@ func (x *FileChangeEvent) GetNewPath() string {
 	return ""
 }
 
+type ListWorkspacesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWorkspacesRequest) Reset() {
+	*x = ListWorkspacesRequest{}
+	mi := &file_blueguy_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWorkspacesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWorkspacesRequest) ProtoMessage() {}
+
+func (x *ListWorkspacesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWorkspacesRequest.ProtoReflect.Descriptor instead.
+func (*ListWorkspacesRequest) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{23}
+}
+
+type ListWorkspacesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Workspaces    []*WorkspaceInfo       `protobuf:"bytes,1,rep,name=workspaces,proto3" json:"workspaces,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWorkspacesResponse) Reset() {
+	*x = ListWorkspacesResponse{}
+	mi := &file_blueguy_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWorkspacesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWorkspacesResponse) ProtoMessage() {}
+
+func (x *ListWorkspacesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWorkspacesResponse.ProtoReflect.Descriptor instead.
+func (*ListWorkspacesResponse) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *ListWorkspacesResponse) GetWorkspaces() []*WorkspaceInfo {
+	if x != nil {
+		return x.Workspaces
+	}
+	return nil
+}
+
+type WorkspaceInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Root          string                 `protobuf:"bytes,2,opt,name=root,proto3" json:"root,omitempty"` // Only on the control socket
+	Session       *SessionStatus         `protobuf:"bytes,3,opt,name=session,proto3" json:"session,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WorkspaceInfo) Reset() {
+	*x = WorkspaceInfo{}
+	mi := &file_blueguy_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WorkspaceInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WorkspaceInfo) ProtoMessage() {}
+
+func (x *WorkspaceInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WorkspaceInfo.ProtoReflect.Descriptor instead.
+func (*WorkspaceInfo) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *WorkspaceInfo) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *WorkspaceInfo) GetRoot() string {
+	if x != nil {
+		return x.Root
+	}
+	return ""
+}
+
+func (x *WorkspaceInfo) GetSession() *SessionStatus {
+	if x != nil {
+		return x.Session
+	}
+	return nil
+}
+
+type GetStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatusRequest) Reset() {
+	*x = GetStatusRequest{}
+	mi := &file_blueguy_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatusRequest) ProtoMessage() {}
+
+func (x *GetStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetStatusRequest) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{26}
+}
+
+type SessionStatus struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Branch        string                 `protobuf:"bytes,2,opt,name=branch,proto3" json:"branch,omitempty"`
+	Rotation      *Rotation              `protobuf:"bytes,3,opt,name=rotation,proto3" json:"rotation,omitempty"` // Unset when no rotation is configured
+	Push          *PushState             `protobuf:"bytes,4,opt,name=push,proto3" json:"push,omitempty"`         // Unset when git integration is off
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SessionStatus) Reset() {
+	*x = SessionStatus{}
+	mi := &file_blueguy_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionStatus) ProtoMessage() {}
+
+func (x *SessionStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionStatus.ProtoReflect.Descriptor instead.
+func (*SessionStatus) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *SessionStatus) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *SessionStatus) GetBranch() string {
+	if x != nil {
+		return x.Branch
+	}
+	return ""
+}
+
+func (x *SessionStatus) GetRotation() *Rotation {
+	if x != nil {
+		return x.Rotation
+	}
+	return nil
+}
+
+func (x *SessionStatus) GetPush() *PushState {
+	if x != nil {
+		return x.Push
+	}
+	return nil
+}
+
+type Rotation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Roster        []string               `protobuf:"bytes,1,rep,name=roster,proto3" json:"roster,omitempty"`
+	Driver        string                 `protobuf:"bytes,2,opt,name=driver,proto3" json:"driver,omitempty"`
+	NextDriver    string                 `protobuf:"bytes,3,opt,name=next_driver,json=nextDriver,proto3" json:"next_driver,omitempty"`
+	TurnEndsUnix  int64                  `protobuf:"varint,4,opt,name=turn_ends_unix,json=turnEndsUnix,proto3" json:"turn_ends_unix,omitempty"`
+	TurnSeconds   int64                  `protobuf:"varint,5,opt,name=turn_seconds,json=turnSeconds,proto3" json:"turn_seconds,omitempty"`
+	DriversOnly   bool                   `protobuf:"varint,6,opt,name=drivers_only,json=driversOnly,proto3" json:"drivers_only,omitempty"` // Non-drivers are read-only during a turn
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Rotation) Reset() {
+	*x = Rotation{}
+	mi := &file_blueguy_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Rotation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Rotation) ProtoMessage() {}
+
+func (x *Rotation) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Rotation.ProtoReflect.Descriptor instead.
+func (*Rotation) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *Rotation) GetRoster() []string {
+	if x != nil {
+		return x.Roster
+	}
+	return nil
+}
+
+func (x *Rotation) GetDriver() string {
+	if x != nil {
+		return x.Driver
+	}
+	return ""
+}
+
+func (x *Rotation) GetNextDriver() string {
+	if x != nil {
+		return x.NextDriver
+	}
+	return ""
+}
+
+func (x *Rotation) GetTurnEndsUnix() int64 {
+	if x != nil {
+		return x.TurnEndsUnix
+	}
+	return 0
+}
+
+func (x *Rotation) GetTurnSeconds() int64 {
+	if x != nil {
+		return x.TurnSeconds
+	}
+	return 0
+}
+
+func (x *Rotation) GetDriversOnly() bool {
+	if x != nil {
+		return x.DriversOnly
+	}
+	return false
+}
+
+type PushState struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Policy        string                 `protobuf:"bytes,1,opt,name=policy,proto3" json:"policy,omitempty"` // commit, interval, stop or never
+	Remote        string                 `protobuf:"bytes,2,opt,name=remote,proto3" json:"remote,omitempty"`
+	Pending       bool                   `protobuf:"varint,3,opt,name=pending,proto3" json:"pending,omitempty"`                                    // Commits the remote doesn't have yet
+	LastPushUnix  int64                  `protobuf:"varint,4,opt,name=last_push_unix,json=lastPushUnix,proto3" json:"last_push_unix,omitempty"`    // 0 if nothing was pushed yet
+	LastError     string                 `protobuf:"bytes,5,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`                // Empty after a successful push
+	Failures      int32                  `protobuf:"varint,6,opt,name=failures,proto3" json:"failures,omitempty"`                                  // Consecutive failed attempts
+	NextRetryUnix int64                  `protobuf:"varint,7,opt,name=next_retry_unix,json=nextRetryUnix,proto3" json:"next_retry_unix,omitempty"` // 0 unless backing off
+	Held          string                 `protobuf:"bytes,8,opt,name=held,proto3" json:"held,omitempty"`                                           // Why pushing is on hold, e.g. possible secrets
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PushState) Reset() {
+	*x = PushState{}
+	mi := &file_blueguy_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PushState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PushState) ProtoMessage() {}
+
+func (x *PushState) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PushState.ProtoReflect.Descriptor instead.
+func (*PushState) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *PushState) GetPolicy() string {
+	if x != nil {
+		return x.Policy
+	}
+	return ""
+}
+
+func (x *PushState) GetRemote() string {
+	if x != nil {
+		return x.Remote
+	}
+	return ""
+}
+
+func (x *PushState) GetPending() bool {
+	if x != nil {
+		return x.Pending
+	}
+	return false
+}
+
+func (x *PushState) GetLastPushUnix() int64 {
+	if x != nil {
+		return x.LastPushUnix
+	}
+	return 0
+}
+
+func (x *PushState) GetLastError() string {
+	if x != nil {
+		return x.LastError
+	}
+	return ""
+}
+
+func (x *PushState) GetFailures() int32 {
+	if x != nil {
+		return x.Failures
+	}
+	return 0
+}
+
+func (x *PushState) GetNextRetryUnix() int64 {
+	if x != nil {
+		return x.NextRetryUnix
+	}
+	return 0
+}
+
+func (x *PushState) GetHeld() string {
+	if x != nil {
+		return x.Held
+	}
+	return ""
+}
+
+type WatchSessionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchSessionRequest) Reset() {
+	*x = WatchSessionRequest{}
+	mi := &file_blueguy_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchSessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchSessionRequest) ProtoMessage() {}
+
+func (x *WatchSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchSessionRequest.ProtoReflect.Descriptor instead.
+func (*WatchSessionRequest) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{30}
+}
+
+type FlushedRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FlushId       string                 `protobuf:"bytes,1,opt,name=flush_id,json=flushId,proto3" json:"flush_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FlushedRequest) Reset() {
+	*x = FlushedRequest{}
+	mi := &file_blueguy_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FlushedRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FlushedRequest) ProtoMessage() {}
+
+func (x *FlushedRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FlushedRequest.ProtoReflect.Descriptor instead.
+func (*FlushedRequest) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *FlushedRequest) GetFlushId() string {
+	if x != nil {
+		return x.FlushId
+	}
+	return ""
+}
+
+type FlushedResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FlushedResponse) Reset() {
+	*x = FlushedResponse{}
+	mi := &file_blueguy_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FlushedResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FlushedResponse) ProtoMessage() {}
+
+func (x *FlushedResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FlushedResponse.ProtoReflect.Descriptor instead.
+func (*FlushedResponse) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{32}
+}
+
+type SessionEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*SessionEvent_DriverChange
+	//	*SessionEvent_Push
+	//	*SessionEvent_Secrets
+	//	*SessionEvent_LargeFiles
+	//	*SessionEvent_Ending
+	Event         isSessionEvent_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SessionEvent) Reset() {
+	*x = SessionEvent{}
+	mi := &file_blueguy_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionEvent) ProtoMessage() {}
+
+func (x *SessionEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionEvent.ProtoReflect.Descriptor instead.
+func (*SessionEvent) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *SessionEvent) GetEvent() isSessionEvent_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetDriverChange() *DriverChange {
+	if x != nil {
+		if x, ok := x.Event.(*SessionEvent_DriverChange); ok {
+			return x.DriverChange
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetPush() *PushState {
+	if x != nil {
+		if x, ok := x.Event.(*SessionEvent_Push); ok {
+			return x.Push
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetSecrets() *SecretAlert {
+	if x != nil {
+		if x, ok := x.Event.(*SessionEvent_Secrets); ok {
+			return x.Secrets
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetLargeFiles() *LargeFiles {
+	if x != nil {
+		if x, ok := x.Event.(*SessionEvent_LargeFiles); ok {
+			return x.LargeFiles
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetEnding() *SessionEnding {
+	if x != nil {
+		if x, ok := x.Event.(*SessionEvent_Ending); ok {
+			return x.Ending
+		}
+	}
+	return nil
+}
+
+type isSessionEvent_Event interface {
+	isSessionEvent_Event()
+}
+
+type SessionEvent_DriverChange struct {
+	DriverChange *DriverChange `protobuf:"bytes,1,opt,name=driver_change,json=driverChange,proto3,oneof"`
+}
+
+type SessionEvent_Push struct {
+	Push *PushState `protobuf:"bytes,2,opt,name=push,proto3,oneof"`
+}
+
+type SessionEvent_Secrets struct {
+	Secrets *SecretAlert `protobuf:"bytes,3,opt,name=secrets,proto3,oneof"`
+}
+
+type SessionEvent_LargeFiles struct {
+	LargeFiles *LargeFiles `protobuf:"bytes,4,opt,name=large_files,json=largeFiles,proto3,oneof"`
+}
+
+type SessionEvent_Ending struct {
+	Ending *SessionEnding `protobuf:"bytes,5,opt,name=ending,proto3,oneof"`
+}
+
+func (*SessionEvent_DriverChange) isSessionEvent_Event() {}
+
+func (*SessionEvent_Push) isSessionEvent_Event() {}
+
+func (*SessionEvent_Secrets) isSessionEvent_Event() {}
+
+func (*SessionEvent_LargeFiles) isSessionEvent_Event() {}
+
+func (*SessionEvent_Ending) isSessionEvent_Event() {}
+
+// SessionEnding says the host is shutting down. Clients stop writing,
+// call Flushed with flush_id once their writes are in, and unmount. The
+// host makes its final commit after every client has flushed, or after
+// grace_ms at the latest.
+type SessionEnding struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Reason        string                 `protobuf:"bytes,1,opt,name=reason,proto3" json:"reason,omitempty"`
+	GraceMs       int64                  `protobuf:"varint,2,opt,name=grace_ms,json=graceMs,proto3" json:"grace_ms,omitempty"`
+	FlushId       string                 `protobuf:"bytes,3,opt,name=flush_id,json=flushId,proto3" json:"flush_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SessionEnding) Reset() {
+	*x = SessionEnding{}
+	mi := &file_blueguy_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionEnding) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionEnding) ProtoMessage() {}
+
+func (x *SessionEnding) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionEnding.ProtoReflect.Descriptor instead.
+func (*SessionEnding) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *SessionEnding) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *SessionEnding) GetGraceMs() int64 {
+	if x != nil {
+		return x.GraceMs
+	}
+	return 0
+}
+
+func (x *SessionEnding) GetFlushId() string {
+	if x != nil {
+		return x.FlushId
+	}
+	return ""
+}
+
+type DriverChange struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	PreviousDriver string                 `protobuf:"bytes,1,opt,name=previous_driver,json=previousDriver,proto3" json:"previous_driver,omitempty"` // Empty for the first turn
+	Rotation       *Rotation              `protobuf:"bytes,2,opt,name=rotation,proto3" json:"rotation,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *DriverChange) Reset() {
+	*x = DriverChange{}
+	mi := &file_blueguy_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DriverChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DriverChange) ProtoMessage() {}
+
+func (x *DriverChange) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DriverChange.ProtoReflect.Descriptor instead.
+func (*DriverChange) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *DriverChange) GetPreviousDriver() string {
+	if x != nil {
+		return x.PreviousDriver
+	}
+	return ""
+}
+
+func (x *DriverChange) GetRotation() *Rotation {
+	if x != nil {
+		return x.Rotation
+	}
+	return nil
+}
+
+// SecretAlert reports possible credentials in changes about to be
+// committed. The secrets themselves are never sent.
+type SecretAlert struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Mode          string                 `protobuf:"bytes,1,opt,name=mode,proto3" json:"mode,omitempty"` // block: not committed; hold-push: committed, not pushed
+	Findings      []*SecretFinding       `protobuf:"bytes,2,rep,name=findings,proto3" json:"findings,omitempty"`
+	Commit        string                 `protobuf:"bytes,3,opt,name=commit,proto3" json:"commit,omitempty"` // Set for hold-push
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SecretAlert) Reset() {
+	*x = SecretAlert{}
+	mi := &file_blueguy_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SecretAlert) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SecretAlert) ProtoMessage() {}
+
+func (x *SecretAlert) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SecretAlert.ProtoReflect.Descriptor instead.
+func (*SecretAlert) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *SecretAlert) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *SecretAlert) GetFindings() []*SecretFinding {
+	if x != nil {
+		return x.Findings
+	}
+	return nil
+}
+
+func (x *SecretAlert) GetCommit() string {
+	if x != nil {
+		return x.Commit
+	}
+	return ""
+}
+
+type SecretFinding struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Line          int32                  `protobuf:"varint,2,opt,name=line,proto3" json:"line,omitempty"`
+	Rule          string                 `protobuf:"bytes,3,opt,name=rule,proto3" json:"rule,omitempty"`         // e.g. aws-access-key, high-entropy-string
+	Redacted      string                 `protobuf:"bytes,4,opt,name=redacted,proto3" json:"redacted,omitempty"` // First few characters, e.g. AKIA****
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SecretFinding) Reset() {
+	*x = SecretFinding{}
+	mi := &file_blueguy_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SecretFinding) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SecretFinding) ProtoMessage() {}
+
+func (x *SecretFinding) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SecretFinding.ProtoReflect.Descriptor instead.
+func (*SecretFinding) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *SecretFinding) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *SecretFinding) GetLine() int32 {
+	if x != nil {
+		return x.Line
+	}
+	return 0
+}
+
+func (x *SecretFinding) GetRule() string {
+	if x != nil {
+		return x.Rule
+	}
+	return ""
+}
+
+func (x *SecretFinding) GetRedacted() string {
+	if x != nil {
+		return x.Redacted
+	}
+	return ""
+}
+
+// LargeFiles lists files too large to auto-commit. Skipped files stay on
+// disk, uncommitted; with LFS configured they're committed through it.
+type LargeFiles struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Files         []*LargeFile           `protobuf:"bytes,1,rep,name=files,proto3" json:"files,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LargeFiles) Reset() {
+	*x = LargeFiles{}
+	mi := &file_blueguy_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LargeFiles) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LargeFiles) ProtoMessage() {}
+
+func (x *LargeFiles) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LargeFiles.ProtoReflect.Descriptor instead.
+func (*LargeFiles) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *LargeFiles) GetFiles() []*LargeFile {
+	if x != nil {
+		return x.Files
+	}
+	return nil
+}
+
+type LargeFile struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Size          int64                  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	Binary        bool                   `protobuf:"varint,3,opt,name=binary,proto3" json:"binary,omitempty"`
+	Lfs           bool                   `protobuf:"varint,4,opt,name=lfs,proto3" json:"lfs,omitempty"` // Committed through Git LFS rather than skipped
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LargeFile) Reset() {
+	*x = LargeFile{}
+	mi := &file_blueguy_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LargeFile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LargeFile) ProtoMessage() {}
+
+func (x *LargeFile) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LargeFile.ProtoReflect.Descriptor instead.
+func (*LargeFile) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *LargeFile) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *LargeFile) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *LargeFile) GetBinary() bool {
+	if x != nil {
+		return x.Binary
+	}
+	return false
+}
+
+func (x *LargeFile) GetLfs() bool {
+	if x != nil {
+		return x.Lfs
+	}
+	return false
+}
+
+type GitStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GitStatusRequest) Reset() {
+	*x = GitStatusRequest{}
+	mi := &file_blueguy_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GitStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GitStatusRequest) ProtoMessage() {}
+
+func (x *GitStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GitStatusRequest.ProtoReflect.Descriptor instead.
+func (*GitStatusRequest) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{40}
+}
+
+type GitStatusResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Branch        string                 `protobuf:"bytes,1,opt,name=branch,proto3" json:"branch,omitempty"`
+	Files         []*FileStatus          `protobuf:"bytes,2,rep,name=files,proto3" json:"files,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GitStatusResponse) Reset() {
+	*x = GitStatusResponse{}
+	mi := &file_blueguy_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GitStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GitStatusResponse) ProtoMessage() {}
+
+func (x *GitStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GitStatusResponse.ProtoReflect.Descriptor instead.
+func (*GitStatusResponse) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *GitStatusResponse) GetBranch() string {
+	if x != nil {
+		return x.Branch
+	}
+	return ""
+}
+
+func (x *GitStatusResponse) GetFiles() []*FileStatus {
+	if x != nil {
+		return x.Files
+	}
+	return nil
+}
+
+type FileStatus struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	OrigPath      string                 `protobuf:"bytes,2,opt,name=orig_path,json=origPath,proto3" json:"orig_path,omitempty"` // Source of a rename or copy
+	Staging       string                 `protobuf:"bytes,3,opt,name=staging,proto3" json:"staging,omitempty"`                   // Porcelain status letters: " ", "M", "A", "D", "R", "?", ...
+	Worktree      string                 `protobuf:"bytes,4,opt,name=worktree,proto3" json:"worktree,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileStatus) Reset() {
+	*x = FileStatus{}
+	mi := &file_blueguy_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileStatus) ProtoMessage() {}
+
+func (x *FileStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileStatus.ProtoReflect.Descriptor instead.
+func (*FileStatus) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *FileStatus) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *FileStatus) GetOrigPath() string {
+	if x != nil {
+		return x.OrigPath
+	}
+	return ""
+}
+
+func (x *FileStatus) GetStaging() string {
+	if x != nil {
+		return x.Staging
+	}
+	return ""
+}
+
+func (x *FileStatus) GetWorktree() string {
+	if x != nil {
+		return x.Worktree
+	}
+	return ""
+}
+
+type DiffRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Commit        string                 `protobuf:"bytes,1,opt,name=commit,proto3" json:"commit,omitempty"`                            // Changes made by this commit; empty for the working tree
+	SinceStart    bool                   `protobuf:"varint,2,opt,name=since_start,json=sinceStart,proto3" json:"since_start,omitempty"` // Working tree against the session's starting point instead of HEAD
+	Paths         []string               `protobuf:"bytes,3,rep,name=paths,proto3" json:"paths,omitempty"`                              // Limit to these paths
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DiffRequest) Reset() {
+	*x = DiffRequest{}
+	mi := &file_blueguy_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DiffRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DiffRequest) ProtoMessage() {}
+
+func (x *DiffRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DiffRequest.ProtoReflect.Descriptor instead.
+func (*DiffRequest) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *DiffRequest) GetCommit() string {
+	if x != nil {
+		return x.Commit
+	}
+	return ""
+}
+
+func (x *DiffRequest) GetSinceStart() bool {
+	if x != nil {
+		return x.SinceStart
+	}
+	return false
+}
+
+func (x *DiffRequest) GetPaths() []string {
+	if x != nil {
+		return x.Paths
+	}
+	return nil
+}
+
+type DiffResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Patch         string                 `protobuf:"bytes,1,opt,name=patch,proto3" json:"patch,omitempty"`
+	Truncated     bool                   `protobuf:"varint,2,opt,name=truncated,proto3" json:"truncated,omitempty"` // Patch was cut at 1MB
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DiffResponse) Reset() {
+	*x = DiffResponse{}
+	mi := &file_blueguy_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DiffResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DiffResponse) ProtoMessage() {}
+
+func (x *DiffResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DiffResponse.ProtoReflect.Descriptor instead.
+func (*DiffResponse) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *DiffResponse) GetPatch() string {
+	if x != nil {
+		return x.Patch
+	}
+	return ""
+}
+
+func (x *DiffResponse) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
+type LogRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Limit         int32                  `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"` // Default 50
+	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`    // Only commits touching this path
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogRequest) Reset() {
+	*x = LogRequest{}
+	mi := &file_blueguy_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogRequest) ProtoMessage() {}
+
+func (x *LogRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogRequest.ProtoReflect.Descriptor instead.
+func (*LogRequest) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *LogRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *LogRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+type LogResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Commits       []*CommitInfo          `protobuf:"bytes,1,rep,name=commits,proto3" json:"commits,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogResponse) Reset() {
+	*x = LogResponse{}
+	mi := &file_blueguy_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogResponse) ProtoMessage() {}
+
+func (x *LogResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogResponse.ProtoReflect.Descriptor instead.
+func (*LogResponse) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *LogResponse) GetCommits() []*CommitInfo {
+	if x != nil {
+		return x.Commits
+	}
+	return nil
+}
+
+type CommitInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hash          string                 `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	AuthorName    string                 `protobuf:"bytes,2,opt,name=author_name,json=authorName,proto3" json:"author_name,omitempty"`
+	AuthorEmail   string                 `protobuf:"bytes,3,opt,name=author_email,json=authorEmail,proto3" json:"author_email,omitempty"`
+	TimeUnix      int64                  `protobuf:"varint,4,opt,name=time_unix,json=timeUnix,proto3" json:"time_unix,omitempty"`
+	Subject       string                 `protobuf:"bytes,5,opt,name=subject,proto3" json:"subject,omitempty"`
+	Body          string                 `protobuf:"bytes,6,opt,name=body,proto3" json:"body,omitempty"`
+	CoAuthors     []string               `protobuf:"bytes,7,rep,name=co_authors,json=coAuthors,proto3" json:"co_authors,omitempty"` // "Name <email>"
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommitInfo) Reset() {
+	*x = CommitInfo{}
+	mi := &file_blueguy_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommitInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommitInfo) ProtoMessage() {}
+
+func (x *CommitInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[47]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommitInfo.ProtoReflect.Descriptor instead.
+func (*CommitInfo) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *CommitInfo) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *CommitInfo) GetAuthorName() string {
+	if x != nil {
+		return x.AuthorName
+	}
+	return ""
+}
+
+func (x *CommitInfo) GetAuthorEmail() string {
+	if x != nil {
+		return x.AuthorEmail
+	}
+	return ""
+}
+
+func (x *CommitInfo) GetTimeUnix() int64 {
+	if x != nil {
+		return x.TimeUnix
+	}
+	return 0
+}
+
+func (x *CommitInfo) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+func (x *CommitInfo) GetBody() string {
+	if x != nil {
+		return x.Body
+	}
+	return ""
+}
+
+func (x *CommitInfo) GetCoAuthors() []string {
+	if x != nil {
+		return x.CoAuthors
+	}
+	return nil
+}
+
+type BlameRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Commit        string                 `protobuf:"bytes,2,opt,name=commit,proto3" json:"commit,omitempty"` // Empty blames the working copy
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BlameRequest) Reset() {
+	*x = BlameRequest{}
+	mi := &file_blueguy_proto_msgTypes[48]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BlameRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlameRequest) ProtoMessage() {}
+
+func (x *BlameRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[48]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlameRequest.ProtoReflect.Descriptor instead.
+func (*BlameRequest) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{48}
+}
+
+func (x *BlameRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *BlameRequest) GetCommit() string {
+	if x != nil {
+		return x.Commit
+	}
+	return ""
+}
+
+type BlameResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Lines         []*BlameLine           `protobuf:"bytes,1,rep,name=lines,proto3" json:"lines,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BlameResponse) Reset() {
+	*x = BlameResponse{}
+	mi := &file_blueguy_proto_msgTypes[49]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BlameResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlameResponse) ProtoMessage() {}
+
+func (x *BlameResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[49]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlameResponse.ProtoReflect.Descriptor instead.
+func (*BlameResponse) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{49}
+}
+
+func (x *BlameResponse) GetLines() []*BlameLine {
+	if x != nil {
+		return x.Lines
+	}
+	return nil
+}
+
+type BlameLine struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Line          int32                  `protobuf:"varint,1,opt,name=line,proto3" json:"line,omitempty"`
+	Hash          string                 `protobuf:"bytes,2,opt,name=hash,proto3" json:"hash,omitempty"` // All zeros for uncommitted lines
+	AuthorName    string                 `protobuf:"bytes,3,opt,name=author_name,json=authorName,proto3" json:"author_name,omitempty"`
+	AuthorEmail   string                 `protobuf:"bytes,4,opt,name=author_email,json=authorEmail,proto3" json:"author_email,omitempty"`
+	TimeUnix      int64                  `protobuf:"varint,5,opt,name=time_unix,json=timeUnix,proto3" json:"time_unix,omitempty"`
+	Summary       string                 `protobuf:"bytes,6,opt,name=summary,proto3" json:"summary,omitempty"`
+	Text          string                 `protobuf:"bytes,7,opt,name=text,proto3" json:"text,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BlameLine) Reset() {
+	*x = BlameLine{}
+	mi := &file_blueguy_proto_msgTypes[50]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BlameLine) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlameLine) ProtoMessage() {}
+
+func (x *BlameLine) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[50]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlameLine.ProtoReflect.Descriptor instead.
+func (*BlameLine) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{50}
+}
+
+func (x *BlameLine) GetLine() int32 {
+	if x != nil {
+		return x.Line
+	}
+	return 0
+}
+
+func (x *BlameLine) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *BlameLine) GetAuthorName() string {
+	if x != nil {
+		return x.AuthorName
+	}
+	return ""
+}
+
+func (x *BlameLine) GetAuthorEmail() string {
+	if x != nil {
+		return x.AuthorEmail
+	}
+	return ""
+}
+
+func (x *BlameLine) GetTimeUnix() int64 {
+	if x != nil {
+		return x.TimeUnix
+	}
+	return 0
+}
+
+func (x *BlameLine) GetSummary() string {
+	if x != nil {
+		return x.Summary
+	}
+	return ""
+}
+
+func (x *BlameLine) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+type CheckpointRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Message string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"` // Empty generates one from the changes
+	// On a client's control socket: the mount (or a path inside it) whose
+	// host should commit, when the client has several
+	Mount string `protobuf:"bytes,2,opt,name=mount,proto3" json:"mount,omitempty"`
+	// On a host's control socket: the workspace (a name, or a path inside
+	// it) to commit, when the host serves several
+	Workspace     string `protobuf:"bytes,3,opt,name=workspace,proto3" json:"workspace,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckpointRequest) Reset() {
+	*x = CheckpointRequest{}
+	mi := &file_blueguy_proto_msgTypes[51]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckpointRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckpointRequest) ProtoMessage() {}
+
+func (x *CheckpointRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[51]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckpointRequest.ProtoReflect.Descriptor instead.
+func (*CheckpointRequest) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{51}
+}
+
+func (x *CheckpointRequest) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *CheckpointRequest) GetMount() string {
+	if x != nil {
+		return x.Mount
+	}
+	return ""
+}
+
+func (x *CheckpointRequest) GetWorkspace() string {
+	if x != nil {
+		return x.Workspace
+	}
+	return ""
+}
+
+type CheckpointResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Commit        string                 `protobuf:"bytes,1,opt,name=commit,proto3" json:"commit,omitempty"` // Empty if there was nothing to commit
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckpointResponse) Reset() {
+	*x = CheckpointResponse{}
+	mi := &file_blueguy_proto_msgTypes[52]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckpointResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckpointResponse) ProtoMessage() {}
+
+func (x *CheckpointResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[52]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckpointResponse.ProtoReflect.Descriptor instead.
+func (*CheckpointResponse) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{52}
+}
+
+func (x *CheckpointResponse) GetCommit() string {
+	if x != nil {
+		return x.Commit
+	}
+	return ""
+}
+
+type RollbackRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Commit        string                 `protobuf:"bytes,1,opt,name=commit,proto3" json:"commit,omitempty"`
+	Paths         []string               `protobuf:"bytes,2,rep,name=paths,proto3" json:"paths,omitempty"` // Empty rolls back the whole workspace
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RollbackRequest) Reset() {
+	*x = RollbackRequest{}
+	mi := &file_blueguy_proto_msgTypes[53]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RollbackRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RollbackRequest) ProtoMessage() {}
+
+func (x *RollbackRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[53]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RollbackRequest.ProtoReflect.Descriptor instead.
+func (*RollbackRequest) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{53}
+}
+
+func (x *RollbackRequest) GetCommit() string {
+	if x != nil {
+		return x.Commit
+	}
+	return ""
+}
+
+func (x *RollbackRequest) GetPaths() []string {
+	if x != nil {
+		return x.Paths
+	}
+	return nil
+}
+
+type RollbackResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Commit        string                 `protobuf:"bytes,1,opt,name=commit,proto3" json:"commit,omitempty"` // Commit recording the rollback; empty if nothing changed
+	ChangedPaths  []string               `protobuf:"bytes,2,rep,name=changed_paths,json=changedPaths,proto3" json:"changed_paths,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RollbackResponse) Reset() {
+	*x = RollbackResponse{}
+	mi := &file_blueguy_proto_msgTypes[54]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RollbackResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RollbackResponse) ProtoMessage() {}
+
+func (x *RollbackResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[54]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RollbackResponse.ProtoReflect.Descriptor instead.
+func (*RollbackResponse) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{54}
+}
+
+func (x *RollbackResponse) GetCommit() string {
+	if x != nil {
+		return x.Commit
+	}
+	return ""
+}
+
+func (x *RollbackResponse) GetChangedPaths() []string {
+	if x != nil {
+		return x.ChangedPaths
+	}
+	return nil
+}
+
+type HistoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Snapshot      string                 `protobuf:"bytes,1,opt,name=snapshot,proto3" json:"snapshot,omitempty"` // Commit hash, "start", or a time like 2024-05-01T14:30
+	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`         // Relative to the workspace as it was then
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistoryRequest) Reset() {
+	*x = HistoryRequest{}
+	mi := &file_blueguy_proto_msgTypes[55]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryRequest) ProtoMessage() {}
+
+func (x *HistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[55]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryRequest.ProtoReflect.Descriptor instead.
+func (*HistoryRequest) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{55}
+}
+
+func (x *HistoryRequest) GetSnapshot() string {
+	if x != nil {
+		return x.Snapshot
+	}
+	return ""
+}
+
+func (x *HistoryRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+type HistoryReadRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Snapshot      string                 `protobuf:"bytes,1,opt,name=snapshot,proto3" json:"snapshot,omitempty"`
+	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	Offset        int64                  `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	Length        int64                  `protobuf:"varint,4,opt,name=length,proto3" json:"length,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistoryReadRequest) Reset() {
+	*x = HistoryReadRequest{}
+	mi := &file_blueguy_proto_msgTypes[56]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistoryReadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryReadRequest) ProtoMessage() {}
+
+func (x *HistoryReadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[56]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryReadRequest.ProtoReflect.Descriptor instead.
+func (*HistoryReadRequest) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{56}
+}
+
+func (x *HistoryReadRequest) GetSnapshot() string {
+	if x != nil {
+		return x.Snapshot
+	}
+	return ""
+}
+
+func (x *HistoryReadRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *HistoryReadRequest) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *HistoryReadRequest) GetLength() int64 {
+	if x != nil {
+		return x.Length
+	}
+	return 0
+}
+
+type ControlStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ControlStatusRequest) Reset() {
+	*x = ControlStatusRequest{}
+	mi := &file_blueguy_proto_msgTypes[57]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ControlStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ControlStatusRequest) ProtoMessage() {}
+
+func (x *ControlStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[57]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ControlStatusRequest.ProtoReflect.Descriptor instead.
+func (*ControlStatusRequest) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{57}
+}
+
+type ControlStatus struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Role          string                 `protobuf:"bytes,1,opt,name=role,proto3" json:"role,omitempty"` // host or client
+	Pid           int32                  `protobuf:"varint,2,opt,name=pid,proto3" json:"pid,omitempty"`
+	Workspace     string                 `protobuf:"bytes,3,opt,name=workspace,proto3" json:"workspace,omitempty"` // The host's root, or the client's mount point
+	Addr          string                 `protobuf:"bytes,4,opt,name=addr,proto3" json:"addr,omitempty"`           // Where a host listens, or the host a client joined
+	StartedUnix   int64                  `protobuf:"varint,5,opt,name=started_unix,json=startedUnix,proto3" json:"started_unix,omitempty"`
+	Session       *SessionStatus         `protobuf:"bytes,6,opt,name=session,proto3" json:"session,omitempty"`        // Unset if a client can't reach its host
+	Clients       int32                  `protobuf:"varint,7,opt,name=clients,proto3" json:"clients,omitempty"`       // Hosts only
+	Mounts        []*MountInfo           `protobuf:"bytes,8,rep,name=mounts,proto3" json:"mounts,omitempty"`          // Clients only
+	Background    bool                   `protobuf:"varint,9,opt,name=background,proto3" json:"background,omitempty"` // A client daemon rather than a join in a terminal
+	Workspaces    []*WorkspaceInfo       `protobuf:"bytes,10,rep,name=workspaces,proto3" json:"workspaces,omitempty"` // Hosts only
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ControlStatus) Reset() {
+	*x = ControlStatus{}
+	mi := &file_blueguy_proto_msgTypes[58]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ControlStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ControlStatus) ProtoMessage() {}
+
+func (x *ControlStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[58]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ControlStatus.ProtoReflect.Descriptor instead.
+func (*ControlStatus) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{58}
+}
+
+func (x *ControlStatus) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *ControlStatus) GetPid() int32 {
+	if x != nil {
+		return x.Pid
+	}
+	return 0
+}
+
+func (x *ControlStatus) GetWorkspace() string {
+	if x != nil {
+		return x.Workspace
+	}
+	return ""
+}
+
+func (x *ControlStatus) GetAddr() string {
+	if x != nil {
+		return x.Addr
+	}
+	return ""
+}
+
+func (x *ControlStatus) GetStartedUnix() int64 {
+	if x != nil {
+		return x.StartedUnix
+	}
+	return 0
+}
+
+func (x *ControlStatus) GetSession() *SessionStatus {
+	if x != nil {
+		return x.Session
+	}
+	return nil
+}
+
+func (x *ControlStatus) GetClients() int32 {
+	if x != nil {
+		return x.Clients
+	}
+	return 0
+}
+
+func (x *ControlStatus) GetMounts() []*MountInfo {
+	if x != nil {
+		return x.Mounts
+	}
+	return nil
+}
+
+func (x *ControlStatus) GetBackground() bool {
+	if x != nil {
+		return x.Background
+	}
+	return false
+}
+
+func (x *ControlStatus) GetWorkspaces() []*WorkspaceInfo {
+	if x != nil {
+		return x.Workspaces
+	}
+	return nil
+}
+
+type MountInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Addr          string                 `protobuf:"bytes,1,opt,name=addr,proto3" json:"addr,omitempty"`   // The host
+	Mount         string                 `protobuf:"bytes,2,opt,name=mount,proto3" json:"mount,omitempty"` // Mount point
+	StartedUnix   int64                  `protobuf:"varint,3,opt,name=started_unix,json=startedUnix,proto3" json:"started_unix,omitempty"`
+	Session       *SessionStatus         `protobuf:"bytes,4,opt,name=session,proto3" json:"session,omitempty"`     // Unset if the host can't be reached
+	Workspace     string                 `protobuf:"bytes,5,opt,name=workspace,proto3" json:"workspace,omitempty"` // Which of the host's workspaces, if it has several
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MountInfo) Reset() {
+	*x = MountInfo{}
+	mi := &file_blueguy_proto_msgTypes[59]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MountInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MountInfo) ProtoMessage() {}
+
+func (x *MountInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[59]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MountInfo.ProtoReflect.Descriptor instead.
+func (*MountInfo) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{59}
+}
+
+func (x *MountInfo) GetAddr() string {
+	if x != nil {
+		return x.Addr
+	}
+	return ""
+}
+
+func (x *MountInfo) GetMount() string {
+	if x != nil {
+		return x.Mount
+	}
+	return ""
+}
+
+func (x *MountInfo) GetStartedUnix() int64 {
+	if x != nil {
+		return x.StartedUnix
+	}
+	return 0
+}
+
+func (x *MountInfo) GetSession() *SessionStatus {
+	if x != nil {
+		return x.Session
+	}
+	return nil
+}
+
+func (x *MountInfo) GetWorkspace() string {
+	if x != nil {
+		return x.Workspace
+	}
+	return ""
+}
+
+type ListClientsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListClientsRequest) Reset() {
+	*x = ListClientsRequest{}
+	mi := &file_blueguy_proto_msgTypes[60]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListClientsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListClientsRequest) ProtoMessage() {}
+
+func (x *ListClientsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[60]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListClientsRequest.ProtoReflect.Descriptor instead.
+func (*ListClientsRequest) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{60}
+}
+
+type ListClientsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Clients       []*ClientInfo          `protobuf:"bytes,1,rep,name=clients,proto3" json:"clients,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListClientsResponse) Reset() {
+	*x = ListClientsResponse{}
+	mi := &file_blueguy_proto_msgTypes[61]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListClientsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListClientsResponse) ProtoMessage() {}
+
+func (x *ListClientsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[61]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListClientsResponse.ProtoReflect.Descriptor instead.
+func (*ListClientsResponse) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{61}
+}
+
+func (x *ListClientsResponse) GetClients() []*ClientInfo {
+	if x != nil {
+		return x.Clients
+	}
+	return nil
+}
+
+type ClientInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Email         string                 `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
+	Addr          string                 `protobuf:"bytes,3,opt,name=addr,proto3" json:"addr,omitempty"` // Remote address of the connection
+	ConnectedUnix int64                  `protobuf:"varint,4,opt,name=connected_unix,json=connectedUnix,proto3" json:"connected_unix,omitempty"`
+	LastSeenUnix  int64                  `protobuf:"varint,5,opt,name=last_seen_unix,json=lastSeenUnix,proto3" json:"last_seen_unix,omitempty"` // Last request
+	Workspace     string                 `protobuf:"bytes,6,opt,name=workspace,proto3" json:"workspace,omitempty"`                              // Workspace of the last request
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClientInfo) Reset() {
+	*x = ClientInfo{}
+	mi := &file_blueguy_proto_msgTypes[62]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClientInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClientInfo) ProtoMessage() {}
+
+func (x *ClientInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[62]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClientInfo.ProtoReflect.Descriptor instead.
+func (*ClientInfo) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{62}
+}
+
+func (x *ClientInfo) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ClientInfo) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *ClientInfo) GetAddr() string {
+	if x != nil {
+		return x.Addr
+	}
+	return ""
+}
+
+func (x *ClientInfo) GetConnectedUnix() int64 {
+	if x != nil {
+		return x.ConnectedUnix
+	}
+	return 0
+}
+
+func (x *ClientInfo) GetLastSeenUnix() int64 {
+	if x != nil {
+		return x.LastSeenUnix
+	}
+	return 0
+}
+
+func (x *ClientInfo) GetWorkspace() string {
+	if x != nil {
+		return x.Workspace
+	}
+	return ""
+}
+
+type StopRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Reason        string                 `protobuf:"bytes,1,opt,name=reason,proto3" json:"reason,omitempty"` // Logged by the process
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StopRequest) Reset() {
+	*x = StopRequest{}
+	mi := &file_blueguy_proto_msgTypes[63]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StopRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StopRequest) ProtoMessage() {}
+
+func (x *StopRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[63]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StopRequest.ProtoReflect.Descriptor instead.
+func (*StopRequest) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{63}
+}
+
+func (x *StopRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type StopResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StopResponse) Reset() {
+	*x = StopResponse{}
+	mi := &file_blueguy_proto_msgTypes[64]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StopResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StopResponse) ProtoMessage() {}
+
+func (x *StopResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[64]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StopResponse.ProtoReflect.Descriptor instead.
+func (*StopResponse) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{64}
+}
+
+type ReloadConfigRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReloadConfigRequest) Reset() {
+	*x = ReloadConfigRequest{}
+	mi := &file_blueguy_proto_msgTypes[65]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReloadConfigRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReloadConfigRequest) ProtoMessage() {}
+
+func (x *ReloadConfigRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[65]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReloadConfigRequest.ProtoReflect.Descriptor instead.
+func (*ReloadConfigRequest) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{65}
+}
+
+type ReloadConfigResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Files         []string               `protobuf:"bytes,1,rep,name=files,proto3" json:"files,omitempty"`     // Config files read
+	Applied       []string               `protobuf:"bytes,2,rep,name=applied,proto3" json:"applied,omitempty"` // Changed settings now in effect
+	Restart       []string               `protobuf:"bytes,3,rep,name=restart,proto3" json:"restart,omitempty"` // Changed settings that need a restart
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReloadConfigResponse) Reset() {
+	*x = ReloadConfigResponse{}
+	mi := &file_blueguy_proto_msgTypes[66]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReloadConfigResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReloadConfigResponse) ProtoMessage() {}
+
+func (x *ReloadConfigResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[66]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReloadConfigResponse.ProtoReflect.Descriptor instead.
+func (*ReloadConfigResponse) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{66}
+}
+
+func (x *ReloadConfigResponse) GetFiles() []string {
+	if x != nil {
+		return x.Files
+	}
+	return nil
+}
+
+func (x *ReloadConfigResponse) GetApplied() []string {
+	if x != nil {
+		return x.Applied
+	}
+	return nil
+}
+
+func (x *ReloadConfigResponse) GetRestart() []string {
+	if x != nil {
+		return x.Restart
+	}
+	return nil
+}
+
+type SetLogLevelRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Level         string                 `protobuf:"bytes,1,opt,name=level,proto3" json:"level,omitempty"` // trace, debug, info, warn, error or disabled
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetLogLevelRequest) Reset() {
+	*x = SetLogLevelRequest{}
+	mi := &file_blueguy_proto_msgTypes[67]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetLogLevelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetLogLevelRequest) ProtoMessage() {}
+
+func (x *SetLogLevelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[67]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetLogLevelRequest.ProtoReflect.Descriptor instead.
+func (*SetLogLevelRequest) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{67}
+}
+
+func (x *SetLogLevelRequest) GetLevel() string {
+	if x != nil {
+		return x.Level
+	}
+	return ""
+}
+
+type SetLogLevelResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Previous      string                 `protobuf:"bytes,1,opt,name=previous,proto3" json:"previous,omitempty"`
+	Level         string                 `protobuf:"bytes,2,opt,name=level,proto3" json:"level,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetLogLevelResponse) Reset() {
+	*x = SetLogLevelResponse{}
+	mi := &file_blueguy_proto_msgTypes[68]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetLogLevelResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetLogLevelResponse) ProtoMessage() {}
+
+func (x *SetLogLevelResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[68]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetLogLevelResponse.ProtoReflect.Descriptor instead.
+func (*SetLogLevelResponse) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{68}
+}
+
+func (x *SetLogLevelResponse) GetPrevious() string {
+	if x != nil {
+		return x.Previous
+	}
+	return ""
+}
+
+func (x *SetLogLevelResponse) GetLevel() string {
+	if x != nil {
+		return x.Level
+	}
+	return ""
+}
+
+type KickClientRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Client        string                 `protobuf:"bytes,1,opt,name=client,proto3" json:"client,omitempty"` // Name, email or remote address, as ListClients shows
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"` // Shown to the client
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KickClientRequest) Reset() {
+	*x = KickClientRequest{}
+	mi := &file_blueguy_proto_msgTypes[69]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KickClientRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KickClientRequest) ProtoMessage() {}
+
+func (x *KickClientRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[69]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KickClientRequest.ProtoReflect.Descriptor instead.
+func (*KickClientRequest) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{69}
+}
+
+func (x *KickClientRequest) GetClient() string {
+	if x != nil {
+		return x.Client
+	}
+	return ""
+}
+
+func (x *KickClientRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type KickClientResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Kicked        []*ClientInfo          `protobuf:"bytes,1,rep,name=kicked,proto3" json:"kicked,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KickClientResponse) Reset() {
+	*x = KickClientResponse{}
+	mi := &file_blueguy_proto_msgTypes[70]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KickClientResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KickClientResponse) ProtoMessage() {}
+
+func (x *KickClientResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[70]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KickClientResponse.ProtoReflect.Descriptor instead.
+func (*KickClientResponse) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{70}
+}
+
+func (x *KickClientResponse) GetKicked() []*ClientInfo {
+	if x != nil {
+		return x.Kicked
+	}
+	return nil
+}
+
+type JoinRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Addr          string                 `protobuf:"bytes,1,opt,name=addr,proto3" json:"addr,omitempty"` // host[:port]
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"` // Identity for commit credit; empty uses the client's
+	Email         string                 `protobuf:"bytes,3,opt,name=email,proto3" json:"email,omitempty"`
+	Workspace     string                 `protobuf:"bytes,4,opt,name=workspace,proto3" json:"workspace,omitempty"` // Empty for a host that serves one
+	Force         bool                   `protobuf:"varint,5,opt,name=force,proto3" json:"force,omitempty"`        // Mount over a directory that has files in it
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JoinRequest) Reset() {
+	*x = JoinRequest{}
+	mi := &file_blueguy_proto_msgTypes[71]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JoinRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JoinRequest) ProtoMessage() {}
+
+func (x *JoinRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[71]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JoinRequest.ProtoReflect.Descriptor instead.
+func (*JoinRequest) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{71}
+}
+
+func (x *JoinRequest) GetAddr() string {
+	if x != nil {
+		return x.Addr
+	}
+	return ""
+}
+
+func (x *JoinRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *JoinRequest) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *JoinRequest) GetWorkspace() string {
+	if x != nil {
+		return x.Workspace
+	}
+	return ""
+}
+
+func (x *JoinRequest) GetForce() bool {
+	if x != nil {
+		return x.Force
+	}
+	return false
+}
+
+type LeaveRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Mount         string                 `protobuf:"bytes,1,opt,name=mount,proto3" json:"mount,omitempty"` // Mount point or host address
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LeaveRequest) Reset() {
+	*x = LeaveRequest{}
+	mi := &file_blueguy_proto_msgTypes[72]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LeaveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeaveRequest) ProtoMessage() {}
+
+func (x *LeaveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[72]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeaveRequest.ProtoReflect.Descriptor instead.
+func (*LeaveRequest) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{72}
+}
+
+func (x *LeaveRequest) GetMount() string {
+	if x != nil {
+		return x.Mount
+	}
+	return ""
+}
+
+type LeaveResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LeaveResponse) Reset() {
+	*x = LeaveResponse{}
+	mi := &file_blueguy_proto_msgTypes[73]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LeaveResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeaveResponse) ProtoMessage() {}
+
+func (x *LeaveResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[73]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeaveResponse.ProtoReflect.Descriptor instead.
+func (*LeaveResponse) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{73}
+}
+
+type ListMountsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListMountsRequest) Reset() {
+	*x = ListMountsRequest{}
+	mi := &file_blueguy_proto_msgTypes[74]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListMountsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListMountsRequest) ProtoMessage() {}
+
+func (x *ListMountsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[74]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListMountsRequest.ProtoReflect.Descriptor instead.
+func (*ListMountsRequest) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{74}
+}
+
+type ListMountsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Mounts        []*MountInfo           `protobuf:"bytes,1,rep,name=mounts,proto3" json:"mounts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListMountsResponse) Reset() {
+	*x = ListMountsResponse{}
+	mi := &file_blueguy_proto_msgTypes[75]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListMountsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListMountsResponse) ProtoMessage() {}
+
+func (x *ListMountsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_blueguy_proto_msgTypes[75]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListMountsResponse.ProtoReflect.Descriptor instead.
+func (*ListMountsResponse) Descriptor() ([]byte, []int) {
+	return file_blueguy_proto_rawDescGZIP(), []int{75}
+}
+
+func (x *ListMountsResponse) GetMounts() []*MountInfo {
+	if x != nil {
+		return x.Mounts
+	}
+	return nil
+}
+
 var File_blueguy_proto protoreflect.FileDescriptor
 
 const file_blueguy_proto_rawDesc = "" +
@@ -1211,7 +4217,210 @@ const file_blueguy_proto_rawDesc = "" +
 	"\x0fFileChangeEvent\x12\x12\n" +
 	"\x04path\x18\x01 \x01(\tR\x04path\x12*\n" +
 	"\x04type\x18\x02 \x01(\x0e2\x16.blueguy.v1.ChangeTypeR\x04type\x12\x19\n" +
-	"\bnew_path\x18\x03 \x01(\tR\anewPath*\x8e\x01\n" +
+	"\bnew_path\x18\x03 \x01(\tR\anewPath\"\x17\n" +
+	"\x15ListWorkspacesRequest\"S\n" +
+	"\x16ListWorkspacesResponse\x129\n" +
+	"\n" +
+	"workspaces\x18\x01 \x03(\v2\x19.blueguy.v1.WorkspaceInfoR\n" +
+	"workspaces\"l\n" +
+	"\rWorkspaceInfo\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04root\x18\x02 \x01(\tR\x04root\x123\n" +
+	"\asession\x18\x03 \x01(\v2\x19.blueguy.v1.SessionStatusR\asession\"\x12\n" +
+	"\x10GetStatusRequest\"\xa3\x01\n" +
+	"\rSessionStatus\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x16\n" +
+	"\x06branch\x18\x02 \x01(\tR\x06branch\x120\n" +
+	"\brotation\x18\x03 \x01(\v2\x14.blueguy.v1.RotationR\brotation\x12)\n" +
+	"\x04push\x18\x04 \x01(\v2\x15.blueguy.v1.PushStateR\x04push\"\xc7\x01\n" +
+	"\bRotation\x12\x16\n" +
+	"\x06roster\x18\x01 \x03(\tR\x06roster\x12\x16\n" +
+	"\x06driver\x18\x02 \x01(\tR\x06driver\x12\x1f\n" +
+	"\vnext_driver\x18\x03 \x01(\tR\n" +
+	"nextDriver\x12$\n" +
+	"\x0eturn_ends_unix\x18\x04 \x01(\x03R\fturnEndsUnix\x12!\n" +
+	"\fturn_seconds\x18\x05 \x01(\x03R\vturnSeconds\x12!\n" +
+	"\fdrivers_only\x18\x06 \x01(\bR\vdriversOnly\"\xf2\x01\n" +
+	"\tPushState\x12\x16\n" +
+	"\x06policy\x18\x01 \x01(\tR\x06policy\x12\x16\n" +
+	"\x06remote\x18\x02 \x01(\tR\x06remote\x12\x18\n" +
+	"\apending\x18\x03 \x01(\bR\apending\x12$\n" +
+	"\x0elast_push_unix\x18\x04 \x01(\x03R\flastPushUnix\x12\x1d\n" +
+	"\n" +
+	"last_error\x18\x05 \x01(\tR\tlastError\x12\x1a\n" +
+	"\bfailures\x18\x06 \x01(\x05R\bfailures\x12&\n" +
+	"\x0fnext_retry_unix\x18\a \x01(\x03R\rnextRetryUnix\x12\x12\n" +
+	"\x04held\x18\b \x01(\tR\x04held\"\x15\n" +
+	"\x13WatchSessionRequest\"+\n" +
+	"\x0eFlushedRequest\x12\x19\n" +
+	"\bflush_id\x18\x01 \x01(\tR\aflushId\"\x11\n" +
+	"\x0fFlushedResponse\"\xaa\x02\n" +
+	"\fSessionEvent\x12?\n" +
+	"\rdriver_change\x18\x01 \x01(\v2\x18.blueguy.v1.DriverChangeH\x00R\fdriverChange\x12+\n" +
+	"\x04push\x18\x02 \x01(\v2\x15.blueguy.v1.PushStateH\x00R\x04push\x123\n" +
+	"\asecrets\x18\x03 \x01(\v2\x17.blueguy.v1.SecretAlertH\x00R\asecrets\x129\n" +
+	"\vlarge_files\x18\x04 \x01(\v2\x16.blueguy.v1.LargeFilesH\x00R\n" +
+	"largeFiles\x123\n" +
+	"\x06ending\x18\x05 \x01(\v2\x19.blueguy.v1.SessionEndingH\x00R\x06endingB\a\n" +
+	"\x05event\"]\n" +
+	"\rSessionEnding\x12\x16\n" +
+	"\x06reason\x18\x01 \x01(\tR\x06reason\x12\x19\n" +
+	"\bgrace_ms\x18\x02 \x01(\x03R\agraceMs\x12\x19\n" +
+	"\bflush_id\x18\x03 \x01(\tR\aflushId\"i\n" +
+	"\fDriverChange\x12'\n" +
+	"\x0fprevious_driver\x18\x01 \x01(\tR\x0epreviousDriver\x120\n" +
+	"\brotation\x18\x02 \x01(\v2\x14.blueguy.v1.RotationR\brotation\"p\n" +
+	"\vSecretAlert\x12\x12\n" +
+	"\x04mode\x18\x01 \x01(\tR\x04mode\x125\n" +
+	"\bfindings\x18\x02 \x03(\v2\x19.blueguy.v1.SecretFindingR\bfindings\x12\x16\n" +
+	"\x06commit\x18\x03 \x01(\tR\x06commit\"g\n" +
+	"\rSecretFinding\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x12\n" +
+	"\x04line\x18\x02 \x01(\x05R\x04line\x12\x12\n" +
+	"\x04rule\x18\x03 \x01(\tR\x04rule\x12\x1a\n" +
+	"\bredacted\x18\x04 \x01(\tR\bredacted\"9\n" +
+	"\n" +
+	"LargeFiles\x12+\n" +
+	"\x05files\x18\x01 \x03(\v2\x15.blueguy.v1.LargeFileR\x05files\"]\n" +
+	"\tLargeFile\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x03R\x04size\x12\x16\n" +
+	"\x06binary\x18\x03 \x01(\bR\x06binary\x12\x10\n" +
+	"\x03lfs\x18\x04 \x01(\bR\x03lfs\"\x12\n" +
+	"\x10GitStatusRequest\"Y\n" +
+	"\x11GitStatusResponse\x12\x16\n" +
+	"\x06branch\x18\x01 \x01(\tR\x06branch\x12,\n" +
+	"\x05files\x18\x02 \x03(\v2\x16.blueguy.v1.FileStatusR\x05files\"s\n" +
+	"\n" +
+	"FileStatus\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x1b\n" +
+	"\torig_path\x18\x02 \x01(\tR\borigPath\x12\x18\n" +
+	"\astaging\x18\x03 \x01(\tR\astaging\x12\x1a\n" +
+	"\bworktree\x18\x04 \x01(\tR\bworktree\"\\\n" +
+	"\vDiffRequest\x12\x16\n" +
+	"\x06commit\x18\x01 \x01(\tR\x06commit\x12\x1f\n" +
+	"\vsince_start\x18\x02 \x01(\bR\n" +
+	"sinceStart\x12\x14\n" +
+	"\x05paths\x18\x03 \x03(\tR\x05paths\"B\n" +
+	"\fDiffResponse\x12\x14\n" +
+	"\x05patch\x18\x01 \x01(\tR\x05patch\x12\x1c\n" +
+	"\ttruncated\x18\x02 \x01(\bR\ttruncated\"6\n" +
+	"\n" +
+	"LogRequest\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x05R\x05limit\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\"?\n" +
+	"\vLogResponse\x120\n" +
+	"\acommits\x18\x01 \x03(\v2\x16.blueguy.v1.CommitInfoR\acommits\"\xce\x01\n" +
+	"\n" +
+	"CommitInfo\x12\x12\n" +
+	"\x04hash\x18\x01 \x01(\tR\x04hash\x12\x1f\n" +
+	"\vauthor_name\x18\x02 \x01(\tR\n" +
+	"authorName\x12!\n" +
+	"\fauthor_email\x18\x03 \x01(\tR\vauthorEmail\x12\x1b\n" +
+	"\ttime_unix\x18\x04 \x01(\x03R\btimeUnix\x12\x18\n" +
+	"\asubject\x18\x05 \x01(\tR\asubject\x12\x12\n" +
+	"\x04body\x18\x06 \x01(\tR\x04body\x12\x1d\n" +
+	"\n" +
+	"co_authors\x18\a \x03(\tR\tcoAuthors\":\n" +
+	"\fBlameRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x16\n" +
+	"\x06commit\x18\x02 \x01(\tR\x06commit\"<\n" +
+	"\rBlameResponse\x12+\n" +
+	"\x05lines\x18\x01 \x03(\v2\x15.blueguy.v1.BlameLineR\x05lines\"\xc2\x01\n" +
+	"\tBlameLine\x12\x12\n" +
+	"\x04line\x18\x01 \x01(\x05R\x04line\x12\x12\n" +
+	"\x04hash\x18\x02 \x01(\tR\x04hash\x12\x1f\n" +
+	"\vauthor_name\x18\x03 \x01(\tR\n" +
+	"authorName\x12!\n" +
+	"\fauthor_email\x18\x04 \x01(\tR\vauthorEmail\x12\x1b\n" +
+	"\ttime_unix\x18\x05 \x01(\x03R\btimeUnix\x12\x18\n" +
+	"\asummary\x18\x06 \x01(\tR\asummary\x12\x12\n" +
+	"\x04text\x18\a \x01(\tR\x04text\"a\n" +
+	"\x11CheckpointRequest\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x14\n" +
+	"\x05mount\x18\x02 \x01(\tR\x05mount\x12\x1c\n" +
+	"\tworkspace\x18\x03 \x01(\tR\tworkspace\",\n" +
+	"\x12CheckpointResponse\x12\x16\n" +
+	"\x06commit\x18\x01 \x01(\tR\x06commit\"?\n" +
+	"\x0fRollbackRequest\x12\x16\n" +
+	"\x06commit\x18\x01 \x01(\tR\x06commit\x12\x14\n" +
+	"\x05paths\x18\x02 \x03(\tR\x05paths\"O\n" +
+	"\x10RollbackResponse\x12\x16\n" +
+	"\x06commit\x18\x01 \x01(\tR\x06commit\x12#\n" +
+	"\rchanged_paths\x18\x02 \x03(\tR\fchangedPaths\"@\n" +
+	"\x0eHistoryRequest\x12\x1a\n" +
+	"\bsnapshot\x18\x01 \x01(\tR\bsnapshot\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\"t\n" +
+	"\x12HistoryReadRequest\x12\x1a\n" +
+	"\bsnapshot\x18\x01 \x01(\tR\bsnapshot\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x03R\x06offset\x12\x16\n" +
+	"\x06length\x18\x04 \x01(\x03R\x06length\"\x16\n" +
+	"\x14ControlStatusRequest\"\xe3\x02\n" +
+	"\rControlStatus\x12\x12\n" +
+	"\x04role\x18\x01 \x01(\tR\x04role\x12\x10\n" +
+	"\x03pid\x18\x02 \x01(\x05R\x03pid\x12\x1c\n" +
+	"\tworkspace\x18\x03 \x01(\tR\tworkspace\x12\x12\n" +
+	"\x04addr\x18\x04 \x01(\tR\x04addr\x12!\n" +
+	"\fstarted_unix\x18\x05 \x01(\x03R\vstartedUnix\x123\n" +
+	"\asession\x18\x06 \x01(\v2\x19.blueguy.v1.SessionStatusR\asession\x12\x18\n" +
+	"\aclients\x18\a \x01(\x05R\aclients\x12-\n" +
+	"\x06mounts\x18\b \x03(\v2\x15.blueguy.v1.MountInfoR\x06mounts\x12\x1e\n" +
+	"\n" +
+	"background\x18\t \x01(\bR\n" +
+	"background\x129\n" +
+	"\n" +
+	"workspaces\x18\n" +
+	" \x03(\v2\x19.blueguy.v1.WorkspaceInfoR\n" +
+	"workspaces\"\xab\x01\n" +
+	"\tMountInfo\x12\x12\n" +
+	"\x04addr\x18\x01 \x01(\tR\x04addr\x12\x14\n" +
+	"\x05mount\x18\x02 \x01(\tR\x05mount\x12!\n" +
+	"\fstarted_unix\x18\x03 \x01(\x03R\vstartedUnix\x123\n" +
+	"\asession\x18\x04 \x01(\v2\x19.blueguy.v1.SessionStatusR\asession\x12\x1c\n" +
+	"\tworkspace\x18\x05 \x01(\tR\tworkspace\"\x14\n" +
+	"\x12ListClientsRequest\"G\n" +
+	"\x13ListClientsResponse\x120\n" +
+	"\aclients\x18\x01 \x03(\v2\x16.blueguy.v1.ClientInfoR\aclients\"\xb5\x01\n" +
+	"\n" +
+	"ClientInfo\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05email\x18\x02 \x01(\tR\x05email\x12\x12\n" +
+	"\x04addr\x18\x03 \x01(\tR\x04addr\x12%\n" +
+	"\x0econnected_unix\x18\x04 \x01(\x03R\rconnectedUnix\x12$\n" +
+	"\x0elast_seen_unix\x18\x05 \x01(\x03R\flastSeenUnix\x12\x1c\n" +
+	"\tworkspace\x18\x06 \x01(\tR\tworkspace\"%\n" +
+	"\vStopRequest\x12\x16\n" +
+	"\x06reason\x18\x01 \x01(\tR\x06reason\"\x0e\n" +
+	"\fStopResponse\"\x15\n" +
+	"\x13ReloadConfigRequest\"`\n" +
+	"\x14ReloadConfigResponse\x12\x14\n" +
+	"\x05files\x18\x01 \x03(\tR\x05files\x12\x18\n" +
+	"\aapplied\x18\x02 \x03(\tR\aapplied\x12\x18\n" +
+	"\arestart\x18\x03 \x03(\tR\arestart\"*\n" +
+	"\x12SetLogLevelRequest\x12\x14\n" +
+	"\x05level\x18\x01 \x01(\tR\x05level\"G\n" +
+	"\x13SetLogLevelResponse\x12\x1a\n" +
+	"\bprevious\x18\x01 \x01(\tR\bprevious\x12\x14\n" +
+	"\x05level\x18\x02 \x01(\tR\x05level\"C\n" +
+	"\x11KickClientRequest\x12\x16\n" +
+	"\x06client\x18\x01 \x01(\tR\x06client\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\"D\n" +
+	"\x12KickClientResponse\x12.\n" +
+	"\x06kicked\x18\x01 \x03(\v2\x16.blueguy.v1.ClientInfoR\x06kicked\"\x7f\n" +
+	"\vJoinRequest\x12\x12\n" +
+	"\x04addr\x18\x01 \x01(\tR\x04addr\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
+	"\x05email\x18\x03 \x01(\tR\x05email\x12\x1c\n" +
+	"\tworkspace\x18\x04 \x01(\tR\tworkspace\x12\x14\n" +
+	"\x05force\x18\x05 \x01(\bR\x05force\"$\n" +
+	"\fLeaveRequest\x12\x14\n" +
+	"\x05mount\x18\x01 \x01(\tR\x05mount\"\x0f\n" +
+	"\rLeaveResponse\"\x13\n" +
+	"\x11ListMountsRequest\"C\n" +
+	"\x12ListMountsResponse\x12-\n" +
+	"\x06mounts\x18\x01 \x03(\v2\x15.blueguy.v1.MountInfoR\x06mounts*\x8e\x01\n" +
 	"\n" +
 	"ChangeType\x12\x1b\n" +
 	"\x17CHANGE_TYPE_UNSPECIFIED\x10\x00\x12\x17\n" +
@@ -1230,7 +4439,39 @@ const file_blueguy_proto_rawDesc = "" +
 	"\x06Rename\x12\x19.blueguy.v1.RenameRequest\x1a\x1a.blueguy.v1.RenameResponse\x12<\n" +
 	"\x05Chmod\x12\x18.blueguy.v1.ChmodRequest\x1a\x19.blueguy.v1.ChmodResponse\x12E\n" +
 	"\bTruncate\x12\x1b.blueguy.v1.TruncateRequest\x1a\x1c.blueguy.v1.TruncateResponse\x12N\n" +
-	"\fWatchChanges\x12\x1f.blueguy.v1.WatchChangesRequest\x1a\x1b.blueguy.v1.FileChangeEvent0\x01B4Z2github.com/victorarias/blue-guy/internal/proto/genb\x06proto3"
+	"\fWatchChanges\x12\x1f.blueguy.v1.WatchChangesRequest\x1a\x1b.blueguy.v1.FileChangeEvent0\x012k\n" +
+	"\x10WorkspaceService\x12W\n" +
+	"\x0eListWorkspaces\x12!.blueguy.v1.ListWorkspacesRequest\x1a\".blueguy.v1.ListWorkspacesResponse2\xe7\x01\n" +
+	"\x0eSessionService\x12D\n" +
+	"\tGetStatus\x12\x1c.blueguy.v1.GetStatusRequest\x1a\x19.blueguy.v1.SessionStatus\x12K\n" +
+	"\fWatchSession\x12\x1f.blueguy.v1.WatchSessionRequest\x1a\x18.blueguy.v1.SessionEvent0\x01\x12B\n" +
+	"\aFlushed\x12\x1a.blueguy.v1.FlushedRequest\x1a\x1b.blueguy.v1.FlushedResponse2\xf9\x04\n" +
+	"\n" +
+	"GitService\x12E\n" +
+	"\x06Status\x12\x1c.blueguy.v1.GitStatusRequest\x1a\x1d.blueguy.v1.GitStatusResponse\x129\n" +
+	"\x04Diff\x12\x17.blueguy.v1.DiffRequest\x1a\x18.blueguy.v1.DiffResponse\x126\n" +
+	"\x03Log\x12\x16.blueguy.v1.LogRequest\x1a\x17.blueguy.v1.LogResponse\x12<\n" +
+	"\x05Blame\x12\x18.blueguy.v1.BlameRequest\x1a\x19.blueguy.v1.BlameResponse\x12K\n" +
+	"\n" +
+	"Checkpoint\x12\x1d.blueguy.v1.CheckpointRequest\x1a\x1e.blueguy.v1.CheckpointResponse\x12E\n" +
+	"\bRollback\x12\x1b.blueguy.v1.RollbackRequest\x1a\x1c.blueguy.v1.RollbackResponse\x12C\n" +
+	"\vHistoryStat\x12\x1a.blueguy.v1.HistoryRequest\x1a\x18.blueguy.v1.StatResponse\x12I\n" +
+	"\x0eHistoryReadDir\x12\x1a.blueguy.v1.HistoryRequest\x1a\x1b.blueguy.v1.ReadDirResponse\x12O\n" +
+	"\x0fHistoryReadFile\x12\x1e.blueguy.v1.HistoryReadRequest\x1a\x1c.blueguy.v1.ReadFileResponse2\xe2\x05\n" +
+	"\x0eControlService\x12E\n" +
+	"\x06Status\x12 .blueguy.v1.ControlStatusRequest\x1a\x19.blueguy.v1.ControlStatus\x12N\n" +
+	"\vListClients\x12\x1e.blueguy.v1.ListClientsRequest\x1a\x1f.blueguy.v1.ListClientsResponse\x12K\n" +
+	"\n" +
+	"Checkpoint\x12\x1d.blueguy.v1.CheckpointRequest\x1a\x1e.blueguy.v1.CheckpointResponse\x129\n" +
+	"\x04Stop\x12\x17.blueguy.v1.StopRequest\x1a\x18.blueguy.v1.StopResponse\x12Q\n" +
+	"\fReloadConfig\x12\x1f.blueguy.v1.ReloadConfigRequest\x1a .blueguy.v1.ReloadConfigResponse\x12N\n" +
+	"\vSetLogLevel\x12\x1e.blueguy.v1.SetLogLevelRequest\x1a\x1f.blueguy.v1.SetLogLevelResponse\x12K\n" +
+	"\n" +
+	"KickClient\x12\x1d.blueguy.v1.KickClientRequest\x1a\x1e.blueguy.v1.KickClientResponse\x126\n" +
+	"\x04Join\x12\x17.blueguy.v1.JoinRequest\x1a\x15.blueguy.v1.MountInfo\x12<\n" +
+	"\x05Leave\x12\x18.blueguy.v1.LeaveRequest\x1a\x19.blueguy.v1.LeaveResponse\x12K\n" +
+	"\n" +
+	"ListMounts\x12\x1d.blueguy.v1.ListMountsRequest\x1a\x1e.blueguy.v1.ListMountsResponseB4Z2github.com/victorarias/blue-guy/internal/proto/genb\x06proto3"
 
 var (
 	file_blueguy_proto_rawDescOnce sync.Once
@@ -1245,64 +4486,185 @@ func file_blueguy_proto_rawDescGZIP() []byte {
 }
 
 var file_blueguy_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_blueguy_proto_msgTypes = make([]protoimpl.MessageInfo, 23)
+var file_blueguy_proto_msgTypes = make([]protoimpl.MessageInfo, 76)
 var file_blueguy_proto_goTypes = []any{
-	(ChangeType)(0),             // 0: blueguy.v1.ChangeType
-	(*FileInfo)(nil),            // 1: blueguy.v1.FileInfo
-	(*StatRequest)(nil),         // 2: blueguy.v1.StatRequest
-	(*StatResponse)(nil),        // 3: blueguy.v1.StatResponse
-	(*ReadFileRequest)(nil),     // 4: blueguy.v1.ReadFileRequest
-	(*ReadFileResponse)(nil),    // 5: blueguy.v1.ReadFileResponse
-	(*WriteFileRequest)(nil),    // 6: blueguy.v1.WriteFileRequest
-	(*WriteFileResponse)(nil),   // 7: blueguy.v1.WriteFileResponse
-	(*ReadDirRequest)(nil),      // 8: blueguy.v1.ReadDirRequest
-	(*ReadDirResponse)(nil),     // 9: blueguy.v1.ReadDirResponse
-	(*CreateRequest)(nil),       // 10: blueguy.v1.CreateRequest
-	(*CreateResponse)(nil),      // 11: blueguy.v1.CreateResponse
-	(*MkdirRequest)(nil),        // 12: blueguy.v1.MkdirRequest
-	(*MkdirResponse)(nil),       // 13: blueguy.v1.MkdirResponse
-	(*RemoveRequest)(nil),       // 14: blueguy.v1.RemoveRequest
-	(*RemoveResponse)(nil),      // 15: blueguy.v1.RemoveResponse
-	(*RenameRequest)(nil),       // 16: blueguy.v1.RenameRequest
-	(*RenameResponse)(nil),      // 17: blueguy.v1.RenameResponse
-	(*ChmodRequest)(nil),        // 18: blueguy.v1.ChmodRequest
-	(*ChmodResponse)(nil),       // 19: blueguy.v1.ChmodResponse
-	(*TruncateRequest)(nil),     // 20: blueguy.v1.TruncateRequest
-	(*TruncateResponse)(nil),    // 21: blueguy.v1.TruncateResponse
-	(*WatchChangesRequest)(nil), // 22: blueguy.v1.WatchChangesRequest
-	(*FileChangeEvent)(nil),     // 23: blueguy.v1.FileChangeEvent
+	(ChangeType)(0),                // 0: blueguy.v1.ChangeType
+	(*FileInfo)(nil),               // 1: blueguy.v1.FileInfo
+	(*StatRequest)(nil),            // 2: blueguy.v1.StatRequest
+	(*StatResponse)(nil),           // 3: blueguy.v1.StatResponse
+	(*ReadFileRequest)(nil),        // 4: blueguy.v1.ReadFileRequest
+	(*ReadFileResponse)(nil),       // 5: blueguy.v1.ReadFileResponse
+	(*WriteFileRequest)(nil),       // 6: blueguy.v1.WriteFileRequest
+	(*WriteFileResponse)(nil),      // 7: blueguy.v1.WriteFileResponse
+	(*ReadDirRequest)(nil),         // 8: blueguy.v1.ReadDirRequest
+	(*ReadDirResponse)(nil),        // 9: blueguy.v1.ReadDirResponse
+	(*CreateRequest)(nil),          // 10: blueguy.v1.CreateRequest
+	(*CreateResponse)(nil),         // 11: blueguy.v1.CreateResponse
+	(*MkdirRequest)(nil),           // 12: blueguy.v1.MkdirRequest
+	(*MkdirResponse)(nil),          // 13: blueguy.v1.MkdirResponse
+	(*RemoveRequest)(nil),          // 14: blueguy.v1.RemoveRequest
+	(*RemoveResponse)(nil),         // 15: blueguy.v1.RemoveResponse
+	(*RenameRequest)(nil),          // 16: blueguy.v1.RenameRequest
+	(*RenameResponse)(nil),         // 17: blueguy.v1.RenameResponse
+	(*ChmodRequest)(nil),           // 18: blueguy.v1.ChmodRequest
+	(*ChmodResponse)(nil),          // 19: blueguy.v1.ChmodResponse
+	(*TruncateRequest)(nil),        // 20: blueguy.v1.TruncateRequest
+	(*TruncateResponse)(nil),       // 21: blueguy.v1.TruncateResponse
+	(*WatchChangesRequest)(nil),    // 22: blueguy.v1.WatchChangesRequest
+	(*FileChangeEvent)(nil),        // 23: blueguy.v1.FileChangeEvent
+	(*ListWorkspacesRequest)(nil),  // 24: blueguy.v1.ListWorkspacesRequest
+	(*ListWorkspacesResponse)(nil), // 25: blueguy.v1.ListWorkspacesResponse
+	(*WorkspaceInfo)(nil),          // 26: blueguy.v1.WorkspaceInfo
+	(*GetStatusRequest)(nil),       // 27: blueguy.v1.GetStatusRequest
+	(*SessionStatus)(nil),          // 28: blueguy.v1.SessionStatus
+	(*Rotation)(nil),               // 29: blueguy.v1.Rotation
+	(*PushState)(nil),              // 30: blueguy.v1.PushState
+	(*WatchSessionRequest)(nil),    // 31: blueguy.v1.WatchSessionRequest
+	(*FlushedRequest)(nil),         // 32: blueguy.v1.FlushedRequest
+	(*FlushedResponse)(nil),        // 33: blueguy.v1.FlushedResponse
+	(*SessionEvent)(nil),           // 34: blueguy.v1.SessionEvent
+	(*SessionEnding)(nil),          // 35: blueguy.v1.SessionEnding
+	(*DriverChange)(nil),           // 36: blueguy.v1.DriverChange
+	(*SecretAlert)(nil),            // 37: blueguy.v1.SecretAlert
+	(*SecretFinding)(nil),          // 38: blueguy.v1.SecretFinding
+	(*LargeFiles)(nil),             // 39: blueguy.v1.LargeFiles
+	(*LargeFile)(nil),              // 40: blueguy.v1.LargeFile
+	(*GitStatusRequest)(nil),       // 41: blueguy.v1.GitStatusRequest
+	(*GitStatusResponse)(nil),      // 42: blueguy.v1.GitStatusResponse
+	(*FileStatus)(nil),             // 43: blueguy.v1.FileStatus
+	(*DiffRequest)(nil),            // 44: blueguy.v1.DiffRequest
+	(*DiffResponse)(nil),           // 45: blueguy.v1.DiffResponse
+	(*LogRequest)(nil),             // 46: blueguy.v1.LogRequest
+	(*LogResponse)(nil),            // 47: blueguy.v1.LogResponse
+	(*CommitInfo)(nil),             // 48: blueguy.v1.CommitInfo
+	(*BlameRequest)(nil),           // 49: blueguy.v1.BlameRequest
+	(*BlameResponse)(nil),          // 50: blueguy.v1.BlameResponse
+	(*BlameLine)(nil),              // 51: blueguy.v1.BlameLine
+	(*CheckpointRequest)(nil),      // 52: blueguy.v1.CheckpointRequest
+	(*CheckpointResponse)(nil),     // 53: blueguy.v1.CheckpointResponse
+	(*RollbackRequest)(nil),        // 54: blueguy.v1.RollbackRequest
+	(*RollbackResponse)(nil),       // 55: blueguy.v1.RollbackResponse
+	(*HistoryRequest)(nil),         // 56: blueguy.v1.HistoryRequest
+	(*HistoryReadRequest)(nil),     // 57: blueguy.v1.HistoryReadRequest
+	(*ControlStatusRequest)(nil),   // 58: blueguy.v1.ControlStatusRequest
+	(*ControlStatus)(nil),          // 59: blueguy.v1.ControlStatus
+	(*MountInfo)(nil),              // 60: blueguy.v1.MountInfo
+	(*ListClientsRequest)(nil),     // 61: blueguy.v1.ListClientsRequest
+	(*ListClientsResponse)(nil),    // 62: blueguy.v1.ListClientsResponse
+	(*ClientInfo)(nil),             // 63: blueguy.v1.ClientInfo
+	(*StopRequest)(nil),            // 64: blueguy.v1.StopRequest
+	(*StopResponse)(nil),           // 65: blueguy.v1.StopResponse
+	(*ReloadConfigRequest)(nil),    // 66: blueguy.v1.ReloadConfigRequest
+	(*ReloadConfigResponse)(nil),   // 67: blueguy.v1.ReloadConfigResponse
+	(*SetLogLevelRequest)(nil),     // 68: blueguy.v1.SetLogLevelRequest
+	(*SetLogLevelResponse)(nil),    // 69: blueguy.v1.SetLogLevelResponse
+	(*KickClientRequest)(nil),      // 70: blueguy.v1.KickClientRequest
+	(*KickClientResponse)(nil),     // 71: blueguy.v1.KickClientResponse
+	(*JoinRequest)(nil),            // 72: blueguy.v1.JoinRequest
+	(*LeaveRequest)(nil),           // 73: blueguy.v1.LeaveRequest
+	(*LeaveResponse)(nil),          // 74: blueguy.v1.LeaveResponse
+	(*ListMountsRequest)(nil),      // 75: blueguy.v1.ListMountsRequest
+	(*ListMountsResponse)(nil),     // 76: blueguy.v1.ListMountsResponse
 }
 var file_blueguy_proto_depIdxs = []int32{
 	1,  // 0: blueguy.v1.StatResponse.info:type_name -> blueguy.v1.FileInfo
 	1,  // 1: blueguy.v1.ReadDirResponse.entries:type_name -> blueguy.v1.FileInfo
 	0,  // 2: blueguy.v1.FileChangeEvent.type:type_name -> blueguy.v1.ChangeType
-	2,  // 3: blueguy.v1.FileService.Stat:input_type -> blueguy.v1.StatRequest
-	4,  // 4: blueguy.v1.FileService.ReadFile:input_type -> blueguy.v1.ReadFileRequest
-	6,  // 5: blueguy.v1.FileService.WriteFile:input_type -> blueguy.v1.WriteFileRequest
-	8,  // 6: blueguy.v1.FileService.ReadDir:input_type -> blueguy.v1.ReadDirRequest
-	10, // 7: blueguy.v1.FileService.Create:input_type -> blueguy.v1.CreateRequest
-	12, // 8: blueguy.v1.FileService.Mkdir:input_type -> blueguy.v1.MkdirRequest
-	14, // 9: blueguy.v1.FileService.Remove:input_type -> blueguy.v1.RemoveRequest
-	16, // 10: blueguy.v1.FileService.Rename:input_type -> blueguy.v1.RenameRequest
-	18, // 11: blueguy.v1.FileService.Chmod:input_type -> blueguy.v1.ChmodRequest
-	20, // 12: blueguy.v1.FileService.Truncate:input_type -> blueguy.v1.TruncateRequest
-	22, // 13: blueguy.v1.FileService.WatchChanges:input_type -> blueguy.v1.WatchChangesRequest
-	3,  // 14: blueguy.v1.FileService.Stat:output_type -> blueguy.v1.StatResponse
-	5,  // 15: blueguy.v1.FileService.ReadFile:output_type -> blueguy.v1.ReadFileResponse
-	7,  // 16: blueguy.v1.FileService.WriteFile:output_type -> blueguy.v1.WriteFileResponse
-	9,  // 17: blueguy.v1.FileService.ReadDir:output_type -> blueguy.v1.ReadDirResponse
-	11, // 18: blueguy.v1.FileService.Create:output_type -> blueguy.v1.CreateResponse
-	13, // 19: blueguy.v1.FileService.Mkdir:output_type -> blueguy.v1.MkdirResponse
-	15, // 20: blueguy.v1.FileService.Remove:output_type -> blueguy.v1.RemoveResponse
-	17, // 21: blueguy.v1.FileService.Rename:output_type -> blueguy.v1.RenameResponse
-	19, // 22: blueguy.v1.FileService.Chmod:output_type -> blueguy.v1.ChmodResponse
-	21, // 23: blueguy.v1.FileService.Truncate:output_type -> blueguy.v1.TruncateResponse
-	23, // 24: blueguy.v1.FileService.WatchChanges:output_type -> blueguy.v1.FileChangeEvent
-	14, // [14:25] is the sub-list for method output_type
-	3,  // [3:14] is the sub-list for method input_type
-	3,  // [3:3] is the sub-list for extension type_name
-	3,  // [3:3] is the sub-list for extension extendee
-	0,  // [0:3] is the sub-list for field type_name
+	26, // 3: blueguy.v1.ListWorkspacesResponse.workspaces:type_name -> blueguy.v1.WorkspaceInfo
+	28, // 4: blueguy.v1.WorkspaceInfo.session:type_name -> blueguy.v1.SessionStatus
+	29, // 5: blueguy.v1.SessionStatus.rotation:type_name -> blueguy.v1.Rotation
+	30, // 6: blueguy.v1.SessionStatus.push:type_name -> blueguy.v1.PushState
+	36, // 7: blueguy.v1.SessionEvent.driver_change:type_name -> blueguy.v1.DriverChange
+	30, // 8: blueguy.v1.SessionEvent.push:type_name -> blueguy.v1.PushState
+	37, // 9: blueguy.v1.SessionEvent.secrets:type_name -> blueguy.v1.SecretAlert
+	39, // 10: blueguy.v1.SessionEvent.large_files:type_name -> blueguy.v1.LargeFiles
+	35, // 11: blueguy.v1.SessionEvent.ending:type_name -> blueguy.v1.SessionEnding
+	29, // 12: blueguy.v1.DriverChange.rotation:type_name -> blueguy.v1.Rotation
+	38, // 13: blueguy.v1.SecretAlert.findings:type_name -> blueguy.v1.SecretFinding
+	40, // 14: blueguy.v1.LargeFiles.files:type_name -> blueguy.v1.LargeFile
+	43, // 15: blueguy.v1.GitStatusResponse.files:type_name -> blueguy.v1.FileStatus
+	48, // 16: blueguy.v1.LogResponse.commits:type_name -> blueguy.v1.CommitInfo
+	51, // 17: blueguy.v1.BlameResponse.lines:type_name -> blueguy.v1.BlameLine
+	28, // 18: blueguy.v1.ControlStatus.session:type_name -> blueguy.v1.SessionStatus
+	60, // 19: blueguy.v1.ControlStatus.mounts:type_name -> blueguy.v1.MountInfo
+	26, // 20: blueguy.v1.ControlStatus.workspaces:type_name -> blueguy.v1.WorkspaceInfo
+	28, // 21: blueguy.v1.MountInfo.session:type_name -> blueguy.v1.SessionStatus
+	63, // 22: blueguy.v1.ListClientsResponse.clients:type_name -> blueguy.v1.ClientInfo
+	63, // 23: blueguy.v1.KickClientResponse.kicked:type_name -> blueguy.v1.ClientInfo
+	60, // 24: blueguy.v1.ListMountsResponse.mounts:type_name -> blueguy.v1.MountInfo
+	2,  // 25: blueguy.v1.FileService.Stat:input_type -> blueguy.v1.StatRequest
+	4,  // 26: blueguy.v1.FileService.ReadFile:input_type -> blueguy.v1.ReadFileRequest
+	6,  // 27: blueguy.v1.FileService.WriteFile:input_type -> blueguy.v1.WriteFileRequest
+	8,  // 28: blueguy.v1.FileService.ReadDir:input_type -> blueguy.v1.ReadDirRequest
+	10, // 29: blueguy.v1.FileService.Create:input_type -> blueguy.v1.CreateRequest
+	12, // 30: blueguy.v1.FileService.Mkdir:input_type -> blueguy.v1.MkdirRequest
+	14, // 31: blueguy.v1.FileService.Remove:input_type -> blueguy.v1.RemoveRequest
+	16, // 32: blueguy.v1.FileService.Rename:input_type -> blueguy.v1.RenameRequest
+	18, // 33: blueguy.v1.FileService.Chmod:input_type -> blueguy.v1.ChmodRequest
+	20, // 34: blueguy.v1.FileService.Truncate:input_type -> blueguy.v1.TruncateRequest
+	22, // 35: blueguy.v1.FileService.WatchChanges:input_type -> blueguy.v1.WatchChangesRequest
+	24, // 36: blueguy.v1.WorkspaceService.ListWorkspaces:input_type -> blueguy.v1.ListWorkspacesRequest
+	27, // 37: blueguy.v1.SessionService.GetStatus:input_type -> blueguy.v1.GetStatusRequest
+	31, // 38: blueguy.v1.SessionService.WatchSession:input_type -> blueguy.v1.WatchSessionRequest
+	32, // 39: blueguy.v1.SessionService.Flushed:input_type -> blueguy.v1.FlushedRequest
+	41, // 40: blueguy.v1.GitService.Status:input_type -> blueguy.v1.GitStatusRequest
+	44, // 41: blueguy.v1.GitService.Diff:input_type -> blueguy.v1.DiffRequest
+	46, // 42: blueguy.v1.GitService.Log:input_type -> blueguy.v1.LogRequest
+	49, // 43: blueguy.v1.GitService.Blame:input_type -> blueguy.v1.BlameRequest
+	52, // 44: blueguy.v1.GitService.Checkpoint:input_type -> blueguy.v1.CheckpointRequest
+	54, // 45: blueguy.v1.GitService.Rollback:input_type -> blueguy.v1.RollbackRequest
+	56, // 46: blueguy.v1.GitService.HistoryStat:input_type -> blueguy.v1.HistoryRequest
+	56, // 47: blueguy.v1.GitService.HistoryReadDir:input_type -> blueguy.v1.HistoryRequest
+	57, // 48: blueguy.v1.GitService.HistoryReadFile:input_type -> blueguy.v1.HistoryReadRequest
+	58, // 49: blueguy.v1.ControlService.Status:input_type -> blueguy.v1.ControlStatusRequest
+	61, // 50: blueguy.v1.ControlService.ListClients:input_type -> blueguy.v1.ListClientsRequest
+	52, // 51: blueguy.v1.ControlService.Checkpoint:input_type -> blueguy.v1.CheckpointRequest
+	64, // 52: blueguy.v1.ControlService.Stop:input_type -> blueguy.v1.StopRequest
+	66, // 53: blueguy.v1.ControlService.ReloadConfig:input_type -> blueguy.v1.ReloadConfigRequest
+	68, // 54: blueguy.v1.ControlService.SetLogLevel:input_type -> blueguy.v1.SetLogLevelRequest
+	70, // 55: blueguy.v1.ControlService.KickClient:input_type -> blueguy.v1.KickClientRequest
+	72, // 56: blueguy.v1.ControlService.Join:input_type -> blueguy.v1.JoinRequest
+	73, // 57: blueguy.v1.ControlService.Leave:input_type -> blueguy.v1.LeaveRequest
+	75, // 58: blueguy.v1.ControlService.ListMounts:input_type -> blueguy.v1.ListMountsRequest
+	3,  // 59: blueguy.v1.FileService.Stat:output_type -> blueguy.v1.StatResponse
+	5,  // 60: blueguy.v1.FileService.ReadFile:output_type -> blueguy.v1.ReadFileResponse
+	7,  // 61: blueguy.v1.FileService.WriteFile:output_type -> blueguy.v1.WriteFileResponse
+	9,  // 62: blueguy.v1.FileService.ReadDir:output_type -> blueguy.v1.ReadDirResponse
+	11, // 63: blueguy.v1.FileService.Create:output_type -> blueguy.v1.CreateResponse
+	13, // 64: blueguy.v1.FileService.Mkdir:output_type -> blueguy.v1.MkdirResponse
+	15, // 65: blueguy.v1.FileService.Remove:output_type -> blueguy.v1.RemoveResponse
+	17, // 66: blueguy.v1.FileService.Rename:output_type -> blueguy.v1.RenameResponse
+	19, // 67: blueguy.v1.FileService.Chmod:output_type -> blueguy.v1.ChmodResponse
+	21, // 68: blueguy.v1.FileService.Truncate:output_type -> blueguy.v1.TruncateResponse
+	23, // 69: blueguy.v1.FileService.WatchChanges:output_type -> blueguy.v1.FileChangeEvent
+	25, // 70: blueguy.v1.WorkspaceService.ListWorkspaces:output_type -> blueguy.v1.ListWorkspacesResponse
+	28, // 71: blueguy.v1.SessionService.GetStatus:output_type -> blueguy.v1.SessionStatus
+	34, // 72: blueguy.v1.SessionService.WatchSession:output_type -> blueguy.v1.SessionEvent
+	33, // 73: blueguy.v1.SessionService.Flushed:output_type -> blueguy.v1.FlushedResponse
+	42, // 74: blueguy.v1.GitService.Status:output_type -> blueguy.v1.GitStatusResponse
+	45, // 75: blueguy.v1.GitService.Diff:output_type -> blueguy.v1.DiffResponse
+	47, // 76: blueguy.v1.GitService.Log:output_type -> blueguy.v1.LogResponse
+	50, // 77: blueguy.v1.GitService.Blame:output_type -> blueguy.v1.BlameResponse
+	53, // 78: blueguy.v1.GitService.Checkpoint:output_type -> blueguy.v1.CheckpointResponse
+	55, // 79: blueguy.v1.GitService.Rollback:output_type -> blueguy.v1.RollbackResponse
+	3,  // 80: blueguy.v1.GitService.HistoryStat:output_type -> blueguy.v1.StatResponse
+	9,  // 81: blueguy.v1.GitService.HistoryReadDir:output_type -> blueguy.v1.ReadDirResponse
+	5,  // 82: blueguy.v1.GitService.HistoryReadFile:output_type -> blueguy.v1.ReadFileResponse
+	59, // 83: blueguy.v1.ControlService.Status:output_type -> blueguy.v1.ControlStatus
+	62, // 84: blueguy.v1.ControlService.ListClients:output_type -> blueguy.v1.ListClientsResponse
+	53, // 85: blueguy.v1.ControlService.Checkpoint:output_type -> blueguy.v1.CheckpointResponse
+	65, // 86: blueguy.v1.ControlService.Stop:output_type -> blueguy.v1.StopResponse
+	67, // 87: blueguy.v1.ControlService.ReloadConfig:output_type -> blueguy.v1.ReloadConfigResponse
+	69, // 88: blueguy.v1.ControlService.SetLogLevel:output_type -> blueguy.v1.SetLogLevelResponse
+	71, // 89: blueguy.v1.ControlService.KickClient:output_type -> blueguy.v1.KickClientResponse
+	60, // 90: blueguy.v1.ControlService.Join:output_type -> blueguy.v1.MountInfo
+	74, // 91: blueguy.v1.ControlService.Leave:output_type -> blueguy.v1.LeaveResponse
+	76, // 92: blueguy.v1.ControlService.ListMounts:output_type -> blueguy.v1.ListMountsResponse
+	59, // [59:93] is the sub-list for method output_type
+	25, // [25:59] is the sub-list for method input_type
+	25, // [25:25] is the sub-list for extension type_name
+	25, // [25:25] is the sub-list for extension extendee
+	0,  // [0:25] is the sub-list for field type_name
 }
 
 func init() { file_blueguy_proto_init() }
@@ -1310,15 +4672,22 @@ func file_blueguy_proto_init() {
 	if File_blueguy_proto != nil {
 		return
 	}
+	file_blueguy_proto_msgTypes[33].OneofWrappers = []any{
+		(*SessionEvent_DriverChange)(nil),
+		(*SessionEvent_Push)(nil),
+		(*SessionEvent_Secrets)(nil),
+		(*SessionEvent_LargeFiles)(nil),
+		(*SessionEvent_Ending)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_blueguy_proto_rawDesc), len(file_blueguy_proto_rawDesc)),
 			NumEnums:      1,
-			NumMessages:   23,
+			NumMessages:   76,
 			NumExtensions: 0,
-			NumServices:   1,
+			NumServices:   5,
 		},
 		GoTypes:           file_blueguy_proto_goTypes,
 		DependencyIndexes: file_blueguy_proto_depIdxs,
@@ -0,0 +1,62 @@
+// Package workspace carries which of a host's workspaces a client request
+// is for, across gRPC calls.
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// mdName is the metadata key for the workspace name. The -bin suffix makes
+// gRPC base64 the value, so any name survives the trip.
+const mdName = "blueguy-workspace-bin"
+
+// Check reports whether name can name a workspace: it becomes a directory
+// under a client's mount, so it can't be empty, a dot name or contain a
+// slash.
+func Check(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid workspace name %q", name)
+	}
+	return nil
+}
+
+// NewOutgoingContext attaches the workspace name to ctx for an outgoing
+// gRPC call.
+func NewOutgoingContext(ctx context.Context, name string) context.Context {
+	if name == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, mdName, name)
+}
+
+// FromIncomingContext extracts the workspace a call is for on the server
+// side, empty if it doesn't say.
+func FromIncomingContext(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if vals := md.Get(mdName); len(vals) > 0 {
+		return vals[0]
+	}
+	return ""
+}
+
+// UnaryClientInterceptor stamps name on every unary call.
+func UnaryClientInterceptor(name string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(NewOutgoingContext(ctx, name), method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor stamps name on every streaming call.
+func StreamClientInterceptor(name string) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(NewOutgoingContext(ctx, name), desc, cc, method, opts...)
+	}
+}